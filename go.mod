@@ -2,11 +2,15 @@ module github.com/rptl8sr/calculate-service
 
 go 1.23.2
 
+require (
+	github.com/go-chi/chi/v5 v5.2.0
+	github.com/ilyakaznacheev/cleanenv v1.5.0
+	github.com/joho/godotenv v1.5.1
+	github.com/mattn/go-sqlite3 v1.14.24
+)
+
 require (
 	github.com/BurntSushi/toml v1.4.0 // indirect
-	github.com/go-chi/chi/v5 v5.2.0 // indirect
-	github.com/ilyakaznacheev/cleanenv v1.5.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	olympos.io/encoding/edn v0.0.0-20201019073823-d3554ca0b0a3 // indirect
 )
@@ -2,19 +2,24 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"calculate-service/internal/config"
 	"calculate-service/internal/controller"
 	"calculate-service/internal/logger"
+	"calculate-service/internal/models"
 	"calculate-service/internal/router"
+	"calculate-service/internal/storage"
 )
 
 type app struct {
-	server *http.Server
+	server  *http.Server
+	storage storage.Storage
 }
 
 type App interface {
@@ -40,18 +45,56 @@ func MustLoad() (App, error) {
 		)
 	}
 
-	ctrl := controller.New()
-	r := router.New(ctrl, cfg.App.APIVersion)
+	store, err := storage.New(cfg.DB.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl := controller.New(store, cfg.Calculator)
+	if cfg.Calculator.RatesFile != "" {
+		if err := loadRates(ctrl, cfg.Calculator.RatesFile); err != nil {
+			return nil, err
+		}
+	}
+
+	r := router.New(ctrl, cfg.App.APIVersion, cfg.App.AdminToken)
 
 	srv := &http.Server{
 		Handler: r,
 		Addr:    fmt.Sprintf(":%d", cfg.App.Port),
 	}
 
-	return &app{server: srv}, nil
+	return &app{server: srv, storage: store}, nil
+}
+
+// loadRates reads an exchange-rate table from a JSON file into the controller.
+func loadRates(ctrl controller.Controller, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rates: %w", err)
+	}
+
+	var table models.RateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("parse rates %s: %w", path, err)
+	}
+
+	table, err = ctrl.SetRates(context.Background(), table)
+	if err != nil {
+		return fmt.Errorf("load rates %s: %w", path, err)
+	}
+	logger.Info("Exchange rates loaded", "base", table.Base, "currencies", len(table.Rates))
+
+	return nil
 }
 
 func (a *app) Run(ctx context.Context) error {
+	defer func() {
+		if err := a.storage.Close(); err != nil {
+			logger.Error("Error closing storage", "error", err)
+		}
+	}()
+
 	go func() {
 		<-ctx.Done()
 
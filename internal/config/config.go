@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	_ "github.com/joho/godotenv/autoload"
+
+	"calculate-service/pkg/calculator"
 )
 
 type Mode string
@@ -17,7 +20,9 @@ var (
 )
 
 type Config struct {
-	App App
+	App        App
+	DB         DB
+	Calculator Calculator
 }
 
 type App struct {
@@ -27,6 +32,26 @@ type App struct {
 	Name       string     `env:"APP_NAME" env-default:"Calculate"`
 	Mode       Mode       `env:"APP_MODE" env-default:"production"`
 	LogLevel   slog.Level `env:"LOG_LEVEL" env-default:"info"`
+	// AdminToken guards the admin endpoints; they are disabled without it.
+	AdminToken string `env:"ADMIN_TOKEN"`
+}
+
+type DB struct {
+	Path string `env:"DB_PATH" env-default:"calculate.db"`
+}
+
+type Calculator struct {
+	MaxDepth       int                    `env:"CALC_MAX_DEPTH" env-default:"100"`
+	MaxEvaluations int                    `env:"CALC_MAX_EVALUATIONS" env-default:"1000000"`
+	Strict         bool                   `env:"CALC_STRICT" env-default:"false"`
+	Percent        calculator.PercentMode `env:"CALC_PERCENT" env-default:"plain"`
+	Complex        bool                   `env:"CALC_COMPLEX" env-default:"false"`
+	SessionTTL     time.Duration          `env:"SESSION_TTL" env-default:"30m"`
+	SessionLimit   int                    `env:"SESSION_LIMIT" env-default:"10000"`
+	// RatesFile is a JSON exchange-rate table loaded at start, in the format of the admin rates endpoint.
+	RatesFile string `env:"CALC_RATES_FILE"`
+	// Holidays are the dates skipped by the business day functions, e.g. "2026-01-01,2026-12-25".
+	Holidays calculator.Holidays `env:"CALC_HOLIDAYS"`
 }
 
 func MustLoad() (*Config, error) {
@@ -45,5 +70,25 @@ func MustLoad() (*Config, error) {
 		return nil, fmt.Errorf("invalid PORT env value: %d", config.App.Port)
 	}
 
+	if config.DB.Path == "" {
+		return nil, fmt.Errorf("invalid DB_PATH env value: empty")
+	}
+
+	if config.Calculator.MaxDepth <= 0 || config.Calculator.MaxDepth > 10000 {
+		return nil, fmt.Errorf("invalid CALC_MAX_DEPTH env value: %d", config.Calculator.MaxDepth)
+	}
+
+	if config.Calculator.MaxEvaluations <= 0 {
+		return nil, fmt.Errorf("invalid CALC_MAX_EVALUATIONS env value: %d", config.Calculator.MaxEvaluations)
+	}
+
+	if config.Calculator.SessionTTL <= 0 {
+		return nil, fmt.Errorf("invalid SESSION_TTL env value: %s", config.Calculator.SessionTTL)
+	}
+
+	if config.Calculator.SessionLimit <= 0 {
+		return nil, fmt.Errorf("invalid SESSION_LIMIT env value: %d", config.Calculator.SessionLimit)
+	}
+
 	return &config, nil
 }
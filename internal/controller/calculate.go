@@ -3,20 +3,139 @@ package controller
 import (
 	"context"
 	"errors"
+	"time"
 
+	"calculate-service/internal/logger"
+	"calculate-service/internal/models"
 	"calculate-service/pkg/calculator"
 )
 
-func (c *controller) Calculate(_ context.Context, expression string) (float64, error) {
-	res, err := calculator.Evaluate(expression)
+// ErrSessionNotFound is the error of a request in a session that never existed or has expired.
+var ErrSessionNotFound = errors.New("session not found or expired")
+
+func (c *controller) Calculate(ctx context.Context, req models.CalculateRequest) (models.CalculateResult, error) {
+	options := c.options
+	if req.Strict != nil {
+		options.Strict = *req.Strict
+	}
+	if req.Percent != "" {
+		mode, err := calculator.ParsePercentMode(req.Percent)
+		if err != nil {
+			return models.CalculateResult{}, NewRequestError(err)
+		}
+		options.Percent = mode
+	}
+	if req.Complex != nil {
+		options.Complex = *req.Complex
+	}
+	if req.Interval {
+		options.Interval = true
+		options.Complex = false
+	}
+	if req.Uncertainty {
+		options.Uncertainty = true
+		options.Complex = false
+	}
+	if req.Holidays != nil {
+		options.Holidays = req.Holidays
+	}
+	if req.Integer.Enabled() {
+		if err := req.Integer.Validate(); err != nil {
+			return models.CalculateResult{}, NewRequestError(err)
+		}
+		options.Integer = req.Integer
+	}
+	options.Rates = c.rates.Load()
+
+	env := calculator.NewEnvironment(options)
+	var s *session
+	if req.Session != "" {
+		if s = c.sessions.get(req.Session); s == nil {
+			return models.CalculateResult{}, NewNotFoundError(ErrSessionNotFound)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		env = s.env
+		env.SetOptions(options)
+	}
+
+	res, err := env.EvaluateValue(req.Expression)
+	if s == nil && err == nil && !env.Empty() {
+		id, addErr := c.sessions.add(env)
+		if addErr != nil {
+			return models.CalculateResult{}, NewServerError(addErr)
+		}
+		req.Session = id
+	}
+
+	c.record(ctx, req, options, res, err)
 
 	if err != nil {
 		if errors.Is(err, calculator.NewErrUnknown()) {
-			return 0, NewServerError(err)
+			return models.CalculateResult{}, NewServerError(err)
 		} else {
-			return 0, NewRequestError(err)
+			return models.CalculateResult{}, NewRequestError(err)
+		}
+	}
+
+	result := models.CalculateResult{Value: res, Integrals: env.Integrals(), Session: req.Session}
+	if used := env.UsedRates(); len(used) > 0 {
+		result.Rates = rateTable(options.Rates.Base(), used)
+	}
+	if req.Variables {
+		result.Variables = env.Values()
+	}
+
+	return result, nil
+}
+
+// record stores the calculation in the history with the options it was evaluated with.
+// A storage failure is logged and does not fail the request.
+func (c *controller) record(ctx context.Context, req models.CalculateRequest, options calculator.Options, res calculator.Value, err error) {
+	calc := models.Calculation{
+		Expression: req.Expression,
+		RequestID:  req.RequestID,
+		Client:     req.Client,
+		CreatedAt:  time.Now().UTC(),
+		Options:    calculationOptions(options, req.Session),
+	}
+
+	if err != nil {
+		calc.Error = err.Error()
+		calc.ErrorType = calculator.ErrUnknown.String()
+
+		var calcErr calculator.CalcError
+		if errors.As(err, &calcErr) {
+			calc.ErrorType = calcErr.Type.String()
 		}
+	} else {
+		calc.Result = res.String()
 	}
 
-	return res, nil
+	if _, saveErr := c.storage.SaveCalculation(context.WithoutCancel(ctx), calc); saveErr != nil {
+		logger.Error("Failed to save calculation", "error", saveErr, "requestID", req.RequestID)
+	}
+}
+
+// calculationOptions are the options of the history entry of a calculation evaluated with options in a session.
+func calculationOptions(options calculator.Options, session string) models.CalculationOptions {
+	recorded := models.CalculationOptions{
+		Percent:     options.Percent.String(),
+		Strict:      options.Strict,
+		Complex:     options.Complex,
+		Interval:    options.Interval,
+		Uncertainty: options.Uncertainty,
+		Session:     session,
+	}
+	if options.Integer.Enabled() {
+		recorded.Integer = &models.IntegerOptions{
+			Bits:     options.Integer.Bits,
+			Unsigned: options.Integer.Unsigned,
+			Wrap:     options.Integer.Wrap,
+		}
+	}
+	for _, d := range options.Holidays {
+		recorded.Holidays = append(recorded.Holidays, d.Format(time.DateOnly))
+	}
+	return recorded
 }
@@ -2,14 +2,58 @@ package controller
 
 import (
 	"context"
+	"sync/atomic"
+
+	"calculate-service/internal/config"
+	"calculate-service/internal/models"
+	"calculate-service/internal/storage"
+	"calculate-service/pkg/calculator"
 )
 
-type controller struct{}
+type controller struct {
+	storage  storage.Storage
+	options  calculator.Options
+	sessions *sessions
+	rates    atomic.Pointer[calculator.Rates]
+}
 
 type Controller interface {
-	Calculate(ctx context.Context, expression string) (float64, error)
+	Calculate(ctx context.Context, req models.CalculateRequest) (models.CalculateResult, error)
+	History(ctx context.Context, filter models.HistoryFilter) (models.HistoryPage, error)
+	DeleteHistory(ctx context.Context, filter models.HistoryFilter) (int64, error)
+	DeleteHistoryItem(ctx context.Context, id int64) error
+
+	CreateFormula(ctx context.Context, f models.Formula) (models.Formula, error)
+	UpdateFormula(ctx context.Context, f models.Formula) (models.Formula, error)
+	Formula(ctx context.Context, name string, version int) (models.Formula, error)
+	Formulas(ctx context.Context) ([]models.Formula, error)
+	FormulaVersions(ctx context.Context, name string) ([]models.Formula, error)
+	DeleteFormula(ctx context.Context, name string) error
+	EvaluateFormula(ctx context.Context, req models.FormulaRequest) (float64, models.Formula, error)
+
+	Simplify(ctx context.Context, req models.SimplifyRequest) (*calculator.Expression, error)
+	Equivalent(ctx context.Context, req models.EquivalenceRequest) (calculator.Equivalence, error)
+	Derivative(ctx context.Context, req models.DerivativeRequest) (models.DerivativeResult, error)
+	Sample(ctx context.Context, req models.SampleRequest) (calculator.Sampling, error)
+	Polynomial(ctx context.Context, req models.PolynomialRequest) (models.PolynomialResult, error)
+
+	Rates(ctx context.Context) (models.RateTable, error)
+	SetRates(ctx context.Context, table models.RateTable) (models.RateTable, error)
 }
 
-func New() Controller {
-	return &controller{}
+func New(s storage.Storage, cfg config.Calculator) Controller {
+	options := calculator.Options{
+		MaxDepth:       cfg.MaxDepth,
+		MaxEvaluations: cfg.MaxEvaluations,
+		Strict:         cfg.Strict,
+		Percent:        cfg.Percent,
+		Complex:        cfg.Complex,
+		Holidays:       cfg.Holidays,
+	}
+
+	return &controller{
+		storage:  s,
+		options:  options,
+		sessions: newSessions(cfg.SessionTTL, cfg.SessionLimit),
+	}
 }
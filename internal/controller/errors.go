@@ -5,8 +5,10 @@ import "fmt"
 type ErrorType string
 
 const (
-	ErrRequest ErrorType = "request error"
-	ErrServer  ErrorType = "server error"
+	ErrRequest  ErrorType = "request error"
+	ErrServer   ErrorType = "server error"
+	ErrNotFound ErrorType = "not found"
+	ErrConflict ErrorType = "conflict"
 )
 
 type CtrlError struct {
@@ -31,3 +33,17 @@ func NewServerError(err error) CtrlError {
 		Type: ErrServer,
 	}
 }
+
+func NewNotFoundError(err error) CtrlError {
+	return CtrlError{
+		Err:  err,
+		Type: ErrNotFound,
+	}
+}
+
+func NewConflictError(err error) CtrlError {
+	return CtrlError{
+		Err:  err,
+		Type: ErrConflict,
+	}
+}
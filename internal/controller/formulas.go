@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"calculate-service/internal/models"
+	"calculate-service/internal/storage"
+	"calculate-service/pkg/calculator"
+)
+
+var (
+	ErrFormulaNotFound = errors.New("formula not found")
+	ErrFormulaExists   = errors.New("formula already exists")
+)
+
+func (c *controller) CreateFormula(ctx context.Context, f models.Formula) (models.Formula, error) {
+	if err := c.validateFormula(f); err != nil {
+		return models.Formula{}, err
+	}
+
+	f.UpdatedAt = time.Now().UTC()
+
+	f, err := c.storage.CreateFormula(ctx, f)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return models.Formula{}, NewConflictError(ErrFormulaExists)
+	}
+	if err != nil {
+		return models.Formula{}, NewServerError(err)
+	}
+
+	return f, nil
+}
+
+func (c *controller) UpdateFormula(ctx context.Context, f models.Formula) (models.Formula, error) {
+	if err := c.validateFormula(f); err != nil {
+		return models.Formula{}, err
+	}
+
+	f.UpdatedAt = time.Now().UTC()
+
+	f, err := c.storage.UpdateFormula(ctx, f)
+	if err != nil {
+		return models.Formula{}, formulaStorageError(err)
+	}
+
+	return f, nil
+}
+
+func (c *controller) Formula(ctx context.Context, name string, version int) (models.Formula, error) {
+	f, err := c.storage.GetFormula(ctx, name, version)
+	if err != nil {
+		return models.Formula{}, formulaStorageError(err)
+	}
+
+	return f, nil
+}
+
+func (c *controller) Formulas(ctx context.Context) ([]models.Formula, error) {
+	formulas, err := c.storage.ListFormulas(ctx)
+	if err != nil {
+		return nil, NewServerError(err)
+	}
+
+	return formulas, nil
+}
+
+func (c *controller) FormulaVersions(ctx context.Context, name string) ([]models.Formula, error) {
+	formulas, err := c.storage.FormulaVersions(ctx, name)
+	if err != nil {
+		return nil, formulaStorageError(err)
+	}
+
+	return formulas, nil
+}
+
+func (c *controller) DeleteFormula(ctx context.Context, name string) error {
+	if err := c.storage.DeleteFormula(ctx, name); err != nil {
+		return formulaStorageError(err)
+	}
+
+	return nil
+}
+
+// EvaluateFormula evaluates a saved formula. Every declared parameter needs a value and no other values are accepted.
+// The evaluation is recorded in the history like any other calculation.
+func (c *controller) EvaluateFormula(ctx context.Context, req models.FormulaRequest) (float64, models.Formula, error) {
+	f, err := c.Formula(ctx, req.Name, req.Version)
+	if err != nil {
+		return 0, models.Formula{}, err
+	}
+
+	env := calculator.NewEnvironment(c.options)
+	args := make([]string, 0, len(f.Params))
+	for _, p := range f.Params {
+		v, ok := req.Values[p]
+		if !ok {
+			return 0, f, NewRequestError(fmt.Errorf("missing value for parameter %q", p))
+		}
+		env.Set(p, v)
+		args = append(args, fmt.Sprintf("%s=%g", p, v))
+	}
+	if len(req.Values) != len(f.Params) {
+		for name := range req.Values {
+			if !slices.Contains(f.Params, name) {
+				return 0, f, NewRequestError(fmt.Errorf("unknown parameter %q", name))
+			}
+		}
+	}
+
+	res, err := env.Evaluate(f.Expression)
+
+	c.record(ctx, models.CalculateRequest{
+		Expression: fmt.Sprintf("%s@v%d(%s)", f.Name, f.Version, strings.Join(args, ", ")),
+		RequestID:  req.RequestID,
+		Client:     req.Client,
+	}, c.options, calculator.Real(res), err)
+
+	if err != nil {
+		if errors.Is(err, calculator.NewErrUnknown()) {
+			return 0, f, NewServerError(err)
+		}
+		return 0, f, NewRequestError(err)
+	}
+
+	return res, f, nil
+}
+
+// validateFormula checks the formula name and parameters and parses its expression.
+// validateFormula checks a formula with the options it is evaluated with. A formula whose result is not a number,
+// such as a date, is rejected as well: it is tried with every parameter 1, as the types do not depend on the values.
+func (c *controller) validateFormula(f models.Formula) error {
+	if err := calculator.ValidateName(f.Name); err != nil {
+		return NewRequestError(err)
+	}
+	env := calculator.NewEnvironment(c.options)
+	if err := env.Validate(f.Expression, f.Params...); err != nil {
+		return NewRequestError(err)
+	}
+
+	for _, p := range f.Params {
+		env.Set(p, 1)
+	}
+	// Other errors depend on the values: ln(x - 1) fails only for x = 1.
+	var calcErr calculator.CalcError
+	if _, err := env.Evaluate(f.Expression); errors.As(err, &calcErr) && calcErr.Type == calculator.ErrTypeMismatch {
+		return NewRequestError(err)
+	}
+
+	return nil
+}
+
+func formulaStorageError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return NewNotFoundError(ErrFormulaNotFound)
+	}
+	return NewServerError(err)
+}
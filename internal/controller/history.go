@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"calculate-service/internal/models"
+	"calculate-service/internal/storage"
+)
+
+var ErrHistoryNotFound = errors.New("history item not found")
+
+func (c *controller) History(ctx context.Context, filter models.HistoryFilter) (models.HistoryPage, error) {
+	items, total, err := c.storage.ListCalculations(ctx, filter)
+	if err != nil {
+		return models.HistoryPage{}, NewServerError(err)
+	}
+
+	return models.HistoryPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+func (c *controller) DeleteHistory(ctx context.Context, filter models.HistoryFilter) (int64, error) {
+	n, err := c.storage.DeleteCalculations(ctx, filter)
+	if err != nil {
+		return 0, NewServerError(err)
+	}
+
+	return n, nil
+}
+
+func (c *controller) DeleteHistoryItem(ctx context.Context, id int64) error {
+	err := c.storage.DeleteCalculation(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return NewNotFoundError(ErrHistoryNotFound)
+	}
+	if err != nil {
+		return NewServerError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+var ErrRatesNotFound = errors.New("no exchange rates loaded")
+
+// Rates returns the current exchange-rate table.
+func (c *controller) Rates(_ context.Context) (models.RateTable, error) {
+	rates := c.rates.Load()
+	if rates == nil {
+		return models.RateTable{}, NewNotFoundError(ErrRatesNotFound)
+	}
+
+	return rateTable(rates.Base(), rates.Rates()), nil
+}
+
+// SetRates replaces the exchange-rate table. Calculations already running finish with the previous one.
+func (c *controller) SetRates(_ context.Context, table models.RateTable) (models.RateTable, error) {
+	rates := make([]calculator.Rate, len(table.Rates))
+	for i, r := range table.Rates {
+		rate, err := calculator.ParseDecimal(r.Rate)
+		if err != nil {
+			return models.RateTable{}, NewRequestError(fmt.Errorf("rate of %s: %w", r.Currency, err))
+		}
+		rates[i] = calculator.Rate{Currency: r.Currency, Rate: rate, UpdatedAt: r.UpdatedAt.UTC()}
+	}
+
+	t, err := calculator.NewRates(table.Base, rates)
+	if err != nil {
+		return models.RateTable{}, NewRequestError(err)
+	}
+	c.rates.Store(t)
+
+	return rateTable(t.Base(), t.Rates()), nil
+}
+
+func rateTable(base string, rates []calculator.Rate) models.RateTable {
+	table := models.RateTable{Base: base, Rates: make([]models.Rate, len(rates))}
+	for i, r := range rates {
+		table.Rates[i] = models.Rate{
+			Currency:  r.Currency,
+			Rate:      calculator.FormatDecimal(r.Rate),
+			UpdatedAt: r.UpdatedAt,
+		}
+	}
+
+	return table
+}
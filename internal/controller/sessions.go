@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"calculate-service/pkg/calculator"
+)
+
+// session keeps a calculator environment between requests, so functions defined in one request
+// can be called in the next ones.
+type session struct {
+	mu       sync.Mutex
+	env      *calculator.Environment
+	lastUsed time.Time
+}
+
+// sessions holds at most limit sessions. A session is only created once a request defines something, and its id
+// is chosen by the server, so a client can neither guess another client's session nor pick its own id.
+type sessions struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	limit int
+	items map[string]*session
+}
+
+func newSessions(ttl time.Duration, limit int) *sessions {
+	return &sessions{
+		ttl:   ttl,
+		limit: limit,
+		items: make(map[string]*session),
+	}
+}
+
+// get returns the session with the given id, or nil if there is none. Sessions unused for longer than
+// the TTL are dropped.
+func (s *sessions) get(id string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil
+	}
+	item.lastUsed = now
+
+	return item
+}
+
+// add stores a new session with the environment of its first request and returns its id. If the sessions are full,
+// the least recently used one is dropped.
+func (s *sessions) add(env *calculator.Environment) (string, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+
+	if len(s.items) >= s.limit {
+		var oldest string
+		for key, item := range s.items {
+			if oldest == "" || item.lastUsed.Before(s.items[oldest].lastUsed) {
+				oldest = key
+			}
+		}
+		delete(s.items, oldest)
+	}
+	s.items[id] = &session{env: env, lastUsed: now}
+
+	return id, nil
+}
+
+// newSessionID returns 128 random bits as a hexadecimal string.
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// expire drops the sessions unused for longer than the TTL.
+func (s *sessions) expire(now time.Time) {
+	for key, item := range s.items {
+		if now.Sub(item.lastUsed) > s.ttl {
+			delete(s.items, key)
+		}
+	}
+}
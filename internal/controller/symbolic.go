@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+func (c *controller) Simplify(_ context.Context, req models.SimplifyRequest) (*calculator.Expression, error) {
+	expr, err := calculator.Parse(req.Expression)
+	if err != nil {
+		return nil, calculationError(err)
+	}
+
+	return expr.Simplify(), nil
+}
+
+func (c *controller) Equivalent(_ context.Context, req models.EquivalenceRequest) (calculator.Equivalence, error) {
+	left, err := calculator.Parse(req.Left)
+	if err != nil {
+		return calculator.Equivalence{}, calculationError(err)
+	}
+	right, err := calculator.Parse(req.Right)
+	if err != nil {
+		return calculator.Equivalence{}, calculationError(err)
+	}
+
+	eq, err := calculator.Equivalent(left, right, calculator.EquivalenceOptions{
+		Tolerance:  req.Tolerance,
+		Samples:    req.Samples,
+		Seed:       req.Seed,
+		Evaluation: c.options,
+	})
+	if err != nil {
+		return calculator.Equivalence{}, calculationError(err)
+	}
+
+	return eq, nil
+}
+
+func (c *controller) Derivative(_ context.Context, req models.DerivativeRequest) (models.DerivativeResult, error) {
+	expr, err := calculator.Parse(req.Expression)
+	if err != nil {
+		return models.DerivativeResult{}, calculationError(err)
+	}
+
+	d, err := expr.Derivative(req.Variable)
+	if err != nil {
+		return models.DerivativeResult{}, calculationError(err)
+	}
+
+	result := models.DerivativeResult{Derivative: d}
+	if req.At != nil {
+		env := calculator.NewEnvironment(c.options)
+		env.Set(req.Variable, *req.At)
+		result.Value, err = env.EvaluateExpression(d)
+		if err != nil {
+			return models.DerivativeResult{}, calculationError(err)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *controller) Sample(_ context.Context, req models.SampleRequest) (calculator.Sampling, error) {
+	expr, err := calculator.Parse(req.Expression)
+	if err != nil {
+		return calculator.Sampling{}, calculationError(err)
+	}
+
+	sampling, err := calculator.NewEnvironment(c.options).Sample(expr, req.Axes, req.Steps)
+	if err != nil {
+		return calculator.Sampling{}, calculationError(err)
+	}
+
+	return sampling, nil
+}
+
+func (c *controller) Polynomial(_ context.Context, req models.PolynomialRequest) (models.PolynomialResult, error) {
+	env := calculator.NewEnvironment(c.options)
+	p, err := polynomial(env, req.Expression, req.Variable)
+	if err != nil {
+		return models.PolynomialResult{}, err
+	}
+
+	result := models.PolynomialResult{Polynomial: p}
+	if p.Degree() >= 0 {
+		if fz, err := p.Factor(); err == nil {
+			result.Factorization = &fz
+		}
+		if result.Roots, err = p.Roots(); err != nil {
+			return models.PolynomialResult{}, calculationError(err)
+		}
+	}
+
+	if req.Divisor != "" {
+		d, err := polynomial(env, req.Divisor, req.Variable)
+		if err != nil {
+			return models.PolynomialResult{}, err
+		}
+		quo, rem, err := p.DivMod(d)
+		if err != nil {
+			return models.PolynomialResult{}, calculationError(err)
+		}
+		gcd, err := p.GCD(d)
+		if err != nil {
+			return models.PolynomialResult{}, calculationError(err)
+		}
+		result.Quotient, result.Remainder, result.GCD = &quo, &rem, &gcd
+	}
+
+	if req.At != nil {
+		value := p.Eval(*req.At)
+		result.Value = &value
+	}
+
+	return result, nil
+}
+
+func polynomial(env *calculator.Environment, expression, variable string) (calculator.Polynomial, error) {
+	expr, err := calculator.Parse(expression)
+	if err != nil {
+		return calculator.Polynomial{}, calculationError(err)
+	}
+	p, err := env.Polynomial(expr, variable)
+	if err != nil {
+		return calculator.Polynomial{}, calculationError(err)
+	}
+	return p, nil
+}
+
+// calculationError wraps an error of the calculator: a failure of the calculator itself is a server error,
+// anything else is the fault of the request.
+func calculationError(err error) CtrlError {
+	if errors.Is(err, calculator.NewErrUnknown()) {
+		return NewServerError(err)
+	}
+	return NewRequestError(err)
+}
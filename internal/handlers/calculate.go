@@ -2,19 +2,111 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
-	"calculate-service/internal/controller"
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
 )
 
 type CalculatePayload struct {
 	Expression string `json:"expression"`
+	// Variables asks to return every variable bound after the evaluation.
+	Variables bool `json:"variables,omitempty"`
+	// Strict overrides the service's default for rejecting implicit multiplication.
+	Strict *bool `json:"strict,omitempty"`
+	// Percent overrides the service's default meaning of "%": "plain" or "contextual".
+	Percent string `json:"percent,omitempty"`
+	// Integer switches to fixed-width integer arithmetic.
+	Integer *IntegerPayload `json:"integer,omitempty"`
+	// Complex overrides the service's default for complex numbers, defining i and allowing sqrt(-4).
+	Complex *bool `json:"complex,omitempty"`
+	// Interval makes every number an interval, interval(1.9, 2.1) or 2 ± 0.1, and returns guaranteed bounds of the result.
+	Interval bool `json:"interval,omitempty"`
+	// Uncertainty makes 9.81 ± 0.02 a measured value with a standard uncertainty and returns the combined
+	// uncertainty of the result.
+	Uncertainty bool `json:"uncertainty,omitempty"`
+	// Format is the base of integer results: "dec" (default), "hex", "oct" or "bin".
+	Format string `json:"format,omitempty"`
+	// Holidays replace the service's holidays for the business day functions, e.g. ["2026-12-25"].
+	Holidays []string `json:"holidays,omitempty"`
+}
+
+type IntegerPayload struct {
+	// Bits is the width: 8, 16, 32 or 64.
+	Bits     int  `json:"bits"`
+	Unsigned bool `json:"unsigned,omitempty"`
+	// Wrap makes an overflow wrap around instead of failing.
+	Wrap bool `json:"wrap,omitempty"`
+}
+
+// integerFormats are the bases of the integer result formats.
+var integerFormats = map[string]int{
+	"":    10,
+	"dec": 10,
+	"hex": 16,
+	"oct": 8,
+	"bin": 2,
 }
 
 type CalculateResponse struct {
 	Result string `json:"result"`
+	// Complex holds the parts of a complex result.
+	Complex *ComplexResponse `json:"complex,omitempty"`
+	// Interval holds the bounds of an interval result.
+	Interval *IntervalResponse `json:"interval,omitempty"`
+	// Measurement holds the value and the combined standard uncertainty of a measured result.
+	Measurement *MeasurementResponse `json:"measurement,omitempty"`
+	// Quantity holds the number and the unit of a result with a unit of measurement.
+	Quantity *QuantityResponse `json:"quantity,omitempty"`
+	// Duration is a result of time, such as date("2026-03-10") - date("2026-03-01"), in ISO 8601: P9D.
+	Duration string `json:"duration,omitempty"`
+	// Money holds the amount and the currency of an amount of money.
+	Money *MoneyResponse `json:"money,omitempty"`
+	// List holds the items of a list result, such as the rows of amortize, as nested arrays of formatted values.
+	List []any `json:"list,omitempty"`
+	// Rates are the exchange rates used to convert between currencies, with the time they were set.
+	Rates     *models.RateTable `json:"rates,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	// Integrals are the integrals computed by integrate, with the estimates of their errors.
+	Integrals []IntegralResponse `json:"integrals,omitempty"`
+}
+
+type IntegralResponse struct {
+	Expression string  `json:"expression"`
+	Value      float64 `json:"value"`
+	// ErrorEstimate is the estimated absolute error of Value.
+	ErrorEstimate float64 `json:"error_estimate"`
+	Evaluations   int     `json:"evaluations"`
+}
+
+type ComplexResponse struct {
+	Real string `json:"real"`
+	Imag string `json:"imag"`
+}
+
+// IntervalResponse holds the bounds exactly, as the shortest numbers that read back as the same float64;
+// an unbounded side is "-Inf" or "+Inf".
+type IntervalResponse struct {
+	Lower string `json:"lower"`
+	Upper string `json:"upper"`
+}
+
+type MeasurementResponse struct {
+	Value       string `json:"value"`
+	Uncertainty string `json:"uncertainty"`
+}
+
+type QuantityResponse struct {
+	Value string `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+type MoneyResponse struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
 }
 
 type ResponseError struct {
@@ -22,59 +114,170 @@ type ResponseError struct {
 }
 
 func (h handler) Calculate(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	payload := CalculatePayload{}
 
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
-		responseError := ResponseError{Error: err.Error()}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(responseError)
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	defer r.Body.Close()
 
 	if payload.Expression == "" {
-		responseError := ResponseError{Error: "'expression' field is required."}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(responseError)
+		writeError(w, http.StatusBadRequest, "'expression' field is required.")
 		return
 	}
 
-	res, err := h.controller.Calculate(r.Context(), payload.Expression)
+	base, ok := integerFormats[payload.Format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "'format' must be one of dec, hex, oct or bin.")
+		return
+	}
+	if payload.Format != "" && payload.Integer == nil {
+		writeError(w, http.StatusBadRequest, "'format' requires 'integer'.")
+		return
+	}
 
-	if err != nil {
-		var ctrlErr controller.CtrlError
-		if errors.As(err, &ctrlErr) {
-
-			switch ctrlErr.Type {
-			case controller.ErrRequest:
-				responseError := ResponseError{Error: ctrlErr.Error()}
-				w.WriteHeader(http.StatusUnprocessableEntity)
-				json.NewEncoder(w).Encode(responseError)
-				return
-			case controller.ErrServer:
-				responseError := ResponseError{Error: http.StatusText(http.StatusInternalServerError)}
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(responseError)
+	req := newCalculateRequest(r, payload.Expression)
+	req.Variables = payload.Variables
+	req.Strict = payload.Strict
+	req.Percent = payload.Percent
+	req.Complex = payload.Complex
+	if payload.Integer != nil {
+		req.Integer = calculator.IntegerMode{
+			Bits:     payload.Integer.Bits,
+			Unsigned: payload.Integer.Unsigned,
+			Wrap:     payload.Integer.Wrap,
+		}
+		if !req.Integer.Enabled() {
+			writeError(w, http.StatusBadRequest, "'integer.bits' field is required.")
+			return
+		}
+	}
+	if payload.Interval {
+		if payload.Integer != nil || payload.Complex != nil && *payload.Complex {
+			writeError(w, http.StatusBadRequest, "'interval' cannot be combined with 'integer' or 'complex'.")
+			return
+		}
+		req.Interval = true
+	}
+	if payload.Uncertainty {
+		if payload.Integer != nil || payload.Interval || payload.Complex != nil && *payload.Complex {
+			writeError(w, http.StatusBadRequest, "'uncertainty' cannot be combined with 'integer', 'interval' or 'complex'.")
+			return
+		}
+		req.Uncertainty = true
+	}
+	if payload.Holidays != nil {
+		req.Holidays = calculator.Holidays{}
+		for _, day := range payload.Holidays {
+			d, err := time.Parse(time.DateOnly, day)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("'holidays' must be dates like 2026-12-25, got %q.", day))
 				return
 			}
+			req.Holidays = append(req.Holidays, d)
 		}
+	}
 
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	res, err := h.controller.Calculate(r.Context(), req)
+	if err != nil {
+		writeCtrlError(w, err)
 		return
 	}
+	if res.Session != "" {
+		w.Header().Set(SessionIDHeader, res.Session)
+	}
 
 	response := CalculateResponse{
-		Result: fmt.Sprintf("%f", res),
+		Result: formatValue(res.Value, base),
+	}
+	if c, ok := res.Value.(calculator.Complex); ok {
+		response.Complex = &ComplexResponse{
+			Real: fmt.Sprintf("%f", c.Real()),
+			Imag: fmt.Sprintf("%f", c.Imag()),
+		}
+	}
+	if x, ok := res.Value.(calculator.Interval); ok {
+		response.Interval = &IntervalResponse{
+			Lower: calculator.Real(x.Lower()).String(),
+			Upper: calculator.Real(x.Upper()).String(),
+		}
+	}
+	if m, ok := res.Value.(calculator.Uncertain); ok {
+		response.Measurement = &MeasurementResponse{
+			Value:       calculator.Real(m.Value()).String(),
+			Uncertainty: calculator.Real(m.Uncertainty()).String(),
+		}
+	}
+	if q, ok := res.Value.(calculator.Quantity); ok {
+		response.Quantity = &QuantityResponse{
+			Value: fmt.Sprintf("%f", q.Value()),
+			Unit:  q.Unit(),
+		}
+		response.Duration, _ = q.ISODuration()
+	}
+	if m, ok := res.Value.(calculator.Money); ok {
+		response.Money = &MoneyResponse{
+			Amount:   m.Amount(),
+			Currency: m.Currency(),
+		}
+	}
+	if l, ok := res.Value.(calculator.List); ok {
+		response.List = listItems(l, base)
+	}
+	if len(res.Rates.Rates) > 0 {
+		response.Rates = &res.Rates
+	}
+	for _, integral := range res.Integrals {
+		response.Integrals = append(response.Integrals, IntegralResponse{
+			Expression:    integral.Expression,
+			Value:         integral.Value,
+			ErrorEstimate: integral.Error,
+			Evaluations:   integral.Evaluations,
+		})
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if res.Variables != nil {
+		response.Variables = make(map[string]string, len(res.Variables))
+		for name, v := range res.Variables {
+			response.Variables[name] = formatValue(v, base)
+		}
+	}
 
-	err = json.NewEncoder(w).Encode(response)
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+	writeJSON(w, http.StatusOK, response)
+}
+
+// formatValue formats a result: a real number with 6 decimal places followed by its unit if it has one,
+// a list item by item as [1.000000, 2.000000], an amount of money exactly as 150.00 USD, a date in ISO 8601 as 2026-03-01, an integer in the requested base
+// and a complex number as 3+4i.
+func formatValue(v calculator.Value, base int) string {
+	switch v := v.(type) {
+	case calculator.Real:
+		return fmt.Sprintf("%f", float64(v))
+	case calculator.Quantity:
+		return fmt.Sprintf("%f %s", v.Value(), v.Unit())
+	case calculator.Integer:
+		return v.Format(base)
+	case calculator.List:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item, base)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return v.String()
+}
+
+// listItems formats the items of a list, keeping nested lists as arrays.
+func listItems(l calculator.List, base int) []any {
+	items := make([]any, len(l))
+	for i, item := range l {
+		if inner, ok := item.(calculator.List); ok {
+			items[i] = listItems(inner, base)
+		} else {
+			items[i] = formatValue(item, base)
+		}
 	}
+	return items
 }
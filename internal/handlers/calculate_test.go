@@ -3,13 +3,29 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"calculate-service/internal/config"
 	"calculate-service/internal/controller"
+	"calculate-service/internal/storage"
 )
 
+func newTestHandler(t *testing.T) Handler {
+	t.Helper()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("could not open storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return New(controller.New(store, config.Calculator{MaxDepth: 100, SessionTTL: time.Minute, SessionLimit: 2}))
+}
+
 func TestCalculate(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -32,6 +48,41 @@ func TestCalculate(t *testing.T) {
 			errorExpected:  true,
 			expectedCode:   http.StatusUnprocessableEntity,
 		},
+		{
+			name:           "Implicit multiplication",
+			expression:     "2(3+4)",
+			expectedResult: "14.000000",
+			errorExpected:  false,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "Statistics over a list",
+			expression:     "mean([1, 2, 3, 4]) + median(5, 1, 3)",
+			expectedResult: "5.500000",
+			errorExpected:  false,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "List result",
+			expression:     "[1, 2.5]",
+			expectedResult: "[1.000000, 2.500000]",
+			errorExpected:  false,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "Matrix product",
+			expression:     "[[1, 2], [3, 4]] * [1, 1]",
+			expectedResult: "[3.000000, 7.000000]",
+			errorExpected:  false,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "Matrix dimension mismatch",
+			expression:     "[[1, 2], [3, 4]] * [1, 2, 3]",
+			expectedResult: "",
+			errorExpected:  true,
+			expectedCode:   http.StatusUnprocessableEntity,
+		},
 		{
 			name:           "Expression with unsupported operands",
 			expression:     "2a+2",
@@ -60,8 +111,7 @@ func TestCalculate(t *testing.T) {
 			}
 
 			rec := httptest.NewRecorder()
-			ctrl := controller.New()
-			testHandler := New(ctrl)
+			testHandler := newTestHandler(t)
 			testHandler.Calculate(rec, req)
 
 			if tc.errorExpected {
@@ -86,3 +136,438 @@ func TestCalculate(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateSession(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	calculate := func(session, expression string) (int, string, string) {
+		reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: expression})
+		req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes))
+		if session != "" {
+			req.Header.Set(SessionIDHeader, session)
+		}
+
+		rec := httptest.NewRecorder()
+		testHandler.Calculate(rec, req)
+
+		var resp CalculateResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp.Result, rec.Header().Get(SessionIDHeader)
+	}
+
+	code, res, s1 := calculate("", "f(x) = x^2 + 1; f(3) + f(4)")
+	if code != http.StatusOK || res != "27.000000" || len(s1) != 32 {
+		t.Fatalf("expected 27.000000 in a new session, got %v %q in session %q", code, res, s1)
+	}
+	if code, res, session := calculate(s1, "f(5)"); code != http.StatusOK || res != "26.000000" || session != s1 {
+		t.Errorf("expected f to be kept in the session, got %v %q in session %q", code, res, session)
+	}
+	if code, _, _ := calculate("", "f(5)"); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected f to be unknown without a session, got %v", code)
+	}
+	// A client can not choose a session id.
+	if code, _, _ := calculate("s2", "g(x) = 2x; g(1)"); code != http.StatusNotFound {
+		t.Errorf("expected an unknown session to be rejected, got %v", code)
+	}
+
+	// The handler keeps two sessions. A request that defines nothing does not take a place, a third one
+	// that defines something drops the least recently used.
+	if code, res, session := calculate("", "1 + 1"); code != http.StatusOK || res != "2.000000" || session != "" {
+		t.Fatalf("expected 2.000000 without a session, got %v %q in session %q", code, res, session)
+	}
+	_, _, s2 := calculate("", "g(x) = 2x; g(1)")
+	calculate("", "h(x) = 3x; h(1)")
+	if code, _, _ := calculate(s1, "f(5)"); code != http.StatusNotFound {
+		t.Errorf("expected the least recently used session to be dropped, got %v", code)
+	}
+	if code, res, _ := calculate(s2, "g(5)"); code != http.StatusOK || res != "10.000000" {
+		t.Errorf("expected g to be kept in the session, got %v %q", code, res)
+	}
+}
+
+func TestCalculateVariables(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "a = 3\nb = a * 2\na + b", Variables: true})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	var resp CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+
+	if resp.Result != "9.000000" {
+		t.Errorf("expected 9.000000, got %v", resp.Result)
+	}
+	if resp.Variables["a"] != "3.000000" || resp.Variables["b"] != "6.000000" || len(resp.Variables) != 2 {
+		t.Errorf("unexpected variables: %v", resp.Variables)
+	}
+}
+
+func TestCalculateStrict(t *testing.T) {
+	testHandler := newTestHandler(t)
+	strict := true
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "2(3+4)", Strict: &strict})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422 in strict mode; got %v", rec.Code)
+	}
+}
+
+func TestCalculatePercent(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	testCases := []struct {
+		percent      string
+		expectedCode int
+		expected     string
+	}{
+		{"", http.StatusOK, "200.100000"},
+		{"plain", http.StatusOK, "200.100000"},
+		{"contextual", http.StatusOK, "220.000000"},
+		{"desk", http.StatusUnprocessableEntity, ""},
+	}
+
+	for _, tc := range testCases {
+		reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "200 + 10%", Percent: tc.percent})
+		rec := httptest.NewRecorder()
+		testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+		if rec.Code != tc.expectedCode {
+			t.Fatalf("percent %q: expected status %v; got %v", tc.percent, tc.expectedCode, rec.Code)
+		}
+		if tc.expected == "" {
+			continue
+		}
+
+		var response CalculateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("percent %q: could not decode response: %v", tc.percent, err)
+		}
+		if response.Result != tc.expected {
+			t.Errorf("percent %q: expected result %v; got %v", tc.percent, tc.expected, response.Result)
+		}
+	}
+}
+
+func TestCalculateInteger(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	testCases := []struct {
+		name         string
+		payload      CalculatePayload
+		expectedCode int
+		expected     string
+	}{
+		{
+			name:         "Decimal",
+			payload:      CalculatePayload{Expression: "9007199254740993 + 2", Integer: &IntegerPayload{Bits: 64}},
+			expectedCode: http.StatusOK,
+			expected:     "9007199254740995",
+		},
+		{
+			name:         "Hex mask",
+			payload:      CalculatePayload{Expression: "0xdead & ~0xff", Integer: &IntegerPayload{Bits: 16, Unsigned: true}, Format: "hex"},
+			expectedCode: http.StatusOK,
+			expected:     "0xde00",
+		},
+		{
+			name:         "Binary wrap-around",
+			payload:      CalculatePayload{Expression: "255 + 2", Integer: &IntegerPayload{Bits: 8, Unsigned: true, Wrap: true}, Format: "bin"},
+			expectedCode: http.StatusOK,
+			expected:     "0b00000001",
+		},
+		{
+			name:         "Overflow",
+			payload:      CalculatePayload{Expression: "255 + 2", Integer: &IntegerPayload{Bits: 8, Unsigned: true}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Unsupported width",
+			payload:      CalculatePayload{Expression: "1", Integer: &IntegerPayload{Bits: 12}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Missing width",
+			payload:      CalculatePayload{Expression: "1", Integer: &IntegerPayload{}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Format without integer mode",
+			payload:      CalculatePayload{Expression: "1", Format: "hex"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown format",
+			payload:      CalculatePayload{Expression: "1", Integer: &IntegerPayload{Bits: 8}, Format: "base64"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBodyBytes, _ := json.Marshal(&tc.payload)
+			rec := httptest.NewRecorder()
+			testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("expected status %v; got %v", tc.expectedCode, rec.Code)
+			}
+			if tc.expected == "" {
+				return
+			}
+
+			var response CalculateResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if response.Result != tc.expected {
+				t.Errorf("expected result %v; got %v", tc.expected, response.Result)
+			}
+		})
+	}
+}
+
+func TestCalculateComplex(t *testing.T) {
+	testHandler := newTestHandler(t)
+	complexMode := true
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "sqrt(-4) + 3", Complex: &complexMode})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "3+2i" {
+		t.Errorf("expected result 3+2i; got %v", response.Result)
+	}
+	if response.Complex == nil || response.Complex.Real != "3.000000" || response.Complex.Imag != "2.000000" {
+		t.Errorf("unexpected complex parts %+v", response.Complex)
+	}
+
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "sqrt(-4)"})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 without complex mode; got %v", rec.Code)
+	}
+}
+
+func TestCalculateInterval(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "(2 ± 0.5) * interval(1, 3) / interval(0, 4)", Interval: true})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "[0.375, +Inf]" {
+		t.Errorf("expected result [0.375, +Inf]; got %v", response.Result)
+	}
+	if response.Interval == nil || response.Interval.Lower != "0.375" || response.Interval.Upper != "+Inf" {
+		t.Errorf("unexpected interval %+v", response.Interval)
+	}
+
+	complexMode := true
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "2 ± 0.1", Interval: true, Complex: &complexMode})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for interval with complex mode; got %v", rec.Code)
+	}
+
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "2 ± 0.1"})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 without interval mode; got %v", rec.Code)
+	}
+}
+
+func TestCalculateUncertainty(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "x = 2 ± 0.1; y = 3 ± 0.2; x*y - x", Uncertainty: true})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "4 ± 0.447213595499958" {
+		t.Errorf("expected result 4 ± 0.447213595499958; got %v", response.Result)
+	}
+	if response.Measurement == nil || response.Measurement.Value != "4" || response.Measurement.Uncertainty != "0.447213595499958" {
+		t.Errorf("unexpected measurement %+v", response.Measurement)
+	}
+
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "2 ± 0.1", Uncertainty: true, Interval: true})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for uncertainty with interval mode; got %v", rec.Code)
+	}
+}
+
+func TestCalculateUnits(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "60 mi/h to m/s"})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "26.822400 m/s" {
+		t.Errorf("expected result 26.822400 m/s; got %v", response.Result)
+	}
+	if response.Quantity == nil || response.Quantity.Value != "26.822400" || response.Quantity.Unit != "m/s" {
+		t.Errorf("unexpected quantity %+v", response.Quantity)
+	}
+
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "3 m + 2 s"})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for incompatible dimensions; got %v", rec.Code)
+	}
+}
+
+func TestCalculateDates(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	testCases := []struct {
+		name     string
+		payload  CalculatePayload
+		result   string
+		duration string
+	}{
+		{"date plus duration", CalculatePayload{Expression: `date("2026-03-01") + 45d`}, "2026-04-15", ""},
+		{"time of day", CalculatePayload{Expression: `date("2026-03-01") + 90 min`}, "2026-03-01T01:30:00Z", ""},
+		{"difference", CalculatePayload{Expression: `date("2026-03-10") - date("2026-03-01")`}, "9.000000 d", "P9D"},
+		{"holidays", CalculatePayload{
+			Expression: `add_business_days(date("2026-12-24"), 1)`,
+			Holidays:   []string{"2026-12-25"},
+		}, "2026-12-28", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBodyBytes, _ := json.Marshal(&tc.payload)
+			rec := httptest.NewRecorder()
+			testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200; got %v", rec.Code)
+			}
+
+			var response CalculateResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if response.Result != tc.result {
+				t.Errorf("expected result %v; got %v", tc.result, response.Result)
+			}
+			if response.Duration != tc.duration {
+				t.Errorf("expected duration %q; got %q", tc.duration, response.Duration)
+			}
+		})
+	}
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "today()", Holidays: []string{"25.12.2026"}})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid holiday; got %v", rec.Code)
+	}
+}
+
+func TestCalculateAmortize(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "amortize(10%, 2, 1000)"})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(response.List) != 2 {
+		t.Fatalf("expected 2 rows; got %v", response.List)
+	}
+	row, ok := response.List[1].([]any)
+	if !ok || len(row) != 5 || row[0] != "2.000000" || row[1] != "576.190476" || row[4] != "0.000000" {
+		t.Errorf("unexpected second row %v", response.List[1])
+	}
+}
+
+func TestCalculateIntegrals(t *testing.T) {
+	testHandler := newTestHandler(t)
+
+	reqBodyBytes, _ := json.Marshal(&CalculatePayload{Expression: "integrate(x^2, x, 0, 3) + series(k, k, 1, 4)"})
+	rec := httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v", rec.Code)
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "19.000000" || len(response.Integrals) != 1 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	integral := response.Integrals[0]
+	if integral.Expression != "integrate(x^2, x, 0, 3)" || math.Abs(integral.Value-9) > 1e-12 ||
+		integral.ErrorEstimate <= 0 || integral.ErrorEstimate > 1e-10 || integral.Evaluations != 15 {
+		t.Errorf("unexpected integral %+v", integral)
+	}
+
+	reqBodyBytes, _ = json.Marshal(&CalculatePayload{Expression: "series(k, k, 1, 10000000)"})
+	rec = httptest.NewRecorder()
+	testHandler.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(reqBodyBytes)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422; got %v", rec.Code)
+	}
+}
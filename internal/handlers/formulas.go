@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+// FormulaPayload describes a formula either by a single definition, e.g.
+// "shipping(weight, zone) = 4.5 + weight*0.8*zone", or by its separate parts.
+type FormulaPayload struct {
+	Definition  string   `json:"definition"`
+	Name        string   `json:"name"`
+	Params      []string `json:"params"`
+	Expression  string   `json:"expression"`
+	Description string   `json:"description"`
+}
+
+type FormulasResponse struct {
+	Formulas []models.Formula `json:"formulas"`
+}
+
+type EvaluateFormulaPayload struct {
+	Params  map[string]float64 `json:"params"`
+	Version int                `json:"version"`
+}
+
+type EvaluateFormulaResponse struct {
+	Result  string `json:"result"`
+	Formula string `json:"formula"`
+	Version int    `json:"version"`
+}
+
+func (h handler) CreateFormula(w http.ResponseWriter, r *http.Request) {
+	f, err := decodeFormula(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if f.Name == "" || f.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'definition' or 'name' and 'expression' fields are required.")
+		return
+	}
+
+	f, err = h.controller.CreateFormula(r.Context(), f)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, f)
+}
+
+func (h handler) UpdateFormula(w http.ResponseWriter, r *http.Request) {
+	f, err := decodeFormula(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if f.Name != "" && f.Name != name {
+		writeError(w, http.StatusBadRequest, "formula name can not be changed.")
+		return
+	}
+	f.Name = name
+
+	if f.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'expression' or 'definition' field is required.")
+		return
+	}
+
+	f, err = h.controller.UpdateFormula(r.Context(), f)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f)
+}
+
+// Formula returns the current version of a formula, or the one given by the "version" query parameter.
+func (h handler) Formula(w http.ResponseWriter, r *http.Request) {
+	version, err := parseVersion(r.URL.Query().Get("version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f, err := h.controller.Formula(r.Context(), chi.URLParam(r, "name"), version)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (h handler) Formulas(w http.ResponseWriter, r *http.Request) {
+	formulas, err := h.controller.Formulas(r.Context())
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FormulasResponse{Formulas: formulas})
+}
+
+func (h handler) FormulaVersions(w http.ResponseWriter, r *http.Request) {
+	formulas, err := h.controller.FormulaVersions(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FormulasResponse{Formulas: formulas})
+}
+
+func (h handler) DeleteFormula(w http.ResponseWriter, r *http.Request) {
+	if err := h.controller.DeleteFormula(r.Context(), chi.URLParam(r, "name")); err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) EvaluateFormula(w http.ResponseWriter, r *http.Request) {
+	payload := EvaluateFormulaPayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Version < 0 {
+		writeError(w, http.StatusBadRequest, "'version' must be a positive integer.")
+		return
+	}
+
+	requestID, client := requestMeta(r)
+	res, f, err := h.controller.EvaluateFormula(r.Context(), models.FormulaRequest{
+		Name:      chi.URLParam(r, "name"),
+		Version:   payload.Version,
+		Values:    payload.Params,
+		RequestID: requestID,
+		Client:    client,
+	})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, EvaluateFormulaResponse{
+		Result:  fmt.Sprintf("%f", res),
+		Formula: f.Name,
+		Version: f.Version,
+	})
+}
+
+// decodeFormula reads a FormulaPayload, splitting a definition into name, parameters and expression.
+// Required fields are checked by the caller and the formula itself is validated by the controller.
+func decodeFormula(r *http.Request) (models.Formula, error) {
+	payload := FormulaPayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		return models.Formula{}, err
+	}
+	defer r.Body.Close()
+
+	f := models.Formula{
+		Name:        payload.Name,
+		Params:      payload.Params,
+		Expression:  payload.Expression,
+		Description: payload.Description,
+	}
+
+	if payload.Definition != "" {
+		f.Name, f.Params, f.Expression, err = calculator.ParseDefinition(payload.Definition)
+		if err != nil {
+			return models.Formula{}, err
+		}
+	}
+
+	if f.Params == nil {
+		f.Params = []string{}
+	}
+
+	return f, nil
+}
+
+func parseVersion(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(v)
+	if err != nil || version <= 0 {
+		return 0, errors.New("'version' must be a positive integer.")
+	}
+
+	return version, nil
+}
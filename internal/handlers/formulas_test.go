@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"calculate-service/internal/models"
+)
+
+// newRequest builds a request with a JSON body and chi URL parameters given as key/value pairs.
+func newRequest(method, target string, body any, params ...string) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, target, &buf)
+
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestFormulas(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.CreateFormula(rec, newRequest(http.MethodPost, "/formulas",
+		FormulaPayload{Definition: "shipping(weight, zone) = 4.5 + weight*0.8*zone"}))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected status 201; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.CreateFormula(rec, newRequest(http.MethodPost, "/formulas",
+		FormulaPayload{Name: "shipping", Params: []string{"weight"}, Expression: "weight"}))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate create: expected status 409; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.UpdateFormula(rec, newRequest(http.MethodPut, "/formulas/shipping",
+		FormulaPayload{Params: []string{"weight", "zone"}, Expression: "5 + weight*zone"}, "name", "shipping"))
+	var f models.Formula
+	if err := json.NewDecoder(rec.Body).Decode(&f); err != nil || rec.Code != http.StatusOK || f.Version != 2 {
+		t.Fatalf("update: expected version 2, got %v %+v (%v)", rec.Code, f, err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		payload EvaluateFormulaPayload
+		code    int
+		result  string
+	}{
+		{"current version", EvaluateFormulaPayload{Params: map[string]float64{"weight": 2, "zone": 3}}, http.StatusOK, "11.000000"},
+		{"first version", EvaluateFormulaPayload{Params: map[string]float64{"weight": 2, "zone": 3}, Version: 1}, http.StatusOK, "9.300000"},
+		{"missing parameter", EvaluateFormulaPayload{Params: map[string]float64{"weight": 2}}, http.StatusUnprocessableEntity, ""},
+		{"unknown parameter", EvaluateFormulaPayload{Params: map[string]float64{"weight": 2, "zone": 3, "x": 1}}, http.StatusUnprocessableEntity, ""},
+		{"unknown version", EvaluateFormulaPayload{Params: map[string]float64{"weight": 2, "zone": 3}, Version: 9}, http.StatusNotFound, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.EvaluateFormula(rec, newRequest(http.MethodPost, "/formulas/shipping/evaluate", tc.payload, "name", "shipping"))
+			if rec.Code != tc.code {
+				t.Fatalf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+
+			var resp EvaluateFormulaResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if resp.Result != tc.result {
+				t.Errorf("expected %q, got %q", tc.result, resp.Result)
+			}
+		})
+	}
+
+	rec = httptest.NewRecorder()
+	h.FormulaVersions(rec, newRequest(http.MethodGet, "/formulas/shipping/versions", nil, "name", "shipping"))
+	var versions FormulasResponse
+	if err := json.NewDecoder(rec.Body).Decode(&versions); err != nil || len(versions.Formulas) != 2 {
+		t.Fatalf("versions: expected 2 versions, got %+v (%v)", versions, err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.DeleteFormula(rec, newRequest(http.MethodDelete, "/formulas/shipping", nil, "name", "shipping"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Formula(rec, newRequest(http.MethodGet, "/formulas/shipping", nil, "name", "shipping"))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get deleted: expected status 404; got %v", rec.Code)
+	}
+}
+
+func TestCreateFormulaValidation(t *testing.T) {
+	h := newTestHandler(t)
+
+	for _, tc := range []struct {
+		name    string
+		payload FormulaPayload
+		code    int
+	}{
+		{"missing expression", FormulaPayload{Name: "f"}, http.StatusBadRequest},
+		{"malformed definition", FormulaPayload{Definition: "f x = x"}, http.StatusBadRequest},
+		{"undeclared parameter", FormulaPayload{Definition: "f(x) = x * y"}, http.StatusUnprocessableEntity},
+		{"invalid expression", FormulaPayload{Definition: "f(x) = x +"}, http.StatusUnprocessableEntity},
+		{"invalid name", FormulaPayload{Name: "1f", Expression: "1"}, http.StatusUnprocessableEntity},
+		{"date result", FormulaPayload{Definition: `f(x) = date("2026-01-01") + x`}, http.StatusUnprocessableEntity},
+		{"quantity result", FormulaPayload{Definition: "f(x) = x m"}, http.StatusUnprocessableEntity},
+		{"error for some values", FormulaPayload{Definition: "f(x) = ln(x - 1)"}, http.StatusCreated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.CreateFormula(rec, newRequest(http.MethodPost, "/formulas", tc.payload))
+			if rec.Code != tc.code {
+				t.Errorf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/go-chi/chi/v5/middleware"
+
 	"calculate-service/internal/controller"
+	"calculate-service/internal/models"
 )
 
 type handler struct {
@@ -12,6 +17,26 @@ type handler struct {
 
 type Handler interface {
 	Calculate(w http.ResponseWriter, r *http.Request)
+	History(w http.ResponseWriter, r *http.Request)
+	DeleteHistory(w http.ResponseWriter, r *http.Request)
+	DeleteHistoryItem(w http.ResponseWriter, r *http.Request)
+
+	CreateFormula(w http.ResponseWriter, r *http.Request)
+	UpdateFormula(w http.ResponseWriter, r *http.Request)
+	Formula(w http.ResponseWriter, r *http.Request)
+	Formulas(w http.ResponseWriter, r *http.Request)
+	FormulaVersions(w http.ResponseWriter, r *http.Request)
+	DeleteFormula(w http.ResponseWriter, r *http.Request)
+	EvaluateFormula(w http.ResponseWriter, r *http.Request)
+
+	Simplify(w http.ResponseWriter, r *http.Request)
+	Equivalent(w http.ResponseWriter, r *http.Request)
+	Derivative(w http.ResponseWriter, r *http.Request)
+	Sample(w http.ResponseWriter, r *http.Request)
+	Polynomial(w http.ResponseWriter, r *http.Request)
+
+	Rates(w http.ResponseWriter, r *http.Request)
+	SetRates(w http.ResponseWriter, r *http.Request)
 }
 
 func New(ctrl controller.Controller) Handler {
@@ -19,3 +44,65 @@ func New(ctrl controller.Controller) Handler {
 		controller: ctrl,
 	}
 }
+
+const (
+	// ClientIDHeader lets API consumers identify themselves; the remote address is used otherwise.
+	ClientIDHeader = "X-Client-ID"
+	// SessionIDHeader carries the id of the session a calculate request keeps its definitions in. The response
+	// of a request that defines something sets it, and later requests send it back.
+	SessionIDHeader = "X-Session-ID"
+)
+
+// requestMeta returns the request id and the client identity of an HTTP request.
+func requestMeta(r *http.Request) (requestID, client string) {
+	client = r.Header.Get(ClientIDHeader)
+	if client == "" {
+		client = r.RemoteAddr
+	}
+
+	return middleware.GetReqID(r.Context()), client
+}
+
+func newCalculateRequest(r *http.Request, expression string) models.CalculateRequest {
+	requestID, client := requestMeta(r)
+
+	return models.CalculateRequest{
+		Expression: expression,
+		Session:    r.Header.Get(SessionIDHeader),
+		RequestID:  requestID,
+		Client:     client,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ResponseError{Error: msg})
+}
+
+// writeCtrlError maps a controller error to the matching HTTP status.
+func writeCtrlError(w http.ResponseWriter, err error) {
+	var ctrlErr controller.CtrlError
+	if errors.As(err, &ctrlErr) {
+		switch ctrlErr.Type {
+		case controller.ErrRequest:
+			writeError(w, http.StatusUnprocessableEntity, ctrlErr.Error())
+			return
+		case controller.ErrNotFound:
+			writeError(w, http.StatusNotFound, ctrlErr.Error())
+			return
+		case controller.ErrConflict:
+			writeError(w, http.StatusConflict, ctrlErr.Error())
+			return
+		case controller.ErrServer:
+			writeError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"calculate-service/internal/models"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+type DeleteHistoryResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// History returns a page of recorded calculations.
+// Query parameters: limit, offset, from, to (RFC 3339), error_type, client.
+func (h handler) History(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.controller.History(r.Context(), filter)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// DeleteHistory removes every calculation matching the filter. At least one filter is required
+// so that the whole history cannot be wiped by accident; pass all=true to do it on purpose.
+func (h handler) DeleteHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if filter.IsEmpty() && r.URL.Query().Get("all") != "true" {
+		writeError(w, http.StatusBadRequest, "at least one of 'from', 'to', 'error_type', 'client' or 'all=true' is required.")
+		return
+	}
+
+	n, err := h.controller.DeleteHistory(r.Context(), filter)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, DeleteHistoryResponse{Deleted: n})
+}
+
+func (h handler) DeleteHistoryItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid history item id.")
+		return
+	}
+
+	if err = h.controller.DeleteHistoryItem(r.Context(), id); err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseHistoryFilter(r *http.Request) (models.HistoryFilter, error) {
+	q := r.URL.Query()
+	filter := models.HistoryFilter{
+		ErrorType: q.Get("error_type"),
+		Client:    q.Get("client"),
+		Limit:     defaultHistoryLimit,
+	}
+
+	var err error
+
+	if v := q.Get("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxHistoryLimit {
+			return filter, fmt.Errorf("'limit' must be an integer between 1 and %d.", maxHistoryLimit)
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		filter.Offset, err = strconv.Atoi(v)
+		if err != nil || filter.Offset < 0 {
+			return filter, errors.New("'offset' must be a non-negative integer.")
+		}
+	}
+
+	if v := q.Get("from"); v != "" {
+		filter.From, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("'from' must be an RFC 3339 timestamp.")
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		filter.To, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("'to' must be an RFC 3339 timestamp.")
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"calculate-service/internal/models"
+)
+
+func calculateAs(t *testing.T, h Handler, client, expression string) {
+	t.Helper()
+
+	body, _ := json.Marshal(CalculatePayload{Expression: expression})
+	req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(body))
+	req.Header.Set(ClientIDHeader, client)
+	h.Calculate(httptest.NewRecorder(), req)
+}
+
+func getHistory(t *testing.T, h Handler, query string) models.HistoryPage {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.History(rec, httptest.NewRequest(http.MethodGet, "/history?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	var page models.HistoryPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+
+	return page
+}
+
+func TestHistory(t *testing.T) {
+	h := newTestHandler(t)
+
+	calculateAs(t, h, "alice", "2+2")
+	calculateAs(t, h, "alice", "1/0")
+	calculateAs(t, h, "bob", "(1+2")
+
+	page := getHistory(t, h, "")
+	if page.Total != 3 || len(page.Items) != 3 {
+		t.Fatalf("expected 3 items, got total %d, items %d", page.Total, len(page.Items))
+	}
+	if page.Items[0].Expression != "(1+2" {
+		t.Errorf("expected newest item first, got %q", page.Items[0].Expression)
+	}
+
+	page = getHistory(t, h, "client=alice&limit=1")
+	if page.Total != 2 || len(page.Items) != 1 {
+		t.Fatalf("expected 1 of 2 items, got total %d, items %d", page.Total, len(page.Items))
+	}
+
+	page = getHistory(t, h, "error_type=division_by_zero")
+	if page.Total != 1 || page.Items[0].Expression != "1/0" || page.Items[0].Client != "alice" {
+		t.Fatalf("unexpected error_type filter result: %+v", page)
+	}
+
+	page = getHistory(t, h, "client=alice&offset=1")
+	if len(page.Items) != 1 || page.Items[0].Result != "4" {
+		t.Fatalf("unexpected offset result: %+v", page)
+	}
+
+	page = getHistory(t, h, "to=2000-01-01T00:00:00Z")
+	if page.Total != 0 {
+		t.Fatalf("expected empty time range, got %d items", page.Total)
+	}
+}
+
+func TestHistoryOptions(t *testing.T) {
+	h := newTestHandler(t)
+
+	body, _ := json.Marshal(CalculatePayload{
+		Expression: "32767 + 1",
+		Percent:    "contextual",
+		Integer:    &IntegerPayload{Bits: 16, Wrap: true},
+		Holidays:   []string{"2026-12-25"},
+	})
+	rec := httptest.NewRecorder()
+	h.Calculate(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+	calculateAs(t, h, "alice", "2+2")
+
+	page := getHistory(t, h, "")
+	want := models.CalculationOptions{
+		Percent:  "contextual",
+		Integer:  &models.IntegerOptions{Bits: 16, Wrap: true},
+		Holidays: []string{"2026-12-25"},
+	}
+	if got := page.Items[1].Options; !reflect.DeepEqual(got, want) {
+		t.Errorf("options = %+v, want %+v", got, want)
+	}
+	if got := page.Items[0].Options; got.Percent != "plain" || got.Integer != nil {
+		t.Errorf("options = %+v, want the defaults", got)
+	}
+}
+
+func TestHistoryBadRequest(t *testing.T) {
+	h := newTestHandler(t)
+
+	for _, query := range []string{"limit=0", "limit=x", "offset=-1", "from=yesterday"} {
+		rec := httptest.NewRecorder()
+		h.History(rec, httptest.NewRequest(http.MethodGet, "/history?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400; got %v", query, rec.Code)
+		}
+	}
+}
+
+func TestDeleteHistory(t *testing.T) {
+	h := newTestHandler(t)
+
+	calculateAs(t, h, "alice", "2+2")
+	calculateAs(t, h, "bob", "3+3")
+	calculateAs(t, h, "bob", "4+4")
+
+	rec := httptest.NewRecorder()
+	h.DeleteHistory(rec, httptest.NewRequest(http.MethodDelete, "/history", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without filter; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.DeleteHistory(rec, httptest.NewRequest(http.MethodDelete, "/history?client=bob", nil))
+	var resp DeleteHistoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Deleted != 2 {
+		t.Fatalf("expected 2 deleted items, got %+v (%v)", resp, err)
+	}
+
+	page := getHistory(t, h, "")
+	if page.Total != 1 {
+		t.Fatalf("expected 1 remaining item, got %d", page.Total)
+	}
+
+	for _, tc := range []struct {
+		id   string
+		code int
+	}{
+		{"abc", http.StatusBadRequest},
+		{"999", http.StatusNotFound},
+		{"1", http.StatusNoContent},
+	} {
+		rec = httptest.NewRecorder()
+		h.DeleteHistoryItem(rec, newRequest(http.MethodDelete, "/history/"+tc.id, nil, "id", tc.id))
+		if rec.Code != tc.code {
+			t.Errorf("delete %s: expected status %v; got %v", tc.id, tc.code, rec.Code)
+		}
+	}
+}
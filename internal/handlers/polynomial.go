@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+type PolynomialPayload struct {
+	Expression string `json:"expression"`
+	// Variable is the variable of the polynomial, "x" if it is empty.
+	Variable string `json:"variable,omitempty"`
+	// Divisor asks for the quotient, the remainder and the greatest common divisor with this polynomial.
+	Divisor string `json:"divisor,omitempty"`
+	// At asks for the value of the polynomial at this value of the variable.
+	At *float64 `json:"at,omitempty"`
+}
+
+type PolynomialResponse struct {
+	// Polynomial is the expanded polynomial, e.g. "x^2 - 1".
+	Polynomial string `json:"polynomial"`
+	Degree     int    `json:"degree"`
+	// Coefficients are the exact coefficients, highest power first, e.g. ["1/4", "0", "-1"].
+	Coefficients []string `json:"coefficients"`
+	Derivative   string   `json:"derivative"`
+	// Factored is the factorization over the rationals, e.g. "2*(x - 1)^2*(x + 1)". It is missing for 0
+	// and for a polynomial whose coefficients are too large to factor.
+	Factored string           `json:"factored,omitempty"`
+	Constant string           `json:"constant,omitempty"`
+	Factors  []FactorResponse `json:"factors,omitempty"`
+	// Roots are the distinct roots, the real ones in ascending order, then the complex ones like "-0.5+0.866i".
+	Roots     []string `json:"roots"`
+	Quotient  string   `json:"quotient,omitempty"`
+	Remainder string   `json:"remainder,omitempty"`
+	GCD       string   `json:"gcd,omitempty"`
+	Value     *float64 `json:"value,omitempty"`
+}
+
+type FactorResponse struct {
+	Factor       string `json:"factor"`
+	Multiplicity int    `json:"multiplicity"`
+}
+
+func (h handler) Polynomial(w http.ResponseWriter, r *http.Request) {
+	payload := PolynomialPayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'expression' field is required.")
+		return
+	}
+	if payload.Variable == "" {
+		payload.Variable = "x"
+	}
+
+	res, err := h.controller.Polynomial(r.Context(), models.PolynomialRequest{
+		Expression: payload.Expression,
+		Variable:   payload.Variable,
+		Divisor:    payload.Divisor,
+		At:         payload.At,
+	})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	response := PolynomialResponse{
+		Polynomial:   res.Polynomial.String(),
+		Degree:       res.Polynomial.Degree(),
+		Coefficients: []string{},
+		Derivative:   res.Polynomial.Derivative().String(),
+		Roots:        []string{},
+		Value:        res.Value,
+	}
+	for _, c := range res.Polynomial.Coefficients() {
+		response.Coefficients = append(response.Coefficients, c.RatString())
+	}
+	if fz := res.Factorization; fz != nil {
+		response.Factored = fz.String()
+		response.Constant = fz.Constant.RatString()
+		for _, f := range fz.Factors {
+			response.Factors = append(response.Factors, FactorResponse{Factor: f.Polynomial.String(), Multiplicity: f.Multiplicity})
+		}
+	}
+	for _, z := range res.Roots {
+		if imag(z) == 0 {
+			response.Roots = append(response.Roots, calculator.Real(real(z)).String())
+		} else {
+			response.Roots = append(response.Roots, calculator.Complex(z).String())
+		}
+	}
+	if res.Quotient != nil {
+		response.Quotient = res.Quotient.String()
+		response.Remainder = res.Remainder.String()
+		response.GCD = res.GCD.String()
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPolynomial(t *testing.T) {
+	h := newTestHandler(t)
+
+	at := 2.0
+	rec := httptest.NewRecorder()
+	h.Polynomial(rec, newRequest(http.MethodPost, "/polynomial", PolynomialPayload{
+		Expression: "2*x^3 - 2*x",
+		Divisor:    "x^2 - 2*x + 1",
+		At:         &at,
+	}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+	var response PolynomialResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	want := PolynomialResponse{
+		Polynomial:   "2*x^3 - 2*x",
+		Degree:       3,
+		Coefficients: []string{"2", "0", "-2", "0"},
+		Derivative:   "6*x^2 - 2",
+		Factored:     "2*x*(x - 1)*(x + 1)",
+		Constant:     "2",
+		Factors:      []FactorResponse{{"x", 1}, {"x - 1", 1}, {"x + 1", 1}},
+		Roots:        []string{"-1", "0", "1"},
+		Quotient:     "2*x + 4",
+		Remainder:    "4*x - 4",
+		GCD:          "x - 1",
+	}
+	if response.Value == nil || *response.Value != 12 {
+		t.Errorf("unexpected value %v", response.Value)
+	}
+	want.Value = response.Value
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Polynomial(rec, newRequest(http.MethodPost, "/polynomial", PolynomialPayload{Expression: "t^2 + t + 1", Variable: "t"}))
+	if got := rec.Body.String(); got != `{"polynomial":"t^2 + t + 1","degree":2,"coefficients":["1","1","1"],"derivative":"2*t + 1","factored":"t^2 + t + 1","constant":"1","factors":[{"factor":"t^2 + t + 1","multiplicity":1}],"roots":["-0.5-0.8660254037844386i","-0.5+0.8660254037844386i"]}`+"\n" {
+		t.Errorf("unexpected response %s", got)
+	}
+
+	testCases := []struct {
+		name    string
+		payload PolynomialPayload
+		code    int
+	}{
+		{"Missing expression", PolynomialPayload{}, http.StatusBadRequest},
+		{"Not a polynomial", PolynomialPayload{Expression: "1/x"}, http.StatusUnprocessableEntity},
+		{"Division by zero", PolynomialPayload{Expression: "x", Divisor: "0"}, http.StatusUnprocessableEntity},
+		{"Zero", PolynomialPayload{Expression: "x - x"}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Polynomial(rec, newRequest(http.MethodPost, "/polynomial", tc.payload))
+			if rec.Code != tc.code {
+				t.Errorf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"calculate-service/internal/models"
+)
+
+func (h handler) Rates(w http.ResponseWriter, r *http.Request) {
+	table, err := h.controller.Rates(r.Context())
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, table)
+}
+
+func (h handler) SetRates(w http.ResponseWriter, r *http.Request) {
+	var table models.RateTable
+	if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if table.Base == "" {
+		writeError(w, http.StatusBadRequest, "'base' field is required.")
+		return
+	}
+
+	table, err := h.controller.SetRates(r.Context(), table)
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, table)
+}
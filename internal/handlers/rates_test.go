@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"calculate-service/internal/models"
+)
+
+func TestRates(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.Rates(rec, newRequest(http.MethodGet, "/admin/rates", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get without a table: expected status 404; got %v", rec.Code)
+	}
+
+	updated := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	table := models.RateTable{Base: "USD", Rates: []models.Rate{
+		{Currency: "GBP", Rate: "1.25", UpdatedAt: updated},
+		{Currency: "EUR", Rate: "1.08", UpdatedAt: updated},
+	}}
+	rec = httptest.NewRecorder()
+	h.SetRates(rec, newRequest(http.MethodPut, "/admin/rates", table))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put: expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.Calculate(rec, newRequest(http.MethodPost, "/calculate", CalculatePayload{Expression: "100 USD + 50 EUR in GBP"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("calculate: expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	var response CalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Result != "123.20 GBP" || response.Money == nil || response.Money.Amount != "123.20" || response.Money.Currency != "GBP" {
+		t.Errorf("unexpected result %q %+v", response.Result, response.Money)
+	}
+	if response.Rates == nil || response.Rates.Base != "USD" || len(response.Rates.Rates) != 2 ||
+		response.Rates.Rates[0].Currency != "EUR" || !response.Rates.Rates[0].UpdatedAt.Equal(updated) {
+		t.Errorf("unexpected rates %+v", response.Rates)
+	}
+
+	for _, bad := range []models.RateTable{
+		{Base: "USD", Rates: []models.Rate{{Currency: "EUR", Rate: "abc"}}},
+		{Base: "USD", Rates: []models.Rate{{Currency: "EUR", Rate: "-1"}}},
+		{Base: "dollar"},
+	} {
+		rec = httptest.NewRecorder()
+		h.SetRates(rec, newRequest(http.MethodPut, "/admin/rates", bad))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("put %+v: expected status 422; got %v", bad, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.Rates(rec, newRequest(http.MethodGet, "/admin/rates", nil))
+	var got models.RateTable
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || rec.Code != http.StatusOK || len(got.Rates) != 2 || got.Rates[1].Rate != "1.25" {
+		t.Errorf("get: unexpected table %v %+v (%v)", rec.Code, got, err)
+	}
+}
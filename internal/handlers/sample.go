@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+// defaultSampleSteps is the number of steps of an axis when the payload does not set it.
+const defaultSampleSteps = 100
+
+type SamplePayload struct {
+	Expression string `json:"expression"`
+	// Ranges are the one or two axes of the sample.
+	Ranges []RangePayload `json:"ranges"`
+	// Steps is the number of steps along each axis, 100 if it is not set; an axis has one more point.
+	Steps int `json:"steps,omitempty"`
+	// Format is "json" (default) or "csv".
+	Format string `json:"format,omitempty"`
+}
+
+type RangePayload struct {
+	// Variable is "x" for the first range and "y" for the second if it is empty.
+	Variable string  `json:"variable,omitempty"`
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+}
+
+// SampleResponse holds the points of a sample in the layout of plotting libraries. For one variable, Y are the
+// values at the points X. For two, X and Y are the points of the axes and Z[j][i] is the value at X[i], Y[j].
+// A value is null where the expression is undefined.
+type SampleResponse struct {
+	Variables []string     `json:"variables"`
+	X         []float64    `json:"x"`
+	Y         []*float64   `json:"y"`
+	Z         [][]*float64 `json:"z,omitempty"`
+}
+
+func (h handler) Sample(w http.ResponseWriter, r *http.Request) {
+	payload := SamplePayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'expression' field is required.")
+		return
+	}
+	if len(payload.Ranges) != 1 && len(payload.Ranges) != 2 {
+		writeError(w, http.StatusBadRequest, "'ranges' must hold one or two ranges.")
+		return
+	}
+	if payload.Format != "" && payload.Format != "json" && payload.Format != "csv" {
+		writeError(w, http.StatusBadRequest, "'format' must be json or csv.")
+		return
+	}
+	if payload.Steps == 0 {
+		payload.Steps = defaultSampleSteps
+	}
+
+	axes := make([]calculator.Axis, len(payload.Ranges))
+	for i, rng := range payload.Ranges {
+		axes[i] = calculator.Axis{Variable: rng.Variable, From: rng.From, To: rng.To}
+		if axes[i].Variable == "" {
+			axes[i].Variable = []string{"x", "y"}[i]
+		}
+	}
+
+	sampling, err := h.controller.Sample(r.Context(), models.SampleRequest{
+		Expression: payload.Expression,
+		Axes:       axes,
+		Steps:      payload.Steps,
+	})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	if payload.Format == "csv" {
+		writeSampleCSV(w, axes, sampling)
+		return
+	}
+
+	response := SampleResponse{X: sampling.Points[0]}
+	for _, axis := range axes {
+		response.Variables = append(response.Variables, axis.Variable)
+	}
+	if len(axes) == 1 {
+		response.Y = sampleValues(sampling.Values)
+	} else {
+		nx, ny := len(sampling.Points[0]), len(sampling.Points[1])
+		response.Y = sampleValues(sampling.Points[1])
+		response.Z = make([][]*float64, ny)
+		for j := range ny {
+			row := make([]float64, nx)
+			for i := range nx {
+				row[i] = sampling.Values[i*ny+j]
+			}
+			response.Z[j] = sampleValues(row)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// sampleValues turns the NaN of an undefined value into nil, which JSON encodes as null.
+func sampleValues(values []float64) []*float64 {
+	result := make([]*float64, len(values))
+	for i := range values {
+		if !math.IsNaN(values[i]) {
+			result[i] = &values[i]
+		}
+	}
+	return result
+}
+
+// writeSampleCSV writes a sample as CSV with a row per point: the values of the variables and of the expression,
+// which is empty where it is undefined.
+func writeSampleCSV(w http.ResponseWriter, axes []calculator.Axis, sampling calculator.Sampling) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	header := make([]string, 0, len(axes)+1)
+	for _, axis := range axes {
+		header = append(header, axis.Variable)
+	}
+	out.Write(append(header, "value"))
+
+	for k, value := range sampling.Values {
+		row := make([]string, len(axes)+1)
+		// The index of a point on the last axis runs fastest.
+		for i, rest := len(axes)-1, k; i >= 0; i-- {
+			points := sampling.Points[i]
+			row[i] = formatSampleNumber(points[rest%len(points)])
+			rest /= len(points)
+		}
+		if !math.IsNaN(value) {
+			row[len(axes)] = formatSampleNumber(value)
+		}
+		out.Write(row)
+	}
+	out.Flush()
+}
+
+func formatSampleNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.Sample(rec, newRequest(http.MethodPost, "/sample", SamplePayload{
+		Expression: "1/x",
+		Ranges:     []RangePayload{{From: -1, To: 1}},
+		Steps:      2,
+	}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); got != `{"variables":["x"],"x":[-1,0,1],"y":[-1,null,1]}`+"\n" {
+		t.Errorf("unexpected response %s", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Sample(rec, newRequest(http.MethodPost, "/sample", SamplePayload{
+		Expression: "t/s",
+		Ranges:     []RangePayload{{Variable: "t", From: 0, To: 1}, {Variable: "s", From: 0, To: 2}},
+		Steps:      1,
+	}))
+	var response SampleResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(response.Z) != 2 || response.Z[0][0] != nil || response.Z[0][1] != nil || *response.Z[1][1] != 0.5 {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Sample(rec, newRequest(http.MethodPost, "/sample", SamplePayload{
+		Expression: "sqrt(x*y)",
+		Ranges:     []RangePayload{{From: -1, To: 1}, {From: 1, To: 4}},
+		Steps:      1,
+		Format:     "csv",
+	}))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("expected status 200 with CSV; got %v: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); got != "x,y,value\n-1,1,\n-1,4,\n1,1,1\n1,4,2\n" {
+		t.Errorf("unexpected CSV %q", got)
+	}
+
+	testCases := []struct {
+		name    string
+		payload SamplePayload
+		code    int
+	}{
+		{"Missing expression", SamplePayload{Ranges: []RangePayload{{From: 0, To: 1}}}, http.StatusBadRequest},
+		{"Missing ranges", SamplePayload{Expression: "x"}, http.StatusBadRequest},
+		{"Unknown format", SamplePayload{Expression: "x", Ranges: []RangePayload{{From: 0, To: 1}}, Format: "xml"}, http.StatusBadRequest},
+		{"Unknown variable", SamplePayload{Expression: "x + z", Ranges: []RangePayload{{From: 0, To: 1}}}, http.StatusUnprocessableEntity},
+		{"Too many steps", SamplePayload{Expression: "x", Ranges: []RangePayload{{From: 0, To: 1}}, Steps: 20000}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Sample(rec, newRequest(http.MethodPost, "/sample", tc.payload))
+			if rec.Code != tc.code {
+				t.Errorf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"calculate-service/internal/models"
+	"calculate-service/pkg/calculator"
+)
+
+type SimplifyPayload struct {
+	Expression string `json:"expression"`
+}
+
+type SimplifyResponse struct {
+	// Simplified is the canonical form of the expression, e.g. "2*x + 2" for "x + 2 + x*1".
+	Simplified string  `json:"simplified"`
+	AST        ASTNode `json:"ast"`
+}
+
+type EquivalencePayload struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+	// Tolerance is the largest relative difference of two values that counts as equal, 1e-9 if it is not set.
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Samples is the number of random points the values are compared at, 20 if it is not set.
+	Samples int `json:"samples,omitempty"`
+	// Seed selects the random points; the same seed gives the same verdict.
+	Seed uint64 `json:"seed,omitempty"`
+}
+
+type EquivalenceResponse struct {
+	Equivalent bool `json:"equivalent"`
+	// Method is "canonical" when the canonical forms are the same, "numeric" when the values were compared.
+	Method     string  `json:"method"`
+	Confidence float64 `json:"confidence"`
+	Samples    int     `json:"samples,omitempty"`
+	// Counterexample are the values of the variables at a point where the expressions differ.
+	Counterexample map[string]float64 `json:"counterexample,omitempty"`
+}
+
+type DerivativePayload struct {
+	Expression string `json:"expression"`
+	// Variable is the variable to differentiate by, "x" if it is empty.
+	Variable string `json:"variable,omitempty"`
+	// At asks to evaluate the derivative at this value of the variable.
+	At *float64 `json:"at,omitempty"`
+}
+
+type DerivativeResponse struct {
+	// Derivative is the simplified derivative as an expression, e.g. "2*x*sin(x) + x^2*cos(x)".
+	Derivative string  `json:"derivative"`
+	AST        ASTNode `json:"ast"`
+	// Result is the value of the derivative at the requested point.
+	Result string `json:"result,omitempty"`
+}
+
+// ASTNode is a node of an expression tree. Type is number, identifier, operator, prefix, postfix,
+// conditional, call, list or string; Value is the number, the name, the operator or the called function.
+type ASTNode struct {
+	Type  string    `json:"type"`
+	Value string    `json:"value,omitempty"`
+	Args  []ASTNode `json:"args,omitempty"`
+}
+
+func newASTNode(n calculator.Node) ASTNode {
+	node := ASTNode{Type: n.Type, Value: n.Value}
+	for _, arg := range n.Args {
+		node.Args = append(node.Args, newASTNode(arg))
+	}
+	return node
+}
+
+func (h handler) Simplify(w http.ResponseWriter, r *http.Request) {
+	payload := SimplifyPayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'expression' field is required.")
+		return
+	}
+
+	expr, err := h.controller.Simplify(r.Context(), models.SimplifyRequest{Expression: payload.Expression})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SimplifyResponse{
+		Simplified: expr.String(),
+		AST:        newASTNode(expr.Tree()),
+	})
+}
+
+func (h handler) Equivalent(w http.ResponseWriter, r *http.Request) {
+	payload := EquivalencePayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Left == "" || payload.Right == "" {
+		writeError(w, http.StatusBadRequest, "'left' and 'right' fields are required.")
+		return
+	}
+
+	eq, err := h.controller.Equivalent(r.Context(), models.EquivalenceRequest{
+		Left:      payload.Left,
+		Right:     payload.Right,
+		Tolerance: payload.Tolerance,
+		Samples:   payload.Samples,
+		Seed:      payload.Seed,
+	})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, EquivalenceResponse{
+		Equivalent:     eq.Equivalent,
+		Method:         eq.Method,
+		Confidence:     eq.Confidence,
+		Samples:        eq.Samples,
+		Counterexample: eq.Counterexample,
+	})
+}
+
+func (h handler) Derivative(w http.ResponseWriter, r *http.Request) {
+	payload := DerivativePayload{}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	if payload.Expression == "" {
+		writeError(w, http.StatusBadRequest, "'expression' field is required.")
+		return
+	}
+	if payload.Variable == "" {
+		payload.Variable = "x"
+	}
+
+	res, err := h.controller.Derivative(r.Context(), models.DerivativeRequest{
+		Expression: payload.Expression,
+		Variable:   payload.Variable,
+		At:         payload.At,
+	})
+	if err != nil {
+		writeCtrlError(w, err)
+		return
+	}
+
+	response := DerivativeResponse{
+		Derivative: res.Derivative.String(),
+		AST:        newASTNode(res.Derivative.Tree()),
+	}
+	if res.Value != nil {
+		response.Result = formatValue(res.Value, 10)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.Simplify(rec, newRequest(http.MethodPost, "/simplify", SimplifyPayload{Expression: "x + 2 + x*1"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	var response SimplifyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Simplified != "2*x + 2" || response.AST.Value != "+" || len(response.AST.Args) != 2 {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Simplify(rec, newRequest(http.MethodPost, "/simplify", SimplifyPayload{Expression: "x +"}))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Simplify(rec, newRequest(http.MethodPost, "/simplify", SimplifyPayload{}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400; got %v", rec.Code)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	h := newTestHandler(t)
+
+	testCases := []struct {
+		name       string
+		payload    EquivalencePayload
+		code       int
+		equivalent bool
+		method     string
+	}{
+		{"Same canonical form", EquivalencePayload{Left: "2(x+1)", Right: "2x+2"}, http.StatusOK, true, "canonical"},
+		{"Same values", EquivalencePayload{Left: "sin(x)^2 + cos(x)^2", Right: "1"}, http.StatusOK, true, "numeric"},
+		{"Different", EquivalencePayload{Left: "2(x+1)", Right: "2x+1"}, http.StatusOK, false, "numeric"},
+		{"Within tolerance", EquivalencePayload{Left: "x", Right: "x + 0.001", Tolerance: 0.01}, http.StatusOK, true, "numeric"},
+		{"Missing expression", EquivalencePayload{Left: "x"}, http.StatusBadRequest, false, ""},
+		{"Invalid expression", EquivalencePayload{Left: "x +", Right: "x"}, http.StatusUnprocessableEntity, false, ""},
+		{"Too many samples", EquivalencePayload{Left: "x", Right: "x + 1", Samples: 5000}, http.StatusUnprocessableEntity, false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Equivalent(rec, newRequest(http.MethodPost, "/equivalent", tc.payload))
+			if rec.Code != tc.code {
+				t.Fatalf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var response EquivalenceResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if response.Equivalent != tc.equivalent || response.Method != tc.method {
+				t.Errorf("unexpected response %+v", response)
+			}
+			if !response.Equivalent && response.Counterexample == nil {
+				t.Errorf("expected a counterexample; got %+v", response)
+			}
+		})
+	}
+}
+
+func TestDerivative(t *testing.T) {
+	h := newTestHandler(t)
+	at := 0.0
+
+	rec := httptest.NewRecorder()
+	h.Derivative(rec, newRequest(http.MethodPost, "/derivative",
+		DerivativePayload{Expression: "x^2 * sin(x) + x", At: &at}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200; got %v: %s", rec.Code, rec.Body.String())
+	}
+
+	var response DerivativeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if response.Derivative != "2*x*sin(x) + x^2*cos(x) + 1" || response.Result != "1.000000" {
+		t.Errorf("unexpected response %+v", response)
+	}
+	if response.AST.Type != "operator" || response.AST.Value != "+" || len(response.AST.Args) != 2 {
+		t.Errorf("unexpected tree %+v", response.AST)
+	}
+
+	testCases := []struct {
+		name    string
+		payload DerivativePayload
+		code    int
+	}{
+		{"Other variable", DerivativePayload{Expression: "t^3", Variable: "t"}, http.StatusOK},
+		{"Missing expression", DerivativePayload{Variable: "x"}, http.StatusBadRequest},
+		{"Not differentiable", DerivativePayload{Expression: "floor(x)"}, http.StatusUnprocessableEntity},
+		{"Invalid variable", DerivativePayload{Expression: "x", Variable: "2x"}, http.StatusUnprocessableEntity},
+		{"Assignment", DerivativePayload{Expression: "x = 2"}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.Derivative(rec, newRequest(http.MethodPost, "/derivative", tc.payload))
+			if rec.Code != tc.code {
+				t.Errorf("expected status %v; got %v: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
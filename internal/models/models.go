@@ -0,0 +1,184 @@
+package models
+
+import (
+	"time"
+
+	"calculate-service/pkg/calculator"
+)
+
+// CalculateRequest is a single expression evaluation together with the identity of its caller.
+// Variables and functions defined in a request stay available to later requests with the Session returned for it.
+type CalculateRequest struct {
+	Expression  string
+	Variables   bool
+	Strict      *bool
+	Percent     string
+	Integer     calculator.IntegerMode
+	Complex     *bool
+	Interval    bool                // makes every number an interval, without complex numbers
+	Uncertainty bool                // makes x ± u a measured value, without complex numbers
+	Holidays    calculator.Holidays // replaces the service's holidays unless nil
+	Session     string
+	RequestID   string
+	Client      string
+}
+
+// CalculateResult is the value of an evaluated expression and, if they were requested, the bound variables.
+// Rates lists the exchange rates the evaluation converted amounts with; it is empty if there was no conversion.
+// Integrals are the integrals the evaluation computed, with their error estimates. Session is the id of the session
+// the variables and functions of the request are kept in, if there is one.
+type CalculateResult struct {
+	Value     calculator.Value
+	Variables map[string]calculator.Value
+	Rates     RateTable
+	Integrals []calculator.Integral
+	Session   string
+}
+
+// RateTable is an exchange-rate table: the value of one unit of each currency in the base currency.
+type RateTable struct {
+	Base  string `json:"base"`
+	Rates []Rate `json:"rates"`
+}
+
+// Rate is the exchange rate of a currency as an exact decimal, e.g. "1.0825", and the time it was set.
+type Rate struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FormulaRequest evaluates a saved formula. Version 0 means the current version.
+type FormulaRequest struct {
+	Name      string
+	Version   int
+	Values    map[string]float64
+	RequestID string
+	Client    string
+}
+
+// Calculation is a recorded evaluation, either successful or failed.
+type Calculation struct {
+	ID         int64     `json:"id"`
+	Expression string    `json:"expression"`
+	Result     string    `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ErrorType  string    `json:"error_type,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Client     string    `json:"client,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Options are the options the expression was evaluated with; they are empty for calculations recorded
+	// before the options were.
+	Options CalculationOptions `json:"options"`
+}
+
+// CalculationOptions are the options a calculation was evaluated with, so that it can be repeated.
+type CalculationOptions struct {
+	Percent     string          `json:"percent,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
+	Integer     *IntegerOptions `json:"integer,omitempty"`
+	Complex     bool            `json:"complex,omitempty"`
+	Interval    bool            `json:"interval,omitempty"`
+	Uncertainty bool            `json:"uncertainty,omitempty"`
+	Holidays    []string        `json:"holidays,omitempty"`
+	Session     string          `json:"session,omitempty"`
+}
+
+// IntegerOptions are the options of integer mode.
+type IntegerOptions struct {
+	Bits     int  `json:"bits"`
+	Unsigned bool `json:"unsigned,omitempty"`
+	Wrap     bool `json:"wrap,omitempty"`
+}
+
+// HistoryFilter narrows the calculation history. Zero values mean "no restriction".
+type HistoryFilter struct {
+	From      time.Time
+	To        time.Time
+	ErrorType string
+	Client    string
+	Limit     int
+	Offset    int
+}
+
+// IsEmpty reports whether the filter restricts nothing besides pagination.
+func (f HistoryFilter) IsEmpty() bool {
+	return f.From.IsZero() && f.To.IsZero() && f.ErrorType == "" && f.Client == ""
+}
+
+// HistoryPage is a single page of the calculation history.
+type HistoryPage struct {
+	Items  []Calculation `json:"items"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// Formula is a named expression with declared parameters, e.g. shipping(weight, zone) = 4.5 + weight*0.8*zone.
+// Every change creates a new version; UpdatedAt is the time the version was saved.
+type Formula struct {
+	Name        string    `json:"name"`
+	Params      []string  `json:"params"`
+	Expression  string    `json:"expression"`
+	Description string    `json:"description,omitempty"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SimplifyRequest asks for the canonical form of an expression.
+type SimplifyRequest struct {
+	Expression string
+}
+
+// EquivalenceRequest asks whether two expressions have the same value for all values of their variables.
+// Zero Tolerance and Samples mean the defaults of the calculator.
+type EquivalenceRequest struct {
+	Left      string
+	Right     string
+	Tolerance float64
+	Samples   int
+	Seed      uint64
+}
+
+// SampleRequest asks for the values of an expression on a grid of one or two axes with Steps steps each.
+type SampleRequest struct {
+	Expression string
+	Axes       []calculator.Axis
+	Steps      int
+}
+
+// DerivativeRequest differentiates an expression with respect to a variable and, if At is set,
+// evaluates the derivative at that value of the variable.
+type DerivativeRequest struct {
+	Expression string
+	Variable   string
+	At         *float64
+}
+
+// DerivativeResult is the simplified derivative and, if it was requested, its value.
+type DerivativeResult struct {
+	Derivative *calculator.Expression
+	Value      calculator.Value
+}
+
+// PolynomialRequest analyses an expression as a polynomial in Variable: its factors and roots, the quotient,
+// remainder and greatest common divisor with Divisor if it is set, and the value at At if it is set.
+type PolynomialRequest struct {
+	Expression string
+	Variable   string
+	Divisor    string
+	At         *float64
+}
+
+// PolynomialResult is the analysis of a polynomial. Factorization is nil for the polynomial 0, which has no roots,
+// and for a polynomial too large to factor, whose roots are still computed.
+type PolynomialResult struct {
+	Polynomial    calculator.Polynomial
+	Factorization *calculator.Factorization
+	Roots         []complex128
+	Quotient      *calculator.Polynomial
+	Remainder     *calculator.Polynomial
+	GCD           *calculator.Polynomial
+	Value         *float64
+}
@@ -1,7 +1,9 @@
 package router
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,7 +13,7 @@ import (
 	"calculate-service/internal/handlers"
 )
 
-func New(ctrl controller.Controller, apiVersion string) *chi.Mux {
+func New(ctrl controller.Controller, apiVersion, adminToken string) *chi.Mux {
 	h := handlers.New(ctrl)
 	r := chi.NewRouter()
 
@@ -26,8 +28,55 @@ func New(ctrl controller.Controller, apiVersion string) *chi.Mux {
 	r.Route("/api", func(r chi.Router) {
 		r.Route(fmt.Sprintf("/%s", apiVersion), func(r chi.Router) {
 			r.Post("/calculate", h.Calculate)
+			r.Post("/simplify", h.Simplify)
+			r.Post("/equivalent", h.Equivalent)
+			r.Post("/derivative", h.Derivative)
+			r.Post("/sample", h.Sample)
+			r.Post("/polynomial", h.Polynomial)
+
+			// The history holds every client's expressions, so only the admin can read or delete it.
+			r.Route("/history", func(r chi.Router) {
+				r.Use(requireToken(adminToken))
+				r.Get("/", h.History)
+				r.Delete("/", h.DeleteHistory)
+				r.Delete("/{id}", h.DeleteHistoryItem)
+			})
+
+			r.Route("/formulas", func(r chi.Router) {
+				r.Get("/", h.Formulas)
+				r.Post("/", h.CreateFormula)
+				r.Get("/{name}", h.Formula)
+				r.Put("/{name}", h.UpdateFormula)
+				r.Delete("/{name}", h.DeleteFormula)
+				r.Get("/{name}/versions", h.FormulaVersions)
+				r.Post("/{name}/evaluate", h.EvaluateFormula)
+			})
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(requireToken(adminToken))
+				r.Get("/rates", h.Rates)
+				r.Put("/rates", h.SetRates)
+			})
 		})
 	})
 
 	return r
 }
+
+// requireToken lets through only the requests with the header "Authorization: Bearer <token>".
+// Without a configured token the routes are disabled.
+func requireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, "admin endpoints are disabled", http.StatusForbidden)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"calculate-service/internal/models"
+)
+
+const formulaColumns = `v.name, v.version, v.params, v.expression, v.description, v.created_at,
+	(SELECT MIN(created_at) FROM formula_versions WHERE name = v.name)`
+
+// CreateFormula stores the first version of a new formula. A formula that was deleted earlier
+// can be created again; its version numbers continue where the old ones stopped.
+func (s *storage) CreateFormula(ctx context.Context, f models.Formula) (models.Formula, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Formula{}, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM formulas WHERE name = ?", f.Name).Scan(&exists)
+	if err != nil {
+		return models.Formula{}, err
+	}
+	if exists > 0 {
+		return models.Formula{}, ErrAlreadyExists
+	}
+
+	f, err = insertFormulaVersion(ctx, tx, f)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO formulas (name, version, created_at) VALUES (?, ?, ?)",
+		f.Name, f.Version, f.UpdatedAt.UnixNano())
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	f.CreatedAt = f.UpdatedAt
+
+	return f, tx.Commit()
+}
+
+// UpdateFormula stores a new version of an existing formula.
+func (s *storage) UpdateFormula(ctx context.Context, f models.Formula) (models.Formula, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Formula{}, err
+	}
+	defer tx.Rollback()
+
+	var createdAt int64
+	err = tx.QueryRowContext(ctx, "SELECT created_at FROM formulas WHERE name = ?", f.Name).Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Formula{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	f, err = insertFormulaVersion(ctx, tx, f)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE formulas SET version = ? WHERE name = ?", f.Version, f.Name)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	f.CreatedAt = time.Unix(0, createdAt).UTC()
+
+	return f, tx.Commit()
+}
+
+// GetFormula returns the given version of a formula, or its current version if version is 0.
+// Older versions stay available after the formula is deleted.
+func (s *storage) GetFormula(ctx context.Context, name string, version int) (models.Formula, error) {
+	var row *sql.Row
+	if version == 0 {
+		row = s.db.QueryRowContext(ctx, `SELECT `+formulaColumns+` FROM formula_versions v
+			JOIN formulas f ON f.name = v.name AND f.version = v.version WHERE v.name = ?`, name)
+	} else {
+		row = s.db.QueryRowContext(ctx, `SELECT `+formulaColumns+` FROM formula_versions v
+			WHERE v.name = ? AND v.version = ?`, name, version)
+	}
+
+	f, err := scanFormula(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Formula{}, ErrNotFound
+	}
+
+	return f, err
+}
+
+// ListFormulas returns the current version of every formula, ordered by name.
+func (s *storage) ListFormulas(ctx context.Context) ([]models.Formula, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+formulaColumns+` FROM formula_versions v
+		JOIN formulas f ON f.name = v.name AND f.version = v.version ORDER BY v.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFormulas(rows)
+}
+
+// FormulaVersions returns every stored version of a formula, newest first.
+func (s *storage) FormulaVersions(ctx context.Context, name string) ([]models.Formula, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+formulaColumns+` FROM formula_versions v
+		WHERE v.name = ? ORDER BY v.version DESC`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	formulas, err := scanFormulas(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(formulas) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return formulas, nil
+}
+
+// DeleteFormula removes a formula; its version history is kept.
+func (s *storage) DeleteFormula(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM formulas WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func insertFormulaVersion(ctx context.Context, tx *sql.Tx, f models.Formula) (models.Formula, error) {
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) + 1 FROM formula_versions WHERE name = ?",
+		f.Name).Scan(&f.Version)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO formula_versions (name, version, params, expression, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Name, f.Version, strings.Join(f.Params, ","), f.Expression, f.Description, f.UpdatedAt.UnixNano(),
+	)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	return f, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFormula(row scanner) (models.Formula, error) {
+	var f models.Formula
+	var params string
+	var updatedAt, createdAt int64
+
+	err := row.Scan(&f.Name, &f.Version, &params, &f.Expression, &f.Description, &updatedAt, &createdAt)
+	if err != nil {
+		return models.Formula{}, err
+	}
+
+	f.Params = []string{}
+	if params != "" {
+		f.Params = strings.Split(params, ",")
+	}
+	f.CreatedAt = time.Unix(0, createdAt).UTC()
+	f.UpdatedAt = time.Unix(0, updatedAt).UTC()
+
+	return f, nil
+}
+
+func scanFormulas(rows *sql.Rows) ([]models.Formula, error) {
+	defer rows.Close()
+
+	formulas := []models.Formula{}
+	for rows.Next() {
+		f, err := scanFormula(rows)
+		if err != nil {
+			return nil, err
+		}
+		formulas = append(formulas, f)
+	}
+
+	return formulas, rows.Err()
+}
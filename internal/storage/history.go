@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"calculate-service/internal/models"
+)
+
+func (s *storage) SaveCalculation(ctx context.Context, calc models.Calculation) (int64, error) {
+	options, err := json.Marshal(calc.Options)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO calculations (expression, result, error, error_type, request_id, client, created_at, options)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		calc.Expression, calc.Result, calc.Error, calc.ErrorType, calc.RequestID, calc.Client, calc.CreatedAt.UnixNano(),
+		string(options),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func (s *storage) ListCalculations(ctx context.Context, filter models.HistoryFilter) ([]models.Calculation, int, error) {
+	where, args := historyWhere(filter)
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM calculations"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, expression, result, error, error_type, request_id, client, created_at, options
+		FROM calculations`+where+` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, filter.Limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	calcs := make([]models.Calculation, 0, filter.Limit)
+	for rows.Next() {
+		var calc models.Calculation
+		var createdAt int64
+		var options string
+		err = rows.Scan(&calc.ID, &calc.Expression, &calc.Result, &calc.Error, &calc.ErrorType,
+			&calc.RequestID, &calc.Client, &createdAt, &options)
+		if err != nil {
+			return nil, 0, err
+		}
+		if options != "" {
+			if err = json.Unmarshal([]byte(options), &calc.Options); err != nil {
+				return nil, 0, err
+			}
+		}
+		calc.CreatedAt = time.Unix(0, createdAt).UTC()
+		calcs = append(calcs, calc)
+	}
+
+	return calcs, total, rows.Err()
+}
+
+func (s *storage) DeleteCalculations(ctx context.Context, filter models.HistoryFilter) (int64, error) {
+	where, args := historyWhere(filter)
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM calculations"+where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *storage) DeleteCalculation(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM calculations WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// historyWhere builds the WHERE clause for the non-pagination part of a filter.
+// The time range is half-open: From is inclusive, To is exclusive.
+func historyWhere(filter models.HistoryFilter) (string, []any) {
+	var conds []string
+	var args []any
+
+	if !filter.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, filter.From.UnixNano())
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, filter.To.UnixNano())
+	}
+	if filter.ErrorType != "" {
+		conds = append(conds, "error_type = ?")
+		args = append(args, filter.ErrorType)
+	}
+	if filter.Client != "" {
+		conds = append(conds, "client = ?")
+		args = append(args, filter.Client)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
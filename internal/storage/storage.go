@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"calculate-service/internal/models"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS calculations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		expression TEXT NOT NULL,
+		result TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		error_type TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		client TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		options TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS calculations_created_at ON calculations (created_at)`,
+	`CREATE INDEX IF NOT EXISTS calculations_client ON calculations (client, created_at)`,
+	`CREATE TABLE IF NOT EXISTS formulas (
+		name TEXT PRIMARY KEY,
+		version INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS formula_versions (
+		name TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		params TEXT NOT NULL,
+		expression TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (name, version)
+	)`,
+}
+
+// columns are added to the tables of a database created before them.
+var columns = []struct{ table, name, definition string }{
+	{"calculations", "options", "TEXT NOT NULL DEFAULT ''"},
+}
+
+type storage struct {
+	db *sql.DB
+}
+
+type Storage interface {
+	SaveCalculation(ctx context.Context, calc models.Calculation) (int64, error)
+	ListCalculations(ctx context.Context, filter models.HistoryFilter) ([]models.Calculation, int, error)
+	DeleteCalculations(ctx context.Context, filter models.HistoryFilter) (int64, error)
+	DeleteCalculation(ctx context.Context, id int64) error
+
+	CreateFormula(ctx context.Context, f models.Formula) (models.Formula, error)
+	UpdateFormula(ctx context.Context, f models.Formula) (models.Formula, error)
+	GetFormula(ctx context.Context, name string, version int) (models.Formula, error)
+	ListFormulas(ctx context.Context) ([]models.Formula, error)
+	FormulaVersions(ctx context.Context, name string) ([]models.Formula, error)
+	DeleteFormula(ctx context.Context, name string) error
+
+	Close() error
+}
+
+// New opens (or creates) the SQLite database at path and applies the schema.
+// Use ":memory:" for a throwaway database.
+func New(path string) (Storage, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection also keeps ":memory:" databases shared.
+	db.SetMaxOpenConns(1)
+
+	for _, m := range migrations {
+		if _, err = db.Exec(m); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate database: %w", err)
+		}
+	}
+	for _, c := range columns {
+		var n int
+		err = db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.name).Scan(&n)
+		if err == nil && n == 0 {
+			_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.definition))
+		}
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate database: %w", err)
+		}
+	}
+
+	return &storage{db: db}, nil
+}
+
+func (s *storage) Close() error {
+	return s.db.Close()
+}
@@ -2,24 +2,28 @@ package calculator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Evaluate takes a mathematical expression as a string and returns the result or an error.
 func Evaluate(expr string) (float64, error) {
-	tokens, err := tokenize(expr)
-	if err != nil {
-		return 0, err
-	}
+	return NewEnvironment(Options{}).Evaluate(expr)
+}
 
-	rpn, err := toRPN(tokens)
+// parse converts an expression into Reverse Polish Notation.
+func parse(expr string, options Options) ([]string, error) {
+	tokens, err := tokenizeWithOptions(expr, options)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return calculateRPN(rpn)
+	return toRPN(tokens)
 }
 
 type tokenType string
@@ -27,6 +31,7 @@ type tokenType string
 const (
 	Empty        tokenType = ""
 	Number       tokenType = "number"
+	Identifier   tokenType = "identifier"
 	Operator     tokenType = "operator"
 	Dot          tokenType = "."
 	UnaryMinus   tokenType = "unaryMinus"
@@ -34,29 +39,190 @@ const (
 	Sub          tokenType = "-"
 	Multi        tokenType = "*"
 	Div          tokenType = "/"
+	Pow          tokenType = "^"
+	Neg          tokenType = "u-"
 	BracketLeft  tokenType = "("
 	BracketRight tokenType = ")"
+	ListLeft     tokenType = "["
+	ListRight    tokenType = "]"
+	Comma        tokenType = ","
+	Semicolon    tokenType = ";"
+	Assign       tokenType = "="
+	Not          tokenType = "!"
+	And          tokenType = "&&"
+	Or           tokenType = "||"
+	Eq           tokenType = "=="
+	NotEq        tokenType = "!="
+	Less         tokenType = "<"
+	LessEq       tokenType = "<="
+	Greater      tokenType = ">"
+	GreaterEq    tokenType = ">="
+	Question     tokenType = "?"
+	Colon        tokenType = ":"
+	Percent      tokenType = "%"
+	Factorial    tokenType = "n!"
+	BitAnd       tokenType = "&"
+	BitOr        tokenType = "|"
+	BitNot       tokenType = "~"
+	ShiftLeft    tokenType = "<<"
+	ShiftRight   tokenType = ">>"
+	Xor          tokenType = "b^" // "^" in integer mode
+	Rem          tokenType = "i%" // "%" in integer mode
+	Convert      tokenType = "->" // "to" or "in" between a quantity and a unit
+	UnitMulti    tokenType = "q*" // a number followed by a unit, 10 km, which toRPN turns into "*"
+	PlusMinus    tokenType = "±"  // also "+/-"
+	Quote        tokenType = "\""
 )
 
-// tokenize converts the input string into a slice of tokens.
+// binaryOperators are the comparison, boolean, bitwise and conditional operators, longest first.
+var binaryOperators = []tokenType{
+	Eq, NotEq, LessEq, GreaterEq, And, Or, ShiftLeft, ShiftRight, Less, Greater, BitAnd, BitOr, Question, Colon,
+}
+
+// binaryOperatorAt returns the operator from binaryOperators the input starts with, or an empty string.
+func binaryOperatorAt(input string) string {
+	for _, op := range binaryOperators {
+		if strings.HasPrefix(input, string(op)) {
+			return string(op)
+		}
+	}
+	return ""
+}
+
+// negate appends a unary minus. It is spelled "-1 *" except right after "^" or "!", where the multiplication
+// would bind looser than the preceding operator: 2^-3 must not become (2^-1)*3.
+func negate(tokens []string) []string {
+	if len(tokens) > 0 {
+		switch tokenType(tokens[len(tokens)-1]) {
+		case Pow, Not, BitNot:
+			return append(tokens, string(Neg))
+		}
+	}
+	return append(tokens, unaryMinus, "*")
+}
+
+// A unary minus is spelled unaryMinus*x. Right before the digits of a literal in integer mode it is spelled
+// literalMinus instead, so that -128 is read as one int8 literal while -(128) overflows.
+const (
+	unaryMinus   = "-1"
+	literalMinus = "-1."
+)
+
+// negateLiteral appends the unary minus of a literal.
+func negateLiteral(tokens []string, options Options) []string {
+	tokens = negate(tokens)
+	if options.Integer.Enabled() && tokenType(tokens[len(tokens)-1]) == Multi {
+		tokens[len(tokens)-2] = literalMinus
+	}
+	return tokens
+}
+
+// tokenize converts the input string into a slice of tokens, accepting implicit multiplication.
 func tokenize(input string) ([]string, error) {
+	return tokenizeWithOptions(input, Options{})
+}
+
+// tokenizeWithOptions converts the input string into a slice of tokens.
+// Unless options.Strict is set, juxtaposed operands are multiplied: an explicit "*" is inserted
+// between a number or ")" and a following number, name or "(", and between two names separated by spaces.
+// A name directly followed by "(" is a function call, not a multiplication.
+func tokenizeWithOptions(input string, options Options) ([]string, error) {
 	var tokens []string
 	var brackets int
 	var currToken strings.Builder
 
 	prevTokenType := Empty
+	skip := 0
 
 	for i, r := range input {
+		if skip > 0 {
+			skip--
+			continue
+		}
+
 		switch {
+		case r == '\n' && brackets == 0 &&
+			(prevTokenType == Number || prevTokenType == Identifier || prevTokenType == BracketRight):
+			// A line break after a complete operand separates statements like ";" does.
+			if currToken.Len() > 0 {
+				tokens = append(tokens, currToken.String())
+				currToken.Reset()
+			}
+			tokens = append(tokens, string(Semicolon))
+			prevTokenType = Empty
 		case unicode.IsSpace(r):
+			// Whitespace ends an identifier: "ab cd" is two identifiers, not "abcd".
+			if prevTokenType == Identifier && currToken.Len() > 0 {
+				tokens = append(tokens, currToken.String())
+				currToken.Reset()
+			}
+			continue
+		case prevTokenType == Number && continuesRadixLiteral(currToken.String(), input[i:]):
+			currToken.WriteRune(r)
+			continue
+		case prevTokenType == Number && exponentAt(currToken.String(), input[i:]) != "":
+			// The exponent belongs to the number, 1.5e-3 is not 1.5*e - 3.
+			exponent := exponentAt(currToken.String(), input[i:])
+			currToken.WriteString(exponent)
+			skip = len(exponent) - 1
+			continue
+		case unicode.IsDigit(r) && prevTokenType == Identifier && currToken.Len() > 0:
+			currToken.WriteRune(r)
 			continue
 		case unicode.IsDigit(r):
-			if prevTokenType == UnaryMinus {
-				tokens = append(tokens, "-1", "*")
+			switch {
+			case prevTokenType == UnaryMinus:
+				tokens = negateLiteral(tokens, options)
+			case !options.Strict && (prevTokenType == BracketRight || prevTokenType == Identifier):
+				tokens = append(tokens, string(Multi))
 			}
 			currToken.WriteRune(r)
 			prevTokenType = Number
 			continue
+		case (prevTokenType == Number && conversionAt(input, i) == "to" ||
+			prevTokenType == Identifier || prevTokenType == BracketRight) && conversionAt(input, i) != "":
+			// "to" or "in" after an operand converts it into the unit that follows: 60 mi/h to m/s.
+			// Right after a number "in" is the inch: 72 in to ft.
+			if currToken.Len() > 0 {
+				tokens = append(tokens, currToken.String())
+			}
+			tokens = append(tokens, string(Convert))
+			currToken.Reset()
+			prevTokenType = Operator
+			skip = len(conversionAt(input, i)) - 1
+		case unicode.IsLetter(r) || r == '_':
+			switch prevTokenType {
+			case Identifier:
+				if currToken.Len() > 0 {
+					currToken.WriteRune(r)
+					continue
+				}
+				if options.Strict {
+					return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("position %d: %c", i, r))
+				}
+				tokens = append(tokens, string(Multi))
+			case Empty, BracketLeft, Operator:
+			case UnaryMinus:
+				tokens = negate(tokens)
+			case Number, BracketRight:
+				if options.Strict {
+					return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("position %d: %c", i, r))
+				}
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+					currToken.Reset()
+				}
+				if prevTokenType == Number && isQuantityUnit(input[i:], options) {
+					tokens = append(tokens, string(UnitMulti))
+					break
+				}
+				tokens = append(tokens, string(Multi))
+			default:
+				return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("position %d: %c", i, r))
+			}
+			currToken.WriteRune(r)
+			prevTokenType = Identifier
+			continue
 		case tokenType(r) == Dot:
 			switch prevTokenType {
 			case Number:
@@ -68,13 +234,22 @@ func tokenize(input string) ([]string, error) {
 			}
 		case tokenType(r) == BracketLeft:
 			switch prevTokenType {
-			case Empty, BracketLeft, Operator:
+			case Empty, BracketLeft, Operator, Identifier:
 				if currToken.Len() > 0 {
 					tokens = append(tokens, currToken.String())
 					currToken.Reset()
 				}
 			case UnaryMinus:
-				tokens = append(tokens, "-1", "*")
+				tokens = negate(tokens)
+			case Number, BracketRight:
+				if options.Strict {
+					return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+				}
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+					currToken.Reset()
+				}
+				tokens = append(tokens, string(Multi))
 			default:
 				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
 			}
@@ -83,11 +258,49 @@ func tokenize(input string) ([]string, error) {
 			prevTokenType = BracketLeft
 		case tokenType(r) == BracketRight:
 			switch prevTokenType {
-			case Number, BracketRight:
+			case Number, Identifier, BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+					currToken.Reset()
+				}
+			case BracketLeft:
+				// Only a function call may have empty parentheses, e.g. f().
+				if len(tokens) < 2 || tokenType(tokens[len(tokens)-1]) != BracketLeft || !isIdentifier(tokens[len(tokens)-2]) {
+					return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+				}
+			default:
+				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+			}
+			tokens = append(tokens, string(r))
+			brackets--
+			if brackets < 0 {
+				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+			}
+			prevTokenType = BracketRight
+		case tokenType(r) == ListLeft:
+			// A list literal [1, 2, 3] stands where an operand may.
+			switch prevTokenType {
+			case Empty, BracketLeft, Operator:
+			case UnaryMinus:
+				tokens = negate(tokens)
+			default:
+				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+			}
+			tokens = append(tokens, string(r))
+			brackets++
+			prevTokenType = BracketLeft
+		case tokenType(r) == ListRight:
+			switch prevTokenType {
+			case Number, Identifier, BracketRight:
 				if currToken.Len() > 0 {
 					tokens = append(tokens, currToken.String())
 					currToken.Reset()
 				}
+			case BracketLeft:
+				// An empty list.
+				if tokenType(tokens[len(tokens)-1]) != ListLeft {
+					return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
+				}
 			default:
 				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
 			}
@@ -97,12 +310,27 @@ func tokenize(input string) ([]string, error) {
 				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %c", i, r))
 			}
 			prevTokenType = BracketRight
+		case tokenType(r) == Quote:
+			// A string literal is a single token, quotes included. Only date() accepts one.
+			switch prevTokenType {
+			case Empty, BracketLeft, Operator:
+			default:
+				return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("position %d: %c", i, r))
+			}
+			end := strings.IndexRune(input[i+1:], r)
+			if end < 0 {
+				return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("unterminated string, position %d", i))
+			}
+			literal := input[i : i+end+2]
+			tokens = append(tokens, literal)
+			skip = utf8.RuneCountInString(literal) - 1
+			prevTokenType = BracketRight
 		case tokenType(r) == Sub:
 			switch prevTokenType {
 			case Empty, BracketLeft, Operator:
 				prevTokenType = UnaryMinus
 				continue
-			case Number, BracketRight:
+			case Number, Identifier, BracketRight:
 				if currToken.Len() > 0 {
 					tokens = append(tokens, currToken.String())
 				}
@@ -112,13 +340,144 @@ func tokenize(input string) ([]string, error) {
 			default:
 				return nil, NewCalcError(ErrTooManyValues, fmt.Sprintf("position %d: %c", i, r))
 			}
-		case strings.ContainsRune("+*/", r):
+		case tokenType(r) == Comma:
+			switch {
+			case brackets == 0:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("argument separator outside parentheses, position %d: %c", i, r))
+			case prevTokenType == Number, prevTokenType == Identifier, prevTokenType == BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(r))
+				currToken.Reset()
+				prevTokenType = Operator
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case tokenType(r) == Semicolon:
+			switch {
+			case brackets != 0:
+				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("unterminated parentheses' group before position %d", i))
+			case prevTokenType == Empty:
+				continue
+			case prevTokenType == Number, prevTokenType == Identifier, prevTokenType == BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(r))
+				currToken.Reset()
+				prevTokenType = Empty
+			default:
+				return nil, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case strings.HasPrefix(input[i:], string(PlusMinus)) || strings.HasPrefix(input[i:], "+/-"):
 			switch prevTokenType {
-			case Number, BracketRight:
+			case Number, Identifier, BracketRight:
 				if currToken.Len() > 0 {
 					tokens = append(tokens, currToken.String())
 				}
+				tokens = append(tokens, string(PlusMinus))
+				currToken.Reset()
+				prevTokenType = Operator
+				if r == '+' {
+					skip = 2
+				}
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %s", i, PlusMinus))
+			}
+		case binaryOperatorAt(input[i:]) != "":
+			op := binaryOperatorAt(input[i:])
+			switch prevTokenType {
+			case Number, Identifier, BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, op)
+				currToken.Reset()
+				prevTokenType = Operator
+				skip = len(op) - 1
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %s", i, op))
+			}
+		case tokenType(r) == Not:
+			switch prevTokenType {
+			case Empty, BracketLeft, Operator:
 				tokens = append(tokens, string(r))
+				prevTokenType = Operator
+			case UnaryMinus:
+				tokens = append(negate(tokens), string(r))
+				prevTokenType = Operator
+			case Number, Identifier, BracketRight:
+				// After an operand "!" is the factorial, which like "%" completes the operand.
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(Factorial))
+				currToken.Reset()
+				prevTokenType = BracketRight
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case tokenType(r) == Percent && options.Integer.Enabled():
+			// In integer mode "%" is the remainder of a division.
+			switch prevTokenType {
+			case Number, Identifier, BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(Rem))
+				currToken.Reset()
+				prevTokenType = Operator
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case tokenType(r) == Percent:
+			switch prevTokenType {
+			case Number, Identifier, BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(r))
+				currToken.Reset()
+				// A percentage is a complete operand: it can be followed by an operator or a ")".
+				prevTokenType = BracketRight
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case tokenType(r) == BitNot:
+			switch prevTokenType {
+			case Empty, BracketLeft, Operator:
+			case UnaryMinus:
+				tokens = negate(tokens)
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+			tokens = append(tokens, string(r))
+			prevTokenType = Operator
+		case tokenType(r) == Assign:
+			// Inside brackets "=" is the sign of an equation, such as the first argument of solve(2x + 3 = 7, x).
+			switch {
+			case prevTokenType == Identifier, prevTokenType == BracketRight, brackets != 0 && prevTokenType == Number:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				tokens = append(tokens, string(r))
+				currToken.Reset()
+				prevTokenType = Operator
+			default:
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("position %d: %c", i, r))
+			}
+		case strings.ContainsRune("+*/^", r):
+			switch prevTokenType {
+			case Number, Identifier, BracketRight:
+				if currToken.Len() > 0 {
+					tokens = append(tokens, currToken.String())
+				}
+				if tokenType(r) == Pow && options.Integer.Enabled() {
+					tokens = append(tokens, string(Xor))
+				} else {
+					tokens = append(tokens, string(r))
+				}
 				currToken.Reset()
 				prevTokenType = Operator
 			default:
@@ -142,65 +501,225 @@ func tokenize(input string) ([]string, error) {
 
 // isOperator checks if a rune is an arithmetic operator.
 func isOperator(ch rune) bool {
-	return tokenType(ch) == Add || tokenType(ch) == Sub || tokenType(ch) == Multi || tokenType(ch) == Div
+	return tokenType(ch) == Add || tokenType(ch) == Sub || tokenType(ch) == Multi || tokenType(ch) == Div ||
+		tokenType(ch) == Pow
+}
+
+// isBinaryOperator checks if a token is an arithmetic or a logical operator taking two operands.
+func isBinaryOperator(token string) bool {
+	if len(token) == 1 && isOperator(rune(token[0])) {
+		return true
+	}
+	switch tokenType(token) {
+	case Xor, Rem, Convert, Assign, PlusMinus, UnitMulti:
+		return true
+	}
+	return slices.Contains(binaryOperators, tokenType(token))
+}
+
+// isPrefixOperator checks if a token is a unary operator written before its operand.
+func isPrefixOperator(token string) bool {
+	return tokenType(token) == Neg || tokenType(token) == Not || tokenType(token) == BitNot
 }
 
-// precedence returns the precedence of an operator.
+// isPostfixOperator checks if a token is a unary operator written after its operand.
+func isPostfixOperator(token string) bool {
+	return tokenType(token) == Percent || tokenType(token) == Factorial
+}
+
+// precedence returns the precedence of an operator, 0 for anything else.
+// Operators that bind looser than addition have negative precedence; a unit conversion binds looser than
+// any of them but the "=" of an equation. A number and its unit bind tighter than "*" and "/", so 10 km / 2 h
+// divides two quantities, and looser than "^", so 2 m^2 is two square metres.
+// The bitwise operators bind as in Go: "&", "<<" and ">>" like "*", "|" and the integer "^" like "+".
 func precedence(op string) int {
 	switch tokenType(op) {
-	case Add, Sub:
+	case Assign:
+		return -6
+	case Convert:
+		return -5
+	case Question, Colon:
+		return -4
+	case Or:
+		return -3
+	case And:
+		return -2
+	case Eq, NotEq, Less, LessEq, Greater, GreaterEq:
+		return -1
+	case Add, Sub, BitOr, Xor, PlusMinus:
 		return 1
-	case Multi, Div:
+	case Multi, Div, Rem, BitAnd, ShiftLeft, ShiftRight:
 		return 2
+	case Neg, Not, BitNot, UnitMulti:
+		return 3
+	case Pow:
+		return 4
+	case Percent, Factorial:
+		return 5
 	}
 	return 0
 }
 
+// isRightAssociative reports whether an operator groups from the right: 2^3^2 is 2^(3^2)
+// and a ? b : c ? d : e is a ? b : (c ? d : e).
+func isRightAssociative(op string) bool {
+	switch tokenType(op) {
+	case Pow, Neg, Not, BitNot, Question, Colon:
+		return true
+	}
+	return false
+}
+
+// callToken is the RPN spelling of a function call: its name and the number of arguments, e.g. "max/3".
+func callToken(name string, argc int) string {
+	return fmt.Sprintf("%s/%d", name, argc)
+}
+
+// parseCallToken splits a token produced by callToken.
+func parseCallToken(token string) (string, int, bool) {
+	name, argc, ok := strings.Cut(token, "/")
+	if !ok || !isIdentifier(name) {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(argc)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return name, n, true
+}
+
+// listToken is the RPN spelling of a list literal: the number of its items, e.g. "[]/3".
+func listToken(argc int) string {
+	return fmt.Sprintf("%s%s/%d", ListLeft, ListRight, argc)
+}
+
+// parseListToken splits a token produced by listToken.
+func parseListToken(token string) (int, bool) {
+	argc, ok := strings.CutPrefix(token, string(ListLeft)+string(ListRight)+"/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(argc)
+	return n, err == nil
+}
+
+// isOpening reports whether a token opens a parentheses' group or a list.
+func isOpening(token string) bool {
+	return tokenType(token) == BracketLeft || tokenType(token) == ListLeft
+}
+
+// group is an open parentheses' group or list in toRPN; for a function call or a list it counts
+// the arguments or items seen so far.
+type group struct {
+	call bool
+	argc int
+}
+
 // toRPN converts a list of tokens to Reverse Polish Notation using the Shunting Yard algorithm.
+// Function calls are emitted as call tokens, see callToken.
 func toRPN(tokens []string) ([]string, error) {
 	var output []string
 	var operators []string
+	var groups []group
 
 	for i, token := range tokens {
 		switch {
-		case isNumber(token):
+		case isIdentifier(token) && i+1 < len(tokens) && tokenType(tokens[i+1]) == BracketLeft:
+			operators = append(operators, token)
+		case isNumber(token), isIdentifier(token), isString(token):
 			output = append(output, token)
 		case tokenType(token) == BracketLeft:
+			call := i > 0 && isIdentifier(tokens[i-1])
+			groups = append(groups, group{call: call})
+			operators = append(operators, token)
+		case tokenType(token) == ListLeft:
+			groups = append(groups, group{call: true})
 			operators = append(operators, token)
-		case tokenType(token) == BracketRight:
-			for len(operators) > 0 && tokenType(operators[len(operators)-1]) != BracketLeft {
+		case tokenType(token) == Comma:
+			for len(operators) > 0 && !isOpening(operators[len(operators)-1]) {
 				output = append(output, operators[len(operators)-1])
 				operators = operators[:len(operators)-1]
 			}
-			if len(operators) == 0 {
+			if len(groups) == 0 || !groups[len(groups)-1].call {
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("argument separator outside function call, position %d: %s", i, token))
+			}
+			groups[len(groups)-1].argc++
+		case tokenType(token) == BracketRight, tokenType(token) == ListRight:
+			for len(operators) > 0 && !isOpening(operators[len(operators)-1]) {
+				output = append(output, operators[len(operators)-1])
+				operators = operators[:len(operators)-1]
+			}
+			if len(operators) == 0 || len(groups) == 0 {
 				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("position %d: %s", i, token))
 			}
+			opening := operators[len(operators)-1]
+			if (tokenType(opening) == ListLeft) != (tokenType(token) == ListRight) {
+				return nil, NewCalcError(ErrMismatchedParentheses, fmt.Sprintf("%s closed by %s, position %d", opening, token, i))
+			}
 			operators = operators[:len(operators)-1]
-		case isOperator(rune(token[0])):
-			for len(operators) > 0 && precedence(operators[len(operators)-1]) >= precedence(token) {
-				output = append(output, operators[len(operators)-1])
+
+			g := groups[len(groups)-1]
+			groups = groups[:len(groups)-1]
+			if tokenType(token) == ListRight {
+				if tokenType(tokens[i-1]) != ListLeft {
+					g.argc++
+				}
+				output = append(output, listToken(g.argc))
+			} else if g.call {
+				if tokenType(tokens[i-1]) != BracketLeft {
+					g.argc++
+				}
+				output = append(output, callToken(operators[len(operators)-1], g.argc))
+				operators = operators[:len(operators)-1]
+			}
+		case isPrefixOperator(token):
+			operators = append(operators, token)
+		case isPostfixOperator(token):
+			// A postfix operator binds tighter than anything else, its operand is already in the output.
+			output = append(output, token)
+		case isBinaryOperator(token):
+			for len(operators) > 0 {
+				top := operators[len(operators)-1]
+				if !isBinaryOperator(top) && !isPrefixOperator(top) {
+					break
+				}
+				if precedence(top) < precedence(token) || (precedence(top) == precedence(token) && isRightAssociative(token)) {
+					break
+				}
+				output = append(output, top)
 				operators = operators[:len(operators)-1]
 			}
 			operators = append(operators, token)
+		case unicode.IsDigit(rune(token[0])):
+			return nil, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("malformed number %s", token))
 		default:
 			return nil, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %s", i, token))
 		}
 	}
 
 	for len(operators) > 0 {
-		if tokenType(operators[len(operators)-1]) == BracketLeft {
+		if isOpening(operators[len(operators)-1]) {
 			return nil, NewCalcError(ErrMismatchedParentheses, "")
 		}
 		output = append(output, operators[len(operators)-1])
 		operators = operators[:len(operators)-1]
 	}
 
+	// Once grouped, a number and its unit are multiplied like any other operands.
+	for i, token := range output {
+		if tokenType(token) == UnitMulti {
+			output[i] = string(Multi)
+		}
+	}
+
 	return output, nil
 }
 
 // isNumber checks if a string represents a number.
 func isNumber(s string) bool {
-	num, err := strconv.ParseFloat(s, 64)
+	num, err := parseNumber(s)
 	if err != nil {
 		return false
 	}
@@ -211,53 +730,221 @@ func isNumber(s string) bool {
 	return true
 }
 
+// parseNumber reads a decimal number or a hexadecimal, binary or octal integer literal such as 0xff, 0b1010 or 0o17.
+func parseNumber(s string) (float64, error) {
+	if !isRadixLiteral(s) {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	n, ok := parseIntegerLiteral(s)
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f, nil
+}
+
+// isRadixLiteral reports whether s starts like a hexadecimal, binary or octal literal.
+func isRadixLiteral(s string) bool {
+	return len(s) > 1 && s[0] == '0' && strings.ContainsRune("xXbBoO", rune(s[1]))
+}
+
+// radixDigits are the digits of the bases a radix literal may have, by the letter after its leading 0.
+var radixDigits = map[byte]string{
+	'x': "0123456789abcdefABCDEF", 'X': "0123456789abcdefABCDEF",
+	'b': "01", 'B': "01",
+	'o': "01234567", 'O': "01234567",
+}
+
+// continuesRadixLiteral reports whether the input extends the literal being read: the base letter after a leading 0,
+// if a digit of that base follows, or a hexadecimal digit letter after 0x. Otherwise 0x is 0 times x.
+func continuesRadixLiteral(literal, input string) bool {
+	switch {
+	case literal == "0" && len(input) > 1:
+		digits, ok := radixDigits[input[0]]
+		return ok && strings.IndexByte(digits, input[1]) >= 0
+	case strings.HasPrefix(literal, "0x"), strings.HasPrefix(literal, "0X"):
+		return strings.ContainsRune("abcdefABCDEF", rune(input[0]))
+	}
+	return false
+}
+
+// parseIntegerLiteral reads a decimal integer, also written like 1e6, or a hexadecimal, binary or octal literal.
+func parseIntegerLiteral(s string) (*big.Int, bool) {
+	if isRadixLiteral(s) {
+		return new(big.Int).SetString(s, 0)
+	}
+	// A decimal literal may have an exponent, 1e6, as long as its value is an integer.
+	r, ok := decimalLiteral(s)
+	if !ok || !r.IsInt() {
+		return nil, false
+	}
+	return new(big.Int).Set(r.Num()), true
+}
+
+// maxExponentDigits bounds the exponent of a decimal literal read exactly: 1e9999 is far beyond any float64
+// already, and a larger exponent would only cost time and memory.
+const maxExponentDigits = 4
+
+// decimalLiteral returns the exact value of a decimal literal such as 0.1 or 1.5e-3.
+func decimalLiteral(s string) (*big.Rat, bool) {
+	if i := strings.IndexAny(s, "eE"); i >= 0 && len(strings.TrimLeft(s[i+1:], "+-")) > maxExponentDigits {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// literalValue returns the exact value of a number literal, decimal or radix.
+func literalValue(s string) (*big.Rat, bool) {
+	if isRadixLiteral(s) {
+		i, ok := parseIntegerLiteral(s)
+		if !ok {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(i), true
+	}
+	return decimalLiteral(s)
+}
+
+// exponentAt returns the exponent of scientific notation, e5, e-3 or E+10, that the input starts with
+// if it continues the decimal literal, or an empty string.
+func exponentAt(literal, input string) string {
+	if isRadixLiteral(literal) || len(input) < 2 || (input[0] != 'e' && input[0] != 'E') {
+		return ""
+	}
+	end := 1
+	if input[end] == '+' || input[end] == '-' {
+		end++
+	}
+	digits := end
+	for end < len(input) && input[end] >= '0' && input[end] <= '9' {
+		end++
+	}
+	if end == digits {
+		return ""
+	}
+	return input[:end]
+}
+
+// isQuantityUnit reports whether the input starts with the name of a unit or a currency, not called as a function,
+// that follows a number: the two form a single operand such as 10 km or 50 USD.
+func isQuantityUnit(input string, options Options) bool {
+	end := strings.IndexFunc(input, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' && !unicode.IsDigit(r) })
+	if end < 0 {
+		end = len(input)
+	}
+	if strings.HasPrefix(input[end:], string(BracketLeft)) {
+		return false
+	}
+	if options.Rates != nil && options.Rates.has(input[:end]) {
+		return true
+	}
+	_, ok := options.units().lookup(input[:end])
+	return ok
+}
+
+// conversionKeywords are the spellings of the unit conversion operator.
+var conversionKeywords = []string{"to", "in"}
+
+func isConversionKeyword(s string) bool {
+	return slices.Contains(conversionKeywords, s)
+}
+
+// conversionAt returns the conversion keyword at position i of the input if it stands alone
+// between whitespace, or an empty string.
+func conversionAt(input string, i int) string {
+	if before, _ := utf8.DecodeLastRuneInString(input[:i]); !unicode.IsSpace(before) {
+		return ""
+	}
+	for _, keyword := range conversionKeywords {
+		rest, ok := strings.CutPrefix(input[i:], keyword)
+		if after, _ := utf8.DecodeRuneInString(rest); ok && unicode.IsSpace(after) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// isString checks if a token is a string literal, e.g. "2026-03-01".
+func isString(token string) bool {
+	return len(token) >= 2 && strings.HasPrefix(token, string(Quote)) && strings.HasSuffix(token, string(Quote))
+}
+
+// isIdentifier checks if a string is a valid variable name: a letter or underscore followed by letters, digits or underscores.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
 // calculateRPN calculates the result of an expression in Reverse Polish Notation.
 func calculateRPN(rpn []string) (float64, error) {
-	var stack []float64
-
-	for i, token := range rpn {
-		if isNumber(token) {
-			num, err := strconv.ParseFloat(token, 64)
-			if err != nil {
-				return 0, err
-			}
-			stack = append(stack, num)
-		} else if isOperator(rune(token[0])) {
-			if len(stack) < 2 {
-				return 0, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %s", i, token))
-			}
-			b, a := stack[len(stack)-1], stack[len(stack)-2]
-			stack = stack[:len(stack)-2]
-
-			var result float64
-			switch tokenType(token) {
-			case Add:
-				result = a + b
-			case Sub:
-				result = a - b
-			case Multi:
-				result = a * b
-			case Div:
-				if b == 0 {
-					return 0, NewCalcError(ErrDivisionByZero, "")
-				}
-				result = a / b
-			}
-			// Check for large numbers after operation
-			if result > 1e308 || result < -1e308 {
-				return 0, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%f", result))
-			}
-			stack = append(stack, result)
-		}
+	tree, err := buildTree(rpn)
+	if err != nil {
+		return 0, err
 	}
 
-	if len(stack) != 1 {
-		return 0, NewCalcError(ErrTooManyValues, "")
+	v, err := NewEnvironment(Options{}).evalExpression(tree)
+	if err != nil {
+		return 0, err
 	}
+	return toReal(v)
+}
 
-	if stack[0] == 0 {
-		return 0, nil
+// boolean converts a truth value into a number: 1 for true, 0 for false.
+func boolean(b bool) float64 {
+	if b {
+		return 1
 	}
+	return 0
+}
 
-	return stack[0], nil
+// applyOperator applies a binary arithmetic or comparison operator.
+func applyOperator(op string, a, b float64) (float64, error) {
+	var result float64
+	switch tokenType(op) {
+	case Add:
+		result = a + b
+	case Sub:
+		result = a - b
+	case Multi:
+		result = a * b
+	case Div:
+		if b == 0 {
+			return 0, NewCalcError(ErrDivisionByZero, "")
+		}
+		result = a / b
+	case Pow:
+		result = math.Pow(a, b)
+		if math.IsNaN(result) {
+			return 0, NewCalcError(ErrDomain, fmt.Sprintf("%g^%g", a, b))
+		}
+	case Eq:
+		result = boolean(a == b)
+	case NotEq:
+		result = boolean(a != b)
+	case Less:
+		result = boolean(a < b)
+	case LessEq:
+		result = boolean(a <= b)
+	case Greater:
+		result = boolean(a > b)
+	case GreaterEq:
+		result = boolean(a >= b)
+	case BitAnd, BitOr, Xor, Rem, ShiftLeft, ShiftRight:
+		return 0, NewCalcError(ErrMismatchOperator, fmt.Sprintf("%s works only in integer mode", op))
+	default:
+		return 0, NewCalcError(ErrMismatchOperator, op)
+	}
+	// Check for large numbers after operation
+	if result > 1e308 || result < -1e308 {
+		return 0, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%f", result))
+	}
+	return result, nil
 }
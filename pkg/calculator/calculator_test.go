@@ -1,6 +1,8 @@
 package calculator
 
 import (
+	"errors"
+	"math"
 	"reflect"
 	"testing"
 )
@@ -65,6 +67,23 @@ func TestTokenize(t *testing.T) {
 		{"-1+2", []string{"-1", "*", "1", "+", "2"}},
 		{"2*-3", []string{"2", "*", "-1", "*", "3"}},
 		{"2*-3.14", []string{"2", "*", "-1", "*", "3.14"}},
+		{"2^-3", []string{"2", "^", "u-", "3"}},
+		{"max(1, 2)", []string{"max", "(", "1", ",", "2", ")"}},
+		{"f(x) = x; f(1)", []string{"f", "(", "x", ")", "=", "x", ";", "f", "(", "1", ")"}},
+		{"2(3)", []string{"2", "*", "(", "3", ")"}},
+		{"(1)(2)", []string{"(", "1", ")", "*", "(", "2", ")"}},
+		{"3pi", []string{"3", "*", "pi"}},
+		{"10 km / 2 h", []string{"10", "q*", "km", "/", "2", "q*", "h"}},
+		{"2 min(1, 2)", []string{"2", "*", "min", "(", "1", ",", "2", ")"}},
+		{"72 in to ft", []string{"72", "q*", "in", "->", "ft"}},
+		{"0xff+0b1", []string{"0xff", "+", "0b1"}},
+		{"0xe<<1", []string{"0xe", "<<", "1"}},
+		{"~a&b", []string{"~", "a", "&", "b"}},
+		{"1e5", []string{"1e5"}},
+		{"1.5e-3*x", []string{"1.5e-3", "*", "x"}},
+		{"2E+10", []string{"2E+10"}},
+		{"2e", []string{"2", "*", "e"}},
+		{"2e-x", []string{"2", "*", "e", "-", "x"}},
 	}
 
 	for _, tc := range testCases {
@@ -182,6 +201,15 @@ func TestPrecedence(t *testing.T) {
 		{"-", 1},
 		{"*", 2},
 		{"/", 2},
+		{"^", 4},
+		{"==", -1},
+		{"&&", -2},
+		{"||", -3},
+		{"?", -4},
+		{"%", 5},
+		{"&", 2},
+		{"<<", 2},
+		{"|", 1},
 		{"", 0},
 		{"a", 0},
 	}
@@ -202,6 +230,14 @@ func TestToRPN(t *testing.T) {
 		{[]string{"1", "+", "2"}, []string{"1", "2", "+"}},
 		{[]string{"1", "+", "2", "*", "3"}, []string{"1", "2", "3", "*", "+"}},
 		{[]string{"(", "1", "+", "2", ")", "*", "3"}, []string{"1", "2", "+", "3", "*"}},
+		{[]string{"2", "^", "3", "^", "2"}, []string{"2", "3", "2", "^", "^"}},
+		{[]string{"2", "^", "u-", "3", "*", "4"}, []string{"2", "3", "u-", "^", "4", "*"}},
+		{[]string{"max", "(", "1", ",", "2", "+", "3", ")"}, []string{"1", "2", "3", "+", "max/2"}},
+		{[]string{"f", "(", ")", "*", "2"}, []string{"f/0", "2", "*"}},
+		{[]string{"1", "+", "2", "<", "4", "&&", "!", "0"}, []string{"1", "2", "+", "4", "<", "0", "!", "&&"}},
+		{[]string{"1", "?", "2", ":", "3"}, []string{"1", "2", "3", ":", "?"}},
+		{[]string{"[", "1", ",", "2", "+", "3", "]"}, []string{"1", "2", "3", "+", "[]/2"}},
+		{[]string{"mean", "(", "[", "]", ")"}, []string{"[]/0", "mean/1"}},
 	}
 
 	for _, tc := range testCases {
@@ -254,6 +290,8 @@ func TestEvaluate(t *testing.T) {
 		{"-1 + 2", 1},
 		{"2 * -3", -6},
 		{"2.2 * -3.4", -7.48},
+		{"0x10 + 0b11 + 0o7", 26},
+		{"2 * -0xff", -510},
 	}
 
 	for _, tc := range testCases {
@@ -288,3 +326,205 @@ func TestEvaluateErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestLogicalOperators(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"1 < 2", 1},
+		{"2 <= 2", 1},
+		{"3 > 4", 0},
+		{"3 >= 4", 0},
+		{"2 == 2", 1},
+		{"2 != 2", 0},
+		{"1 + 1 == 2", 1},
+		{"1 < 2 && 3 < 2", 0},
+		{"1 < 2 || 3 < 2", 1},
+		{"!0", 1},
+		{"!(1 < 2)", 0},
+		{"!-1", 0},
+		{"1 || 0 && 0", 1},
+		{"5 > 3 ? 10 : 20", 10},
+		{"5 < 3 ? 10 : 20 + 1", 21},
+		{"0 ? 1 : 0 ? 2 : 3", 3},
+		{"if(2 > 1, 7, 1 / 0)", 7},
+		{"0 && 1 / 0", 0},
+		{"qty = 120; qty >= 100 ? qty * 0.9 : qty", 108},
+		{"fact(n) = n <= 1 ? 1 : n * fact(n - 1); fact(10)", 3628800},
+		{"fibo(n) = if(n < 2, n, fibo(n - 1) + fibo(n - 2)); fibo(15)", 610},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestLogicalOperatorsErrors(t *testing.T) {
+	testCases := []string{
+		"1 ? 2",
+		"1 : 2",
+		"< 1",
+		"1 <",
+		"1 && ",
+		"2 + !",
+		"if(1, 2)",
+		"if(x) = x; 1",
+	}
+
+	for _, input := range testCases {
+		if _, err := Evaluate(input); err == nil {
+			t.Errorf("Evaluate(%q): expected error", input)
+		}
+	}
+}
+
+func TestImplicitMultiplication(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"2(3+4)", 14},
+		{"(1+2)(3+4)", 21},
+		{"(1+2)2", 6},
+		{"x = 5; 2x", 10},
+		{"x = 5; 2 x", 10},
+		{"x = 2; y = 3; x y", 6},
+		{"x = 2; (x + 1)x", 6},
+		{"x = 3; 2x^2", 18},
+		{"x = 4; 1/2x", 2},
+		{"-2(3)", -6},
+		{"x = 2; 0x", 0},
+		{"b = 3; 0b + 1", 1},
+		{"xa = 2; 1 + 0xa", 11},
+		{"f(x) = 2x + 1; f(2)3", 15},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	got, err := Evaluate("3pi")
+	if err != nil || got != 3*math.Pi {
+		t.Errorf("Evaluate(%q) = %v, %v, want %v", "3pi", got, err, 3*math.Pi)
+	}
+}
+
+func TestScientificNotation(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"1e5", 100000},
+		{"1.5e-3", 0.0015},
+		{"2E+10", 2e10},
+		{"x = 2; 3e2x", 600},
+		{"det([[1e-20, 0], [0, 1e-20]])", 1e-40},
+		{"2e", 2 * math.E},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	got, err := NewEnvironment(Options{Integer: IntegerMode{Bits: 64}}).Evaluate("1e5 + 1")
+	if err != nil || got != 100001 {
+		t.Errorf("Evaluate(%q) in integer mode = %v, %v, want 100001", "1e5 + 1", got, err)
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"2(3+4)", ErrMismatchedParentheses},
+		{"(1+2)(3+4)", ErrMismatchedParentheses},
+		{"3pi", ErrInvalidCharacter},
+		{"x = 1; x y", ErrInvalidCharacter},
+	}
+
+	env := NewEnvironment(Options{Strict: true})
+	for _, tc := range testCases {
+		_, err := env.Evaluate(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Evaluate(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+
+	if got, err := env.Evaluate("2*(3+4) + pi*0"); err != nil || got != 14 {
+		t.Errorf("expected explicit multiplication to work in strict mode, got %v, %v", got, err)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	testCases := []struct {
+		input      string
+		plain      float64
+		contextual float64
+	}{
+		{"50%", 0.5, 0.5},
+		{"200 + 10%", 200.1, 220},
+		{"200 - 15%", 199.85, 170},
+		{"200 * 10%", 20, 20},
+		{"200 / 50%", 400, 400},
+		{"-50%", -0.5, -0.5},
+		{"(100 + 100)%", 2, 2},
+		{"200 + 10% + 10%", 200.2, 242},
+		{"200 + -10%", 199.9, 180},
+		{"200 - -10%", 200.1, 220},
+		{"x = 20; 100 - x%", 99.8, 80},
+		{"2^50%", math.Sqrt2, math.Sqrt2},
+		{"50%2", 1, 1},
+	}
+
+	for _, tc := range testCases {
+		for mode, want := range map[PercentMode]float64{PercentPlain: tc.plain, PercentContextual: tc.contextual} {
+			got, err := NewEnvironment(Options{Percent: mode}).Evaluate(tc.input)
+			if err != nil {
+				t.Errorf("Evaluate(%q) in %s mode returned unexpected error: %v", tc.input, mode, err)
+			}
+			if math.Abs(got-want) > 1e-12 {
+				t.Errorf("Evaluate(%q) in %s mode = %v, want %v", tc.input, mode, got, want)
+			}
+		}
+	}
+
+	for _, input := range []string{"%", "5 + %", "(%)"} {
+		if _, err := Evaluate(input); err == nil {
+			t.Errorf("Evaluate(%q) expected an error", input)
+		}
+	}
+}
+
+func TestParsePercentMode(t *testing.T) {
+	for _, mode := range []PercentMode{PercentPlain, PercentContextual} {
+		got, err := ParsePercentMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParsePercentMode(%q) = %v, %v, want %v", mode.String(), got, err, mode)
+		}
+	}
+
+	if _, err := ParsePercentMode("desk"); err == nil {
+		t.Error("ParsePercentMode(\"desk\") expected an error")
+	}
+}
@@ -0,0 +1,430 @@
+package calculator
+
+import (
+	"cmp"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxRaisedPower is the largest integer power a product is raised to term by term: (2*x)^3 becomes 8*x^3,
+// but (2*x)^100 stays as it is rather than growing a coefficient of 31 digits.
+const maxRaisedPower = 64
+
+// maxExpandedTerms bounds the number of terms of a product of sums multiplied out.
+const maxExpandedTerms = 256
+
+// Simplify returns the canonical form of the expression: constants folded, neutral terms such as x*1 and
+// x + 0 dropped, like terms collected and the operands of commutative operators sorted, so 3 + x*2 - x
+// becomes x + 3. Expressions that differ only in these respects have the same canonical form, but products
+// of sums are not multiplied out: 2*(x + 1) becomes 2*x + 2, while (x + 1)*(x - 1) stays a product.
+func (x *Expression) Simplify() *Expression {
+	return &Expression{root: canonicalize(x.root, false)}
+}
+
+// canonicalize returns the canonical form of a tree. With expand set it multiplies out products and integer
+// powers of sums, as long as they have at most maxExpandedTerms terms.
+func canonicalize(n *node, expand bool) *node {
+	return toPolynomial(simplify(n), expand).node()
+}
+
+// polynomial is the canonical form of an expression: a sum of monomials with distinct factors, in order.
+// The empty polynomial is 0.
+type polynomial []monomial
+
+// monomial is a rational coefficient times factors with distinct bases, in order.
+type monomial struct {
+	coeff   *big.Rat
+	factors []factor
+}
+
+// factor is a base raised to a rational power. The base is an identifier or an expression that is not
+// a sum, product or power with a rational exponent, in canonical form itself.
+type factor struct {
+	base *node
+	exp  *big.Rat
+	key  string // the base as it is printed
+}
+
+func toPolynomial(n *node, expand bool) polynomial {
+	switch n.kind {
+	case numberNode:
+		return constant(exactRat(n.value))
+	case identifierNode:
+		return single(newFactor(n, big.NewRat(1, 1)))
+	case operatorNode:
+		switch tokenType(n.token) {
+		case Add:
+			return toPolynomial(n.args[0], expand).add(toPolynomial(n.args[1], expand))
+		case Sub:
+			return toPolynomial(n.args[0], expand).add(toPolynomial(n.args[1], expand).scale(big.NewRat(-1, 1)))
+		case Neg:
+			return toPolynomial(n.args[0], expand).scale(big.NewRat(-1, 1))
+		case Multi:
+			p, q := toPolynomial(n.args[0], expand), toPolynomial(n.args[1], expand)
+			if expand {
+				return p.expand(q)
+			}
+			return p.multiply(q)
+		case Div:
+			return toPolynomial(n.args[0], expand).divide(toPolynomial(n.args[1], expand))
+		case Pow:
+			exp := n.args[1]
+			if exp.kind != numberNode {
+				break
+			}
+			p, r := toPolynomial(n.args[0], expand), exactRat(exp.value)
+			if expand && len(p) > 1 && r.IsInt() && r.Sign() > 0 && r.Cmp(big.NewRat(maxRaisedPower, 1)) <= 0 {
+				result := p
+				for range r.Num().Int64() - 1 {
+					result = result.expand(p)
+				}
+				return result
+			}
+			return p.raise(r)
+		}
+	}
+	return single(newFactor(opaque(n, expand), big.NewRat(1, 1)))
+}
+
+// opaque puts the operands of an expression that is not a sum, product or power into canonical form,
+// sorting those of the commutative comparisons and logical operators.
+func opaque(n *node, expand bool) *node {
+	args := make([]*node, len(n.args))
+	for i, arg := range n.args {
+		if arg.kind == stringNode || (tokenType(n.token) == Convert && i == 1) {
+			// A string is no expression and the target of a conversion is a unit.
+			args[i] = arg
+			continue
+		}
+		args[i] = canonicalize(arg, expand)
+	}
+	if n.kind == operatorNode {
+		switch tokenType(n.token) {
+		case Eq, NotEq, And, Or:
+			slices.SortFunc(args, func(a, b *node) int { return strings.Compare(format(a), format(b)) })
+		}
+	}
+	return &node{kind: n.kind, token: n.token, value: n.value, args: args}
+}
+
+// exactRat returns the decimal a float64 prints as, so 0.1 is 1/10 rather than the binary fraction nearest to it.
+func exactRat(x float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(x, 'f', -1, 64))
+	return r
+}
+
+func newFactor(base *node, exp *big.Rat) factor {
+	return factor{base: base, exp: exp, key: format(base)}
+}
+
+func constant(c *big.Rat) polynomial {
+	if c.Sign() == 0 {
+		return nil
+	}
+	return polynomial{{coeff: c}}
+}
+
+func single(f factor) polynomial {
+	return polynomial{{coeff: big.NewRat(1, 1), factors: []factor{f}}}
+}
+
+// monomial returns the polynomial as a single monomial, with a sum of several terms as a factor.
+func (p polynomial) monomial() monomial {
+	if len(p) == 1 {
+		return p[0]
+	}
+	return single(newFactor(p.node(), big.NewRat(1, 1)))[0]
+}
+
+func (p polynomial) isConstant() bool {
+	return len(p) == 1 && len(p[0].factors) == 0
+}
+
+// add collects the like terms of two polynomials.
+func (p polynomial) add(q polynomial) polynomial {
+	var sum polynomial
+	index := make(map[string]int)
+	for _, m := range slices.Concat(p, q) {
+		key := m.key()
+		if i, ok := index[key]; ok {
+			sum[i].coeff = new(big.Rat).Add(sum[i].coeff, m.coeff)
+			continue
+		}
+		index[key] = len(sum)
+		sum = append(sum, monomial{coeff: new(big.Rat).Set(m.coeff), factors: m.factors})
+	}
+	sum = slices.DeleteFunc(sum, func(m monomial) bool { return m.coeff.Sign() == 0 })
+	slices.SortStableFunc(sum, compareMonomials)
+	return sum
+}
+
+func (p polynomial) scale(c *big.Rat) polynomial {
+	if c.Sign() == 0 {
+		return nil
+	}
+	scaled := make(polynomial, len(p))
+	for i, m := range p {
+		scaled[i] = monomial{coeff: new(big.Rat).Mul(m.coeff, c), factors: m.factors}
+	}
+	return scaled
+}
+
+// multiply distributes a constant over a sum; other products of sums stay products.
+func (p polynomial) multiply(q polynomial) polynomial {
+	switch {
+	case len(p) == 0 || len(q) == 0:
+		return nil
+	case p.isConstant():
+		return q.scale(p[0].coeff)
+	case q.isConstant():
+		return p.scale(q[0].coeff)
+	}
+	return polynomial{p.monomial().multiply(q.monomial())}
+}
+
+// expand multiplies out a product of sums, unless it has more than maxExpandedTerms terms.
+func (p polynomial) expand(q polynomial) polynomial {
+	if len(p) < 2 && len(q) < 2 || len(p)*len(q) > maxExpandedTerms {
+		return p.multiply(q)
+	}
+	var sum polynomial
+	for _, a := range p {
+		for _, b := range q {
+			sum = sum.add(polynomial{a.multiply(b)})
+		}
+	}
+	return sum
+}
+
+func (p polynomial) divide(q polynomial) polynomial {
+	if len(q) == 0 {
+		// Leave a division by zero to fail when it is evaluated.
+		return single(newFactor(quotient(p.node(), number(0)), big.NewRat(1, 1)))
+	}
+	return p.multiply(polynomial{q.monomial().inverse()})
+}
+
+// raise raises a monomial to a power term by term, but a sum only as a whole: (x + 1)^2 is not multiplied out.
+func (p polynomial) raise(exp *big.Rat) polynomial {
+	one := big.NewRat(1, 1)
+	switch {
+	case exp.Sign() == 0:
+		return constant(one)
+	case len(p) == 0 && exp.Sign() > 0:
+		return nil
+	case len(p) == 1 && exp.IsInt() && new(big.Rat).Abs(exp).Cmp(big.NewRat(maxRaisedPower, 1)) <= 0:
+		return polynomial{p[0].raise(int(exp.Num().Int64()))}
+	case len(p) == 1 && p[0].coeff.Cmp(one) == 0 && len(p[0].factors) == 1 && p[0].factors[0].exp.Cmp(one) == 0:
+		// Only a single variable is raised to a fraction: (x^2)^0.5 is |x|, not x.
+		return single(newFactor(p[0].factors[0].base, exp))
+	case len(p) > 1:
+		return single(newFactor(p.node(), exp))
+	}
+	return single(newFactor(power(p.node(), ratNode(exp)), one))
+}
+
+func (m monomial) multiply(o monomial) monomial {
+	factors := slices.Clone(m.factors)
+	for _, f := range o.factors {
+		i := slices.IndexFunc(factors, func(g factor) bool { return g.key == f.key })
+		if i < 0 {
+			factors = append(factors, f)
+			continue
+		}
+		factors[i] = factor{base: f.base, exp: new(big.Rat).Add(factors[i].exp, f.exp), key: f.key}
+	}
+	factors = slices.DeleteFunc(factors, func(f factor) bool { return f.exp.Sign() == 0 })
+	slices.SortFunc(factors, compareFactors)
+	return monomial{coeff: new(big.Rat).Mul(m.coeff, o.coeff), factors: factors}
+}
+
+func (m monomial) inverse() monomial {
+	factors := make([]factor, len(m.factors))
+	for i, f := range m.factors {
+		factors[i] = factor{base: f.base, exp: new(big.Rat).Neg(f.exp), key: f.key}
+	}
+	return monomial{coeff: new(big.Rat).Inv(m.coeff), factors: factors}
+}
+
+func (m monomial) raise(n int) monomial {
+	coeff := big.NewRat(1, 1)
+	for range max(n, -n) {
+		coeff.Mul(coeff, m.coeff)
+	}
+	if n < 0 {
+		coeff.Inv(coeff)
+	}
+	factors := make([]factor, len(m.factors))
+	for i, f := range m.factors {
+		factors[i] = factor{base: f.base, exp: new(big.Rat).Mul(f.exp, big.NewRat(int64(n), 1)), key: f.key}
+	}
+	return monomial{coeff: coeff, factors: factors}
+}
+
+// key identifies the like terms, which differ only in their coefficient.
+func (m monomial) key() string {
+	keys := make([]string, len(m.factors))
+	for i, f := range m.factors {
+		keys[i] = f.key + "^" + f.exp.RatString()
+	}
+	return strings.Join(keys, "*")
+}
+
+// degree is the sum of the powers of the variables.
+func (m monomial) degree() *big.Rat {
+	d := new(big.Rat)
+	for _, f := range m.factors {
+		if f.base.kind == identifierNode {
+			d.Add(d, f.exp)
+		}
+	}
+	return d
+}
+
+// compareMonomials orders the terms of a sum by falling degree, with the constant last: x^2 + 2*x + 1.
+func compareMonomials(a, b monomial) int {
+	if c := b.degree().Cmp(a.degree()); c != 0 {
+		return c
+	}
+	if constA, constB := len(a.factors) == 0, len(b.factors) == 0; constA != constB {
+		if constA {
+			return 1
+		}
+		return -1
+	}
+	return strings.Compare(a.key(), b.key())
+}
+
+// compareFactors orders the factors of a product with the variables first: 2*x*y*sin(x).
+func compareFactors(a, b factor) int {
+	if c := cmp.Compare(factorRank(a), factorRank(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.key, b.key)
+}
+
+func factorRank(f factor) int {
+	if f.base.kind == identifierNode {
+		return 0
+	}
+	return 1
+}
+
+// node builds the expression of a polynomial, subtracting the terms with a negative coefficient.
+func (p polynomial) node() *node {
+	if len(p) == 0 {
+		return number(0)
+	}
+	var sum *node
+	for _, m := range p {
+		switch {
+		case sum == nil:
+			sum = m.node(false)
+		case m.coeff.Sign() < 0:
+			sum = minus(sum, m.node(true))
+		default:
+			sum = plus(sum, m.node(false))
+		}
+	}
+	return sum
+}
+
+// node builds the expression of a monomial, dividing by the factors with a negative power.
+// With abs set it leaves out the sign of the coefficient.
+func (m monomial) node(abs bool) *node {
+	negative := m.coeff.Sign() < 0 && !abs
+	coeff := new(big.Rat).Abs(m.coeff)
+	one := big.NewRat(1, 1)
+
+	var numerator, denominator []*node
+	switch {
+	case isDecimal(coeff):
+		if coeff.Cmp(one) != 0 {
+			numerator = append(numerator, ratNode(coeff))
+		}
+	default:
+		if !coeff.Num().IsInt64() || coeff.Num().Int64() != 1 {
+			numerator = append(numerator, ratNode(new(big.Rat).SetInt(coeff.Num())))
+		}
+		denominator = append(denominator, ratNode(new(big.Rat).SetInt(coeff.Denom())))
+	}
+	for _, f := range m.factors {
+		exp := new(big.Rat).Abs(f.exp)
+		term := f.base
+		if exp.Cmp(one) != 0 {
+			term = power(f.base, ratNode(exp))
+		}
+		if f.exp.Sign() > 0 {
+			numerator = append(numerator, term)
+		} else {
+			denominator = append(denominator, term)
+		}
+	}
+
+	if len(numerator) == 0 {
+		numerator = append(numerator, number(1))
+	}
+	if negative {
+		// The sign goes to the first factor: -2*x, -x*y.
+		if first := numerator[0]; first.kind == numberNode {
+			numerator[0] = &node{kind: numberNode, token: "-" + first.token, value: -first.value}
+		} else {
+			numerator[0] = negation(first)
+		}
+	}
+
+	n := numerator[0]
+	for _, f := range numerator[1:] {
+		n = product(n, f)
+	}
+	if len(denominator) > 0 {
+		d := denominator[0]
+		for _, f := range denominator[1:] {
+			d = product(d, f)
+		}
+		n = quotient(n, d)
+	}
+	return n
+}
+
+// decimalPlaces returns the number of decimal places of a rational number, if it has a finite decimal notation.
+func decimalPlaces(r *big.Rat) (int, bool) {
+	d := new(big.Int).Set(r.Denom())
+	places := 0
+	for _, p := range []int64{2, 5} {
+		n := 0
+		q, m := new(big.Int), new(big.Int)
+		for {
+			q.QuoRem(d, big.NewInt(p), m)
+			if m.Sign() != 0 {
+				break
+			}
+			d.Set(q)
+			n++
+		}
+		places = max(places, n)
+	}
+	return places, d.Cmp(big.NewInt(1)) == 0
+}
+
+// isDecimal reports whether a rational number is an integer or has a short decimal notation, like 0.25 but unlike 1/3.
+func isDecimal(r *big.Rat) bool {
+	if r.IsInt() {
+		return true
+	}
+	places, ok := decimalPlaces(r)
+	return ok && len(r.FloatString(places)) <= maxFoldedDigits
+}
+
+// ratNode builds a number node of a rational number, or the quotient of its numerator and denominator
+// if it has no short decimal notation.
+func ratNode(r *big.Rat) *node {
+	if !isDecimal(r) {
+		return quotient(ratNode(new(big.Rat).SetInt(r.Num())), ratNode(new(big.Rat).SetInt(r.Denom())))
+	}
+	places, _ := decimalPlaces(r)
+	f, _ := r.Float64()
+	return &node{kind: numberNode, token: r.FloatString(places), value: f}
+}
@@ -0,0 +1,387 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// secondsPerDay is the length of a calendar day; the times are in fixed zones, without daylight saving changes.
+const secondsPerDay = 86400
+
+// maxBusinessDays bounds the day-by-day walk of the business day functions, about 400 years.
+const maxBusinessDays = 100000
+
+// Time is a date such as 2026-03-01, or a date with a time of day such as 2026-03-01T09:30:00Z.
+type Time struct {
+	t    time.Time
+	date bool
+}
+
+// Time returns the point in time; a date is its midnight.
+func (t Time) Time() time.Time {
+	return t.t
+}
+
+// IsDate reports whether t is a date without a time of day.
+func (t Time) IsDate() bool {
+	return t.date
+}
+
+// String formats the time in ISO 8601: 2026-03-01 for a date, 2026-03-01T09:30:00Z otherwise.
+func (t Time) String() string {
+	if t.date {
+		return t.t.Format(time.DateOnly)
+	}
+	return t.t.Format(time.RFC3339)
+}
+
+// parseTime reads a date (2026-03-01), an RFC 3339 time (2026-03-01T09:30:00+02:00)
+// or a date and time without a zone (2026-03-01 09:30:00), which is read as UTC.
+func parseTime(s string) (Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return Time{t: t, date: true}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return Time{t: t}, nil
+	}
+	if t, err := time.Parse(time.DateTime, s); err == nil {
+		return Time{t: t}, nil
+	}
+	return Time{}, NewCalcError(ErrInvalidCharacter, fmt.Sprintf("date %q, expected 2006-01-02 or 2006-01-02T15:04:05Z", s))
+}
+
+// Holidays are the dates that are not business days besides Saturdays and Sundays.
+type Holidays []time.Time
+
+// ParseHolidays reads a comma-separated list of dates such as "2026-01-01,2026-12-25".
+func ParseHolidays(list string) (Holidays, error) {
+	var holidays Holidays
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q, expected 2006-01-02", s)
+		}
+		holidays = append(holidays, d)
+	}
+	return holidays, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so the holidays can be read from configuration.
+func (h *Holidays) UnmarshalText(text []byte) error {
+	holidays, err := ParseHolidays(string(text))
+	if err != nil {
+		return err
+	}
+	*h = holidays
+	return nil
+}
+
+// set returns the holidays as a set of dates in the 2006-01-02 form.
+func (h Holidays) set() map[string]bool {
+	set := make(map[string]bool, len(h))
+	for _, d := range h {
+		set[d.Format(time.DateOnly)] = true
+	}
+	return set
+}
+
+// isBusinessDay reports whether the date of t is neither a weekend day nor a holiday.
+func isBusinessDay(t time.Time, holidays map[string]bool) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return !holidays[t.Format(time.DateOnly)]
+}
+
+// dayUnit is the unit of the difference of two times. Durations are quantities of time of the standard units.
+var dayUnit = unit{terms: []unitTerm{{name: "d", exp: 1}}, factor: secondsPerDay, dim: dimension{"s": 1}}
+
+func isTime(v Value) bool {
+	_, ok := v.(Time)
+	return ok
+}
+
+func toTime(v Value) (Time, error) {
+	t, ok := v.(Time)
+	if !ok {
+		return Time{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a date is expected", typeName(v), v))
+	}
+	return t, nil
+}
+
+// toInt reads a whole number argument, such as a number of days.
+func toInt(v Value, limit int) (int, error) {
+	x, err := toReal(v)
+	if err != nil {
+		return 0, err
+	}
+	if x != math.Trunc(x) {
+		return 0, NewCalcError(ErrNotInteger, formatFloat(x))
+	}
+	if math.Abs(x) > float64(limit) {
+		return 0, NewCalcError(ErrTooLargeNumber, formatFloat(x))
+	}
+	return int(x), nil
+}
+
+// addDuration moves a time by a duration given in seconds. A date moved by whole days stays a date.
+func addDuration(t Time, seconds float64) (Value, error) {
+	if days := seconds / secondsPerDay; days == math.Trunc(days) {
+		if math.Abs(days) > 1e6 {
+			return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%g days", days))
+		}
+		return Time{t: t.t.AddDate(0, 0, int(days)), date: t.date}, nil
+	}
+
+	if math.Abs(seconds) > math.MaxInt64/float64(time.Second) {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%g s", seconds))
+	}
+	return Time{t: t.t.Add(time.Duration(math.Round(seconds * float64(time.Second))))}, nil
+}
+
+// sub returns b - a in seconds.
+func sub(a, b time.Time) float64 {
+	return float64(a.Unix()-b.Unix()) + float64(a.Nanosecond()-b.Nanosecond())/float64(time.Second)
+}
+
+// applyTimeOperator applies a binary operator to operands of which at least one is a time. A duration,
+// which is a quantity of time such as 45 d or 36 h, can be added to a time and subtracted from it;
+// the difference of two times is a duration in days, and times can be compared.
+func applyTimeOperator(op string, a, b Value) (Value, error) {
+	x, xTime := a.(Time)
+	y, yTime := b.(Time)
+
+	switch {
+	case xTime && yTime:
+		switch tokenType(op) {
+		case Sub:
+			return Quantity{value: sub(x.t, y.t) / secondsPerDay, unit: dayUnit}, nil
+		case Eq, NotEq, Less, LessEq, Greater, GreaterEq:
+			return Real(boolean(compare(op, x.t.Compare(y.t)))), nil
+		}
+	case xTime && (tokenType(op) == Add || tokenType(op) == Sub), yTime && tokenType(op) == Add:
+		t, d := x, b
+		if yTime {
+			t, d = y, a
+		}
+		q, ok := d.(Quantity)
+		if !ok || !q.unit.dim.equal(dayUnit.dim) {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("date %s %s, write the duration with a unit such as 45 d", op, typeName(d)))
+		}
+		seconds := q.base()
+		if tokenType(op) == Sub {
+			seconds = -seconds
+		}
+		return addDuration(t, seconds)
+	}
+	return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", typeName(a), op, typeName(b)))
+}
+
+// now returns the current time of the environment's clock, in UTC.
+func (e *Environment) now() time.Time {
+	if e.options.Now != nil {
+		return e.options.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// timeFunctions are the date functions. They are added to the special forms in init.
+var timeFunctions = map[string]specialForm{
+	// date("2026-03-01"), date("2026-03-01T09:30:00Z"), date(2026, 3, 1), or date(t), the date of a time.
+	"date": {minArgs: 1, maxArgs: 3, eval: func(e *Environment, args []*node, f *frame) (Value, error) {
+		if len(args) == 1 && args[0].kind == stringNode {
+			return parseTime(args[0].token)
+		}
+		values, err := e.evalArgs(args, f)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 1 {
+			t, err := toTime(values[0])
+			if err != nil {
+				return nil, err
+			}
+			y, m, d := t.t.Date()
+			return Time{t: time.Date(y, m, d, 0, 0, 0, 0, t.t.Location()), date: true}, nil
+		}
+		return dateOf(values)
+	}},
+	"now": valueFunction(0, 0, func(e *Environment, _ []Value) (Value, error) {
+		return Time{t: e.now()}, nil
+	}),
+	"today": valueFunction(0, 0, func(e *Environment, _ []Value) (Value, error) {
+		y, m, d := e.now().Date()
+		return Time{t: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), date: true}, nil
+	}),
+	// days_between(a, b) is b - a in days.
+	"days_between": valueFunction(2, 2, func(_ *Environment, args []Value) (Value, error) {
+		a, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := toTime(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return Real(sub(b.t, a.t) / secondsPerDay), nil
+	}),
+	// add_months(d, n) moves by calendar months, keeping the day within the month: 2026-01-31 + 1 is 2026-02-28.
+	"add_months": valueFunction(2, 2, func(_ *Environment, args []Value) (Value, error) {
+		t, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		n, err := toInt(args[1], 12*10000)
+		if err != nil {
+			return nil, err
+		}
+		y, m, d := t.t.Date()
+		first := time.Date(y, m+time.Month(n), 1, t.t.Hour(), t.t.Minute(), t.t.Second(), t.t.Nanosecond(), t.t.Location())
+		last := first.AddDate(0, 1, -1).Day()
+		return Time{t: first.AddDate(0, 0, min(d, last)-1), date: t.date}, nil
+	}),
+	// add_business_days(d, n) moves by n business days, forward or backward, skipping weekends and holidays.
+	"add_business_days": valueFunction(2, 2, func(e *Environment, args []Value) (Value, error) {
+		t, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		n, err := toInt(args[1], maxBusinessDays)
+		if err != nil {
+			return nil, err
+		}
+
+		holidays := e.options.Holidays.set()
+		step := 1
+		if n < 0 {
+			step, n = -1, -n
+		}
+		d := t.t
+		for n > 0 {
+			d = d.AddDate(0, 0, step)
+			if isBusinessDay(d, holidays) {
+				n--
+			}
+		}
+		return Time{t: d, date: t.date}, nil
+	}),
+	// business_days(a, b) counts the business days after a up to and including b, negative if b is before a,
+	// so business_days(d, add_business_days(d, n)) is n.
+	"business_days": valueFunction(2, 2, func(e *Environment, args []Value) (Value, error) {
+		a, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := toTime(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		sign := 1.0
+		from, to := dateOnly(a.t), dateOnly(b.t)
+		if to.Before(from) {
+			sign, from, to = -1, to, from
+		}
+		if to.Sub(from).Hours() > 24*maxBusinessDays*7/5 {
+			return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("business_days(%s, %s)", a, b))
+		}
+
+		holidays := e.options.Holidays.set()
+		count := 0
+		for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+			if isBusinessDay(d, holidays) {
+				count++
+			}
+		}
+		return Real(sign * float64(count)), nil
+	}),
+	"year":  datePart(func(t time.Time) int { return t.Year() }),
+	"month": datePart(func(t time.Time) int { return int(t.Month()) }),
+	"day":   datePart(func(t time.Time) int { return t.Day() }),
+	// weekday(d) is the ISO 8601 day of the week: 1 for Monday to 7 for Sunday.
+	"weekday": datePart(func(t time.Time) int { return (int(t.Weekday())+6)%7 + 1 }),
+}
+
+func datePart(part func(time.Time) int) specialForm {
+	return valueFunction(1, 1, func(_ *Environment, args []Value) (Value, error) {
+		t, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return Real(part(t.t)), nil
+	})
+}
+
+// dateOf builds the date of date(year, month, day), rejecting days that do not exist such as 2026-02-30.
+func dateOf(args []Value) (Value, error) {
+	if len(args) != 3 {
+		return nil, NewCalcError(ErrArgumentCount, fmt.Sprintf("date expects 1 or 3 arguments, got %d", len(args)))
+	}
+	var parts [3]int
+	for i, a := range args {
+		n, err := toInt(a, 1000000)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = n
+	}
+
+	d := time.Date(parts[0], time.Month(parts[1]), parts[2], 0, 0, 0, 0, time.UTC)
+	if d.Year() != parts[0] || int(d.Month()) != parts[1] || d.Day() != parts[2] {
+		return nil, NewCalcError(ErrDomain, fmt.Sprintf("date(%d, %d, %d)", parts[0], parts[1], parts[2]))
+	}
+	return Time{t: d, date: true}, nil
+}
+
+// dateOnly returns the midnight of the date of t, in UTC, for counting days.
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ISODuration formats a duration, a quantity of time, in ISO 8601: P45D, PT1H30M or P1DT12H.
+// It reports false for a quantity of any other dimension.
+func (q Quantity) ISODuration() (string, bool) {
+	if !q.unit.dim.equal(dayUnit.dim) {
+		return "", false
+	}
+
+	seconds := q.base()
+	sign := ""
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+
+	days := math.Floor(seconds / secondsPerDay)
+	seconds -= days * secondsPerDay
+	hours := math.Floor(seconds / 3600)
+	seconds -= hours * 3600
+	minutes := math.Floor(seconds / 60)
+	seconds -= minutes * 60
+	// Round away the errors of the unit conversions, below a microsecond.
+	seconds = math.Round(seconds*1e6) / 1e6
+
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%.0fD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%.0fH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%.0fM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			b.WriteString(formatFloat(seconds) + "S")
+		}
+	}
+	return b.String(), true
+}
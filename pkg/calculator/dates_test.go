@@ -0,0 +1,143 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func testClock() time.Time {
+	return time.Date(2026, 10, 19, 15, 4, 5, 0, time.UTC)
+}
+
+func TestDates(t *testing.T) {
+	holidays, err := ParseHolidays("2026-12-25, 2026-12-28")
+	if err != nil {
+		t.Fatalf("ParseHolidays: %v", err)
+	}
+
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{`date("2026-03-01") + 45d`, "2026-04-15"},
+		{`45 d + date("2026-03-01")`, "2026-04-15"},
+		{`date("2026-03-01") - 1 week`, "2026-02-22"},
+		{`date("2026-03-01") + 36 h`, "2026-03-02T12:00:00Z"},
+		{`date("2026-03-01T09:30:00+02:00") + 30 min`, "2026-03-01T10:00:00+02:00"},
+		{`date("2026-03-01 09:30:00")`, "2026-03-01T09:30:00Z"},
+		{`date(2026, 2, 28) + 1 d`, "2026-03-01"},
+		{`date(now())`, "2026-10-19"},
+		{`date("2026-03-10") - date("2026-03-01")`, "9 d"},
+		{`date("2026-03-10") - date("2026-03-01") to h`, "216 h"},
+		{`days_between(date("2026-03-01"), date("2026-04-15"))`, "45"},
+		{`days_between(date("2026-04-15"), date("2026-03-01"))`, "-45"},
+		{`now()`, "2026-10-19T15:04:05Z"},
+		{`today()`, "2026-10-19"},
+		{`today() + 30 d`, "2026-11-18"},
+		{`date("2026-03-01") < today()`, "1"},
+		{`add_months(date("2026-01-31"), 1)`, "2026-02-28"},
+		{`add_months(date("2024-01-31"), 1)`, "2024-02-29"},
+		{`add_months(date("2026-03-15"), -3)`, "2025-12-15"},
+		{`add_business_days(date("2026-10-16"), 1)`, "2026-10-19"},
+		{`add_business_days(date("2026-10-19"), -1)`, "2026-10-16"},
+		{`add_business_days(date("2026-12-24"), 1)`, "2026-12-29"},
+		{`business_days(date("2026-10-16"), date("2026-10-23"))`, "5"},
+		{`business_days(date("2026-12-21"), date("2026-12-31"))`, "6"},
+		{`business_days(date("2026-10-23"), date("2026-10-16"))`, "-5"},
+		{`year(today()) * 100 + month(today())`, "202610"},
+		{`day(date("2026-03-01"))`, "1"},
+		{`weekday(today())`, "1"},
+		{`weekday(date("2026-10-25"))`, "7"},
+		{`start = date("2026-03-01"); start + 10 d`, "2026-03-11"},
+		{`due(d0, n) = add_business_days(d0, n); due(date("2026-10-16"), 5)`, "2026-10-23"},
+	}
+
+	for _, tc := range testCases {
+		env := NewEnvironment(Options{Now: testClock, Holidays: holidays})
+		got, err := env.EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDatesErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{`date("2026-03-01") + 5`, ErrTypeMismatch},
+		{`date("2026-03-01") + 5 m`, ErrTypeMismatch},
+		{`date("2026-03-01") + date("2026-03-01")`, ErrTypeMismatch},
+		{`date("2026-03-01") * 2`, ErrTypeMismatch},
+		{`date("2026-03-01") + 1.5`, ErrTypeMismatch},
+		{`"2026-03-01" + 1 d`, ErrTypeMismatch},
+		{`date("yesterday")`, ErrInvalidCharacter},
+		{`date("2026-03-01`, ErrInvalidCharacter},
+		{`date(2026, 2, 30)`, ErrDomain},
+		{`date(2026, 2)`, ErrArgumentCount},
+		{`days_between(1, 2)`, ErrTypeMismatch},
+		{`add_months(today(), 1.5)`, ErrNotInteger},
+		{`add_business_days(today(), 1000000)`, ErrTooLargeNumber},
+		{`sin(today())`, ErrTypeMismatch},
+		{`now(1)`, ErrArgumentCount},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Now: testClock}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"45 d", "P45D"},
+		{"90 min", "PT1H30M"},
+		{"36 h", "P1DT12H"},
+		{"-1 d", "-P1D"},
+		{"1.5 s", "PT1.5S"},
+		{"0 s", "PT0S"},
+	}
+
+	for _, tc := range testCases {
+		v, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		q, ok := v.(Quantity)
+		if !ok {
+			t.Errorf("EvaluateValue(%q) = %s, want a quantity", tc.input, v)
+			continue
+		}
+		if got, ok := q.ISODuration(); !ok || got != tc.want {
+			t.Errorf("ISODuration(%s) = %s, %v, want %s", tc.input, got, ok, tc.want)
+		}
+	}
+
+	v, _ := NewEnvironment(Options{}).EvaluateValue("3 m")
+	if _, ok := v.(Quantity).ISODuration(); ok {
+		t.Errorf("ISODuration(3 m): expected no duration")
+	}
+}
+
+func TestParseHolidays(t *testing.T) {
+	var holidays Holidays
+	if err := holidays.UnmarshalText([]byte("2026-01-01,2026-12-25")); err != nil || len(holidays) != 2 {
+		t.Errorf("UnmarshalText = %v, %v", holidays, err)
+	}
+	if _, err := ParseHolidays("2026-13-01"); err == nil {
+		t.Errorf("ParseHolidays: expected error for an invalid date")
+	}
+}
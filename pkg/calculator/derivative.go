@@ -0,0 +1,210 @@
+package calculator
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Derivative returns the simplified derivative of the expression with respect to a variable.
+// Every other name is a constant. Functions without a derivative, such as floor, and the operators
+// that are not smooth, such as comparisons, fail with ErrDomain unless their operands do not depend on the variable.
+func (x *Expression) Derivative(variable string) (*Expression, error) {
+	if err := ValidateName(variable); err != nil {
+		return nil, err
+	}
+	d, err := derive(x.root, variable)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{root: simplify(d)}, nil
+}
+
+// dependsOn reports whether a variable occurs in a tree.
+func dependsOn(n *node, variable string) bool {
+	if n.kind == identifierNode && n.token == variable {
+		return true
+	}
+	return slices.ContainsFunc(n.args, func(arg *node) bool { return dependsOn(arg, variable) })
+}
+
+// derive differentiates a tree; the result is not simplified.
+func derive(n *node, v string) (*node, error) {
+	if !dependsOn(n, v) {
+		return number(0), nil
+	}
+
+	switch n.kind {
+	case identifierNode:
+		return number(1), nil
+	case listNode:
+		items := make([]*node, len(n.args))
+		for i, arg := range n.args {
+			d, err := derive(arg, v)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = d
+		}
+		return &node{kind: listNode, args: items}, nil
+	case callNode:
+		return deriveCall(n, v)
+	case operatorNode:
+		// handled below
+	default:
+		return nil, NewCalcError(ErrDomain, fmt.Sprintf("no derivative of %s", format(n)))
+	}
+
+	ds := make([]*node, len(n.args))
+	for i, arg := range n.args {
+		if tokenType(n.token) == Question && i == 0 {
+			// The condition only selects a branch.
+			continue
+		}
+		d, err := derive(arg, v)
+		if err != nil {
+			return nil, err
+		}
+		ds[i] = d
+	}
+
+	switch tokenType(n.token) {
+	case Add, Sub:
+		return operation(n.token, ds[0], ds[1]), nil
+	case Neg:
+		return negation(ds[0]), nil
+	case Percent:
+		return operation(string(Div), ds[0], number(100)), nil
+	case Question:
+		return &node{kind: operatorNode, token: n.token, args: []*node{n.args[0], ds[1], ds[2]}}, nil
+	case Multi:
+		// (ab)' = a'b + ab'
+		a, b := n.args[0], n.args[1]
+		return plus(product(ds[0], b), product(a, ds[1])), nil
+	case Div:
+		// (a/b)' = (a'b - ab')/b^2
+		a, b := n.args[0], n.args[1]
+		if !dependsOn(b, v) {
+			return quotient(ds[0], b), nil
+		}
+		return quotient(minus(product(ds[0], b), product(a, ds[1])), power(b, number(2))), nil
+	case Pow:
+		a, b := n.args[0], n.args[1]
+		switch {
+		case !dependsOn(b, v):
+			// (a^b)' = b a^(b-1) a'
+			return product(product(b, power(a, operation(string(Sub), b, number(1)))), ds[0]), nil
+		case !dependsOn(a, v):
+			// (a^b)' = a^b ln(a) b'
+			return product(product(n, call("ln", a)), ds[1]), nil
+		default:
+			// (a^b)' = a^b (b' ln(a) + b a'/a)
+			return product(n, plus(product(ds[1], call("ln", a)), quotient(product(b, ds[0]), a))), nil
+		}
+	}
+	return nil, NewCalcError(ErrDomain, fmt.Sprintf("no derivative of %s", operatorSpelling(n.token)))
+}
+
+// derivatives give the derivative of a function of one argument at u, without the inner derivative u' of the chain rule.
+var derivatives = map[string]func(u *node) *node{
+	"sin":  func(u *node) *node { return call("cos", u) },
+	"cos":  func(u *node) *node { return negation(call("sin", u)) },
+	"tan":  func(u *node) *node { return quotient(number(1), power(call("cos", u), number(2))) },
+	"asin": func(u *node) *node { return quotient(number(1), call("sqrt", minus(number(1), power(u, number(2))))) },
+	"acos": func(u *node) *node {
+		return negation(quotient(number(1), call("sqrt", minus(number(1), power(u, number(2))))))
+	},
+	"atan": func(u *node) *node { return quotient(number(1), plus(number(1), power(u, number(2)))) },
+	"sinh": func(u *node) *node { return call("cosh", u) },
+	"cosh": func(u *node) *node { return call("sinh", u) },
+	"tanh": func(u *node) *node { return quotient(number(1), power(call("cosh", u), number(2))) },
+	"exp":  func(u *node) *node { return call("exp", u) },
+	"ln":   func(u *node) *node { return quotient(number(1), u) },
+	"log":  func(u *node) *node { return quotient(number(1), product(u, call("ln", number(10)))) },
+	"sqrt": func(u *node) *node { return quotient(number(1), product(number(2), call("sqrt", u))) },
+	"cbrt": func(u *node) *node { return quotient(number(1), product(number(3), power(call("cbrt", u), number(2)))) },
+	"abs":  func(u *node) *node { return quotient(u, call("abs", u)) },
+}
+
+func deriveCall(n *node, v string) (*node, error) {
+	if d, ok := derivatives[n.token]; ok && len(n.args) == 1 {
+		u := n.args[0]
+		du, err := derive(u, v)
+		if err != nil {
+			return nil, err
+		}
+		return product(d(u), du), nil
+	}
+
+	switch {
+	case n.token == "log" && len(n.args) == 2:
+		// log(u, b) = ln(u)/ln(b)
+		return derive(quotient(call("ln", n.args[0]), call("ln", n.args[1])), v)
+	case n.token == "atan2" && len(n.args) == 2:
+		// atan2(y, x)' = (x y' - y x')/(x^2 + y^2)
+		y, x := n.args[0], n.args[1]
+		dy, err := derive(y, v)
+		if err != nil {
+			return nil, err
+		}
+		dx, err := derive(x, v)
+		if err != nil {
+			return nil, err
+		}
+		return quotient(minus(product(x, dy), product(y, dx)), plus(power(x, number(2)), power(y, number(2)))), nil
+	case n.token == "hypot" && len(n.args) == 2:
+		// hypot(a, b)' = (a a' + b b')/hypot(a, b)
+		a, b := n.args[0], n.args[1]
+		da, err := derive(a, v)
+		if err != nil {
+			return nil, err
+		}
+		db, err := derive(b, v)
+		if err != nil {
+			return nil, err
+		}
+		return quotient(plus(product(a, da), product(b, db)), n), nil
+	}
+
+	if _, ok := builtins[n.token]; !ok {
+		if _, ok := specialForms[n.token]; !ok {
+			return nil, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("function %s", n.token))
+		}
+	}
+	return nil, NewCalcError(ErrDomain, fmt.Sprintf("no derivative of %s", n.token))
+}
+
+func number(x float64) *node {
+	return &node{kind: numberNode, value: x}
+}
+
+func call(name string, args ...*node) *node {
+	return &node{kind: callNode, token: name, args: args}
+}
+
+func operation(op string, a, b *node) *node {
+	return &node{kind: operatorNode, token: op, args: []*node{a, b}}
+}
+
+func negation(a *node) *node {
+	return &node{kind: operatorNode, token: string(Neg), args: []*node{a}}
+}
+
+func product(a, b *node) *node {
+	return operation(string(Multi), a, b)
+}
+
+func quotient(a, b *node) *node {
+	return operation(string(Div), a, b)
+}
+
+func power(a, b *node) *node {
+	return operation(string(Pow), a, b)
+}
+
+func plus(a, b *node) *node {
+	return operation(string(Add), a, b)
+}
+
+func minus(a, b *node) *node {
+	return operation(string(Sub), a, b)
+}
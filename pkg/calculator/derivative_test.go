@@ -0,0 +1,177 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExpressionString(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"2^3^2", "2^3^2"},
+		{"(2^3)^2", "(2^3)^2"},
+		{"a - (b - c)", "a - (b - c)"},
+		{"(a - b) - c", "a - b - c"},
+		{"a / (b * c)", "a/(b*c)"},
+		{"-x^2", "-x^2"},
+		{"(-x)^2", "(-x)^2"},
+		{"2 * -x", "2*(-1)*x"},
+		{"2 * (-x)", "2*(-x)"},
+		{"2^-x", "2^(-x)"},
+		{"(x + 1)!", "(x + 1)!"},
+		{"50%", "50%"},
+		{"!x || y && z", "!x || y && z"},
+		{"(x ? 1 : 2) ? 3 : 4", "(x ? 1 : 2) ? 3 : 4"},
+		{"x ? 1 : y ? 2 : 3", "x ? 1 : y ? 2 : 3"},
+		{"2x sin(x)", "2*x*sin(x)"},
+		{"3 m to km", "3*m to km"},
+		{"max([1, 2], 0x1F)", "max([1, 2], 0x1F)"},
+	}
+
+	for _, tc := range testCases {
+		x, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got := x.String(); got != tc.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tc.input, got, tc.want)
+		}
+		if again, err := Parse(x.String()); err != nil || again.String() != x.String() {
+			t.Errorf("Parse(%q) does not read back: %v, %v", x.String(), again, err)
+		}
+	}
+}
+
+func TestExpressionTree(t *testing.T) {
+	x, err := Parse("-x^2 + sin(y)%")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Node{Type: "operator", Value: "+", Args: []Node{
+		{Type: "operator", Value: "*", Args: []Node{
+			{Type: "number", Value: "-1"},
+			{Type: "operator", Value: "^", Args: []Node{{Type: "identifier", Value: "x"}, {Type: "number", Value: "2"}}},
+		}},
+		{Type: "postfix", Value: "%", Args: []Node{
+			{Type: "call", Value: "sin", Args: []Node{{Type: "identifier", Value: "y"}}},
+		}},
+	}}
+	if got := x.Tree(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tree() = %+v, want %+v", got, want)
+	}
+	if got := x.Variables(); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("Variables() = %v, want [x y]", got)
+	}
+}
+
+func TestDerivative(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"x^2 * sin(x)", "2*x*sin(x) + x^2*cos(x)"},
+		{"x^3", "3*x^2"},
+		{"-x", "-1"},
+		{"a*x^2 + b*x + c", "2*a*x + b"},
+		{"y", "0"},
+		{"e^x", "e^x"},
+		{"x^x", "x^x*(ln(x) + x/x)"},
+		{"1/x", "-1/x^2"},
+		{"sqrt(x)", "1/(2*sqrt(x))"},
+		{"tan(2x)", "2/cos(2*x)^2"},
+		{"(x + 1)^2", "2*(x + 1)"},
+		{"ln(x)", "1/x"},
+		{"log(x, 2)", "1/(x*ln(2))"},
+		{"exp(-x)", "-exp(-x)"},
+		{"x > 0 ? x : -x", "x > 0 ? 1 : -1"},
+		{"x / 4", "0.25"},
+		{"x / 3", "1/3"},
+		{"floor(3) * x", "3"},
+		{"[x, x^2]", "[1, 2*x]"},
+	}
+
+	for _, tc := range testCases {
+		x, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		d, err := x.Derivative("x")
+		if err != nil {
+			t.Errorf("Derivative(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got := d.String(); got != tc.want {
+			t.Errorf("Derivative(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+// TestDerivativeNumerically compares derivatives with central differences.
+func TestDerivativeNumerically(t *testing.T) {
+	inputs := []string{
+		"x^2 * sin(x)", "asin(x/2) + acos(x/3) + atan(x)", "sinh(x) * cosh(x) / tanh(x)",
+		"cbrt(x^2 + 1)", "abs(x - 3)", "log(x) + log(x, x + 1)", "atan2(x, 2) + hypot(x, 3)",
+		"2^x / x^2", "x^sin(x)", "(x + 1)/(x - 4)", "10% * x^2",
+	}
+
+	for _, input := range inputs {
+		x, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		d, err := x.Derivative("x")
+		if err != nil {
+			t.Errorf("Derivative(%q) returned unexpected error: %v", input, err)
+			continue
+		}
+		at := func(e *Expression, v float64) float64 {
+			env := NewEnvironment(Options{})
+			env.Set("x", v)
+			got, err := env.EvaluateExpression(e)
+			if err != nil {
+				t.Fatalf("evaluating %s at %v: %v", e, v, err)
+			}
+			return float64(got.(Real))
+		}
+		for _, v := range []float64{0.5, 1.3} {
+			const h = 1e-6
+			want := (at(x, v+h) - at(x, v-h)) / (2 * h)
+			if got := at(d, v); math.Abs(got-want) > 1e-5*math.Max(1, math.Abs(want)) {
+				t.Errorf("Derivative(%q) = %s is %v at %v, want %v", input, d, got, v, want)
+			}
+		}
+	}
+}
+
+func TestDerivativeErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"floor(x)", ErrDomain},
+		{"x > 1", ErrDomain},
+		{"x!", ErrDomain},
+		{"f(x)", ErrUnknownIdentifier},
+		{"x = 1", ErrMismatchOperator},
+		{"x; 1", ErrMismatchOperator},
+		{"", ErrInsufficientValues},
+		{"(x", ErrMismatchedParentheses},
+	}
+
+	for _, tc := range testCases {
+		x, err := Parse(tc.input)
+		if err == nil {
+			_, err = x.Derivative("x")
+		}
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Derivative(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
@@ -0,0 +1,818 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// constants are the predefined names available in every expression.
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// imaginaryUnit is the name of the constant i, defined in complex mode.
+const imaginaryUnit = "i"
+
+// DefaultMaxDepth is the nesting limit of user-defined function calls when Options.MaxDepth is not set.
+const DefaultMaxDepth = 100
+
+// DefaultMaxEvaluations is the evaluation budget when Options.MaxEvaluations is not set.
+const DefaultMaxEvaluations = 1000000
+
+// Options tune the evaluation. The zero value is ready to use.
+type Options struct {
+	// MaxDepth limits the nesting of user-defined function calls, and so the depth of recursion.
+	MaxDepth int
+	// MaxEvaluations limits the function calls, series terms, integrand points and coefficient products of one evaluation.
+	MaxEvaluations int
+	// Strict rejects implicit multiplication such as 2(3+4), 3pi or 2x.
+	Strict bool
+	// Percent selects the meaning of the postfix %.
+	Percent PercentMode
+	// Integer switches to fixed-width integer arithmetic when Integer.Bits is set.
+	Integer IntegerMode
+	// Complex defines the imaginary unit i and extends the functions and the power to complex numbers,
+	// so sqrt(-4) is 2i instead of an error.
+	Complex bool
+	// Interval makes every number an interval with guaranteed bounds, interval(1.9, 2.1) or 2 ± 0.1, and rounds every
+	// operation outwards, so the result holds the exact value for any values within the bounds. It does not
+	// apply in integer mode.
+	Interval bool
+	// Uncertainty makes 9.81 ± 0.02 a measured value with a standard uncertainty, which propagates to first order
+	// through the operators and functions. It does not apply in integer mode.
+	Uncertainty bool
+	// Units are the units of measurement known to the expressions; nil means StandardUnits.
+	Units *Units
+	// Rates is the exchange-rate table; its currencies can be used like units, e.g. 100 USD + 50 EUR in GBP.
+	// Without a table there are no currencies.
+	Rates *Rates
+	// Now is the clock of now() and today(); nil means time.Now.
+	Now func() time.Time
+	// Holidays are skipped by the business day functions along with Saturdays and Sundays.
+	Holidays Holidays
+}
+
+// PercentMode is the meaning of the postfix % operator.
+type PercentMode int
+
+const (
+	// PercentPlain reads x% as x/100 everywhere: 50% = 0.5, 200 + 10% = 200.1.
+	PercentPlain PercentMode = iota
+	// PercentContextual reads a + x% and a - x% like a desk calculator, as a*(1 + x/100) and a*(1 - x/100):
+	// 200 + 10% = 220, 200 - 15% = 170. Anywhere else x% is x/100, so 200 * 10% = 20.
+	PercentContextual
+)
+
+var percentModeNames = map[PercentMode]string{
+	PercentPlain:      "plain",
+	PercentContextual: "contextual",
+}
+
+func (m PercentMode) String() string {
+	if name, ok := percentModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("PercentMode(%d)", int(m))
+}
+
+// ParsePercentMode returns the mode with the given name, "plain" or "contextual".
+func ParsePercentMode(name string) (PercentMode, error) {
+	for m, n := range percentModeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown percent mode %q", name)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so the mode can be read from configuration.
+func (m *PercentMode) UnmarshalText(text []byte) error {
+	mode, err := ParsePercentMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// Environment holds the variables and user-defined functions visible to the expressions evaluated in it.
+// Functions defined by an evaluated program stay in the environment for the following evaluations.
+type Environment struct {
+	options Options
+	vars    map[string]Value
+	ints    map[string]Integer // variables assigned in integer mode
+	funcs   map[string]*function
+	used    map[string]Rate // exchange rates used by the last evaluation
+
+	evaluations int        // expressions evaluated by integrate, series, product and solve in the last evaluation
+	integrals   []Integral // integrals computed by the last evaluation
+	sources     int        // independent sources of uncertainty, one for every ± evaluated in uncertainty mode
+}
+
+func NewEnvironment(options Options) *Environment {
+	e := &Environment{
+		vars:  make(map[string]Value),
+		ints:  make(map[string]Integer),
+		funcs: make(map[string]*function),
+		used:  make(map[string]Rate),
+	}
+	e.SetOptions(options)
+
+	return e
+}
+
+// Options returns the options the environment evaluates with.
+func (e *Environment) Options() Options {
+	return e.options
+}
+
+// SetOptions changes the options for the following evaluations; bound variables and functions are kept.
+func (e *Environment) SetOptions(options Options) {
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = DefaultMaxDepth
+	}
+	if options.MaxEvaluations <= 0 {
+		options.MaxEvaluations = DefaultMaxEvaluations
+	}
+	e.options = options
+}
+
+// Set binds a variable. A variable shadows a constant of the same name.
+func (e *Environment) Set(name string, value float64) {
+	delete(e.ints, name)
+	e.vars[name] = Real(value)
+}
+
+// Variables returns a copy of the real variables bound in the environment. Values returns all of them.
+func (e *Environment) Variables() map[string]float64 {
+	vars := make(map[string]float64, len(e.vars))
+	for name, v := range e.vars {
+		if n, ok := v.(Real); ok {
+			vars[name] = float64(n)
+		}
+	}
+	return vars
+}
+
+// Values returns a copy of every variable bound in the environment, including the complex ones
+// and those assigned in integer mode.
+func (e *Environment) Values() map[string]Value {
+	vars := maps.Clone(e.vars)
+	for name, v := range e.ints {
+		vars[name] = v
+	}
+	return vars
+}
+
+// Empty reports whether no variable or function is bound in the environment.
+func (e *Environment) Empty() bool {
+	return len(e.vars) == 0 && len(e.ints) == 0 && len(e.funcs) == 0
+}
+
+// Evaluate evaluates a program: one or more statements separated by ";" or line breaks,
+// for example "a = 3; b = a * 2; a + b" or "f(x) = x^2 + 1; f(3) + f(4)".
+// A statement is an expression, a variable assignment or a function definition. The result is the value
+// of the last statement, which must be an expression or an assignment.
+// Identifiers are resolved against function parameters, the environment's variables, the constants, the currencies
+// and the units.
+// Variables and functions bound by the program stay in the environment, unless the evaluation fails.
+// The result must be a real number: in integer mode it is the nearest number to the integer, and a complex
+// result, a quantity with a unit or an amount of money fails with ErrTypeMismatch. EvaluateValue returns any result.
+func (e *Environment) Evaluate(expr string) (float64, error) {
+	v, err := e.EvaluateValue(expr)
+	if err != nil {
+		return 0, err
+	}
+	return toReal(v)
+}
+
+// EvaluateValue evaluates a program like Evaluate and returns its result as it is: a Real, a Quantity, a Money,
+// a Complex in complex mode or an Integer in integer mode.
+func (e *Environment) EvaluateValue(expr string) (Value, error) {
+	e.reset()
+	if e.options.Integer.Enabled() {
+		return e.EvaluateInteger(expr)
+	}
+
+	statements, err := parseProgram(expr, e.options)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statements) == 0 || statements[len(statements)-1].kind == functionStatement {
+		return nil, NewCalcError(ErrInsufficientValues, "no expression to evaluate")
+	}
+
+	vars, ints, funcs := maps.Clone(e.vars), maps.Clone(e.ints), maps.Clone(e.funcs)
+
+	var result Value
+	for _, stmt := range statements {
+		switch stmt.kind {
+		case functionStatement:
+			err = e.define(stmt.name, stmt.params, stmt.body)
+		case assignmentStatement:
+			result, err = e.assign(stmt.name, stmt.body)
+		case expressionStatement:
+			result, err = e.evalExpression(stmt.body)
+		}
+		if err != nil {
+			e.vars, e.ints, e.funcs = vars, ints, funcs
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// assign evaluates an expression and binds its value to a variable. Constants can not be reassigned.
+func (e *Environment) assign(name string, body *node) (Value, error) {
+	if e.isConstant(name) {
+		return nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%s is a constant", name))
+	}
+
+	v, err := e.evalExpression(body)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(e.ints, name)
+	e.vars[name] = v
+
+	return v, nil
+}
+
+// define adds a user-defined function. Built-in functions can not be redefined.
+func (e *Environment) define(name string, params []string, body *node) error {
+	_, isBuiltin := builtins[name]
+	_, isSpecialForm := specialForms[name]
+	if isBuiltin || isSpecialForm {
+		return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%s is a built-in function", name))
+	}
+
+	seen := make(map[string]bool, len(params))
+	for _, p := range params {
+		if seen[p] {
+			return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("duplicate parameter %q of %s", p, name))
+		}
+		seen[p] = true
+	}
+
+	e.funcs[name] = &function{params: params, body: body}
+
+	return nil
+}
+
+// reset forgets what the last evaluation recorded: the exchange rates, the integrals and the spent budget.
+func (e *Environment) reset() {
+	clear(e.used)
+	e.evaluations = 0
+	e.integrals = nil
+}
+
+// spend takes n evaluations of the budget for name, failing with ErrEvaluationLimit once it is exhausted.
+func (e *Environment) spend(name string, n int) error {
+	if n > e.options.MaxEvaluations-e.evaluations {
+		e.evaluations = e.options.MaxEvaluations
+		return NewCalcError(ErrEvaluationLimit, fmt.Sprintf("%s needs more than %d evaluations", name, e.options.MaxEvaluations))
+	}
+	e.evaluations += n
+	return nil
+}
+
+// evalWith evaluates n with a variable bound on top of the arguments of f, as the i of series(i^2, i, 1, 10).
+func (e *Environment) evalWith(n *node, f *frame, variable string, v Value) (Value, error) {
+	inner := &frame{args: map[string]Value{variable: v}}
+	if f != nil {
+		inner.args = maps.Clone(f.args)
+		inner.args[variable] = v
+		inner.depth = f.depth
+	}
+	return e.eval(n, inner)
+}
+
+// evalExpression evaluates a top-level expression tree.
+func (e *Environment) evalExpression(n *node) (Value, error) {
+	result, err := e.eval(n, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == Real(0) {
+		return Real(0), nil
+	}
+
+	return result, nil
+}
+
+// frame holds the arguments of the user-defined function being evaluated and the call depth.
+type frame struct {
+	args  map[string]Value
+	depth int
+}
+
+func (e *Environment) eval(n *node, f *frame) (Value, error) {
+	switch n.kind {
+	case numberNode:
+		if e.options.Interval {
+			return literalInterval(n), nil
+		}
+		return Real(n.value), nil
+	case listNode:
+		items, err := e.evalArgs(n.args, f)
+		if err != nil {
+			return nil, err
+		}
+		return List(items), nil
+	case stringNode:
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("string \"%s\" is only allowed as argument of date", n.token))
+	case identifierNode:
+		if f != nil {
+			if v, ok := f.args[n.token]; ok {
+				return v, nil
+			}
+		}
+		return e.lookup(n.token)
+	case operatorNode:
+		switch tokenType(n.token) {
+		case Neg, Percent, Factorial:
+			v, err := e.eval(n.args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			return e.applyUnary(n.token, v)
+		case Not:
+			v, err := e.eval(n.args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			return Real(boolean(!truthy(v))), nil
+		case And, Or, Question:
+			return e.evalLazy(n, f)
+		case Assign:
+			return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("the equation %s is only allowed as argument of solve", format(n)))
+		case Convert:
+			v, err := e.eval(n.args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			if target := n.args[1]; target.kind == identifierNode && e.isCurrency(target.token) {
+				return e.exchange(v, target.token)
+			}
+			target, err := e.evalUnit(n.args[1])
+			if err != nil {
+				return nil, err
+			}
+			return convert(v, target)
+		}
+
+		a, err := e.eval(n.args[0], f)
+		if err != nil {
+			return nil, err
+		}
+		b, err := e.eval(n.args[1], f)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok, err := e.applyMoneyLiteral(n, a, b); ok {
+			return v, err
+		}
+		if e.isContextualPercent(n) {
+			// b is already x/100: a + x% is a + a*x/100.
+			b, err = e.operate(string(Multi), a, b)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return e.operate(n.token, a, b)
+	case callNode:
+		if form, ok := specialForms[n.token]; ok && e.funcs[n.token] == nil {
+			if err := form.checkArity(n.token, len(n.args)); err != nil {
+				return nil, err
+			}
+			return form.eval(e, n.args, f)
+		}
+
+		args := make([]Value, len(n.args))
+		for i, arg := range n.args {
+			v, err := e.eval(arg, f)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return e.call(n.token, args, f)
+	}
+
+	return nil, NewErrUnknown()
+}
+
+// applyUnary applies the unary minus, the percentage or the factorial.
+func (e *Environment) applyUnary(op string, v Value) (Value, error) {
+	if m, ok := v.(Money); ok {
+		switch tokenType(op) {
+		case Neg:
+			return Money{amount: new(big.Rat).Neg(m.amount), currency: m.currency}, nil
+		case Percent:
+			return Money{amount: new(big.Rat).Quo(m.amount, big.NewRat(100, 1)), currency: m.currency}, nil
+		}
+	}
+	if q, ok := v.(Quantity); ok {
+		switch tokenType(op) {
+		case Neg:
+			return Quantity{value: -q.value, unit: q.unit}, nil
+		case Percent:
+			return Quantity{value: q.value / 100, unit: q.unit}, nil
+		}
+	}
+	if isPolynomial(v) {
+		switch tokenType(op) {
+		case Neg:
+			return e.applyPolynomialOperator(string(Multi), v, Real(-1))
+		case Percent:
+			return e.applyPolynomialOperator(string(Multi), v, Real(0.01))
+		}
+	}
+	if x, ok := v.(Uncertain); ok {
+		switch tokenType(op) {
+		case Neg:
+			return propagate(op, -x.value, []float64{-1}, []Uncertain{x})
+		case Percent:
+			return propagate(op, x.value/100, []float64{0.01}, []Uncertain{x})
+		}
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("(%s)%s, a measured value", x, operatorSpelling(op)))
+	}
+	if x, ok := v.(Interval); ok {
+		switch tokenType(op) {
+		case Neg:
+			return x.neg(), nil
+		case Percent:
+			return intervalValue(x.div(point(100)))
+		}
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s%s, an interval", x, operatorSpelling(op)))
+	}
+	if c, ok := v.(Complex); ok {
+		switch tokenType(op) {
+		case Neg:
+			return -c, nil
+		case Percent:
+			return c / 100, nil
+		}
+	}
+
+	x, err := toReal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenType(op) {
+	case Neg:
+		return Real(-x), nil
+	case Percent:
+		return Real(x / 100), nil
+	case Factorial:
+		k, err := toInteger(x)
+		if err != nil {
+			return nil, err
+		}
+		result, err := fromInteger(factorial(k))
+		return Real(result), err
+	}
+	return nil, NewCalcError(ErrMismatchOperator, op)
+}
+
+// operate applies a binary operator. Real numbers use real arithmetic; in complex mode a real power
+// that has no real result, such as (-8)^(1/3), is computed as a complex one. Quantities keep track of their units,
+// amounts of money are computed exactly, durations can be added to dates, polynomials have exact rational
+// coefficients, intervals bound every result, measured values carry their uncertainty and lists are vectors
+// and matrices.
+func (e *Environment) operate(op string, a, b Value) (Value, error) {
+	if isList(a) || isList(b) {
+		return e.applyListOperator(op, a, b)
+	}
+	if isMoney(a) || isMoney(b) {
+		return e.applyMoneyOperator(op, a, b)
+	}
+	if isTime(a) || isTime(b) {
+		return applyTimeOperator(op, a, b)
+	}
+	if isPolynomial(a) || isPolynomial(b) {
+		return e.applyPolynomialOperator(op, a, b)
+	}
+	if isQuantity(a) || isQuantity(b) {
+		return applyQuantityOperator(op, a, b)
+	}
+	if isUncertain(a) || isUncertain(b) || e.options.Uncertainty && tokenType(op) == PlusMinus {
+		return e.applyUncertainOperator(op, a, b)
+	}
+	if isInterval(a) || isInterval(b) || e.options.Interval {
+		return applyIntervalOperator(op, a, b)
+	}
+	if tokenType(op) == PlusMinus {
+		return nil, NewCalcError(ErrMismatchOperator, "± outside of interval and uncertainty mode")
+	}
+
+	x, xReal := a.(Real)
+	y, yReal := b.(Real)
+	if xReal && yReal {
+		result, err := applyOperator(op, float64(x), float64(y))
+		if err == nil || !e.options.Complex || tokenType(op) != Pow {
+			return Real(result), err
+		}
+	}
+
+	ca, aok := toComplex(a)
+	cb, bok := toComplex(b)
+	if !aok || !bok {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", typeName(a), op, typeName(b)))
+	}
+	return applyComplexOperator(op, ca, cb)
+}
+
+// isContextualPercent reports whether n adds or subtracts a percentage of its left operand.
+func (e *Environment) isContextualPercent(n *node) bool {
+	if e.options.Percent != PercentContextual {
+		return false
+	}
+	if tokenType(n.token) != Add && tokenType(n.token) != Sub {
+		return false
+	}
+	return isPercentage(n.args[1])
+}
+
+// isPercentage reports whether n is x% or a negated one, -x%.
+func isPercentage(n *node) bool {
+	if isUnaryMinus(n) {
+		return isPercentage(n.args[1])
+	}
+	if n.kind != operatorNode {
+		return false
+	}
+	switch tokenType(n.token) {
+	case Percent:
+		return true
+	case Neg:
+		return isPercentage(n.args[0])
+	}
+	return false
+}
+
+// evalLazy evaluates the operators that skip some of their operands: && and || stop as soon as the result is known,
+// a ? b : c evaluates only the chosen branch. This is what lets a recursive function end.
+func (e *Environment) evalLazy(n *node, f *frame) (Value, error) {
+	cond, err := e.eval(n.args[0], f)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenType(n.token) {
+	case And:
+		if !truthy(cond) {
+			return Real(0), nil
+		}
+	case Or:
+		if truthy(cond) {
+			return Real(1), nil
+		}
+	case Question:
+		if truthy(cond) {
+			return e.eval(n.args[1], f)
+		}
+		return e.eval(n.args[2], f)
+	}
+
+	v, err := e.eval(n.args[1], f)
+	if err != nil {
+		return nil, err
+	}
+	return Real(boolean(truthy(v))), nil
+}
+
+// call applies a user-defined function or, if there is none with that name, a built-in one.
+func (e *Environment) call(name string, args []Value, f *frame) (Value, error) {
+	fn, ok := e.funcs[name]
+	if !ok {
+		return e.callBuiltin(name, args)
+	}
+
+	if len(args) != len(fn.params) {
+		return nil, NewCalcError(ErrArgumentCount, fmt.Sprintf("%s expects %d argument(s), got %d", name, len(fn.params), len(args)))
+	}
+
+	depth := 1
+	if f != nil {
+		depth = f.depth + 1
+	}
+	if depth > e.options.MaxDepth {
+		return nil, NewCalcError(ErrRecursionLimit, fmt.Sprintf("%s nested deeper than %d calls", name, e.options.MaxDepth))
+	}
+	if err := e.spend(name, 1); err != nil {
+		return nil, err
+	}
+
+	inner := &frame{args: make(map[string]Value, len(args)), depth: depth}
+	for i, p := range fn.params {
+		inner.args[p] = args[i]
+	}
+
+	return e.eval(fn.body, inner)
+}
+
+// callBuiltin applies a built-in function. The lists among the arguments of an aggregate such as mean
+// are replaced by their items. A complex argument, or in complex mode a real argument out of the real domain,
+// goes to the complex variant of the function; an amount to callMoney, a quantity to callQuantity, a measured value
+// to callUncertain and, in interval mode, any argument to the interval variant.
+func (e *Environment) callBuiltin(name string, args []Value) (Value, error) {
+	b, ok := builtins[name]
+	if !ok {
+		return nil, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("function %s", name))
+	}
+	if aggregates[name] {
+		args = flatten(args)
+	}
+	if slices.ContainsFunc(args, isMoney) {
+		return e.callMoney(name, args)
+	}
+	if slices.ContainsFunc(args, isQuantity) {
+		return callQuantity(name, args)
+	}
+	if slices.ContainsFunc(args, isUncertain) {
+		return callUncertain(name, b, args)
+	}
+	if e.options.Interval || slices.ContainsFunc(args, isInterval) {
+		return callIntervalValues(name, args)
+	}
+	complexFn, hasComplex := complexFunctions[name]
+
+	nums := make([]float64, len(args))
+	for i, a := range args {
+		x, err := toReal(a)
+		if err != nil {
+			if _, ok := a.(Complex); ok && hasComplex {
+				return callComplex(name, complexFn, args)
+			}
+			return nil, err
+		}
+		nums[i] = x
+	}
+
+	result, err := b.call(name, nums)
+	var calcErr CalcError
+	if e.options.Complex && hasComplex && errors.As(err, &calcErr) && calcErr.Type == ErrDomain {
+		return callComplex(name, complexFn, args)
+	}
+	return Real(result), err
+}
+
+// isConstant reports whether name is a predefined constant: pi, e and, in complex mode, i.
+func (e *Environment) isConstant(name string) bool {
+	_, ok := constants[name]
+	return ok || (e.options.Complex && name == imaginaryUnit)
+}
+
+func (e *Environment) lookup(name string) (Value, error) {
+	if v, ok := e.vars[name]; ok {
+		return v, nil
+	}
+	if v, ok := e.ints[name]; ok {
+		return Real(v.Float64()), nil
+	}
+	if v, ok := constants[name]; ok {
+		if e.options.Interval {
+			return constantInterval(v), nil
+		}
+		return Real(v), nil
+	}
+	if e.options.Complex && name == imaginaryUnit {
+		return Complex(1i), nil
+	}
+	if e.isCurrency(name) {
+		return Money{amount: big.NewRat(1, 1), currency: name}, nil
+	}
+	if u, ok := e.units().lookup(name); ok {
+		return quantityValue(1, u)
+	}
+	return nil, NewCalcError(ErrUnknownIdentifier, name)
+}
+
+// Validate checks that expr is a well-formed expression that refers only to the given parameters,
+// the constants and the built-in functions, without evaluating it. Implicit multiplication is accepted.
+func Validate(expr string, params ...string) error {
+	return NewEnvironment(Options{}).Validate(expr, params...)
+}
+
+// Validate checks expr like the function Validate, but parses it with the options of the environment and also
+// accepts their currencies and units, and i in complex mode.
+func (e *Environment) Validate(expr string, params ...string) error {
+	known := make(map[string]bool, len(params))
+	for _, p := range params {
+		if err := ValidateName(p); err != nil {
+			return err
+		}
+		if known[p] {
+			return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("duplicate parameter %q", p))
+		}
+		known[p] = true
+	}
+
+	rpn, err := parse(expr, e.options)
+	if err != nil {
+		return err
+	}
+
+	tree, err := buildTree(rpn)
+	if err != nil {
+		return err
+	}
+
+	return e.validateTree(tree, known)
+}
+
+func (e *Environment) validateTree(n *node, known map[string]bool) error {
+	switch n.kind {
+	case identifierNode:
+		if _, isUnit := e.units().lookup(n.token); !known[n.token] && !e.isConstant(n.token) && !e.isCurrency(n.token) && !isUnit {
+			return NewCalcError(ErrUnknownIdentifier, n.token)
+		}
+	case callNode:
+		if form, ok := specialForms[n.token]; ok {
+			if err := form.checkArity(n.token, len(n.args)); err != nil {
+				return err
+			}
+			if form.binds != nil && form.binds(n.args) {
+				return e.validateBinding(n, known)
+			}
+			break
+		}
+		b, ok := builtins[n.token]
+		if !ok {
+			return NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("function %s", n.token))
+		}
+		if err := b.checkArity(n.token, len(n.args)); err != nil {
+			return err
+		}
+	}
+
+	for _, arg := range n.args {
+		if err := e.validateTree(arg, known); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateBinding validates a form like solve(x^2 = 2, x), whose first argument may use the variable
+// named by the second one.
+func (e *Environment) validateBinding(n *node, known map[string]bool) error {
+	variable := n.args[1]
+	if variable.kind != identifierNode {
+		return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%s for %s, expected a variable", n.token, format(variable)))
+	}
+	inner := maps.Clone(known)
+	inner[variable.token] = true
+	if err := e.validateTree(n.args[0], inner); err != nil {
+		return err
+	}
+	for _, arg := range n.args[2:] {
+		if err := e.validateTree(arg, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseDefinition splits a formula definition such as "shipping(weight, zone) = 4.5 + weight*0.8*zone"
+// into its name, parameters and body. The body is not validated.
+func ParseDefinition(def string) (name string, params []string, body string, err error) {
+	head, body, ok := strings.Cut(def, "=")
+	if !ok {
+		return "", nil, "", NewCalcError(ErrInvalidIdentifier, "definition must look like name(params) = expression")
+	}
+
+	tokens, err := tokenize(head)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	name, params, err = parseSignature(tokens)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	return name, params, strings.TrimSpace(body), nil
+}
+
+// ValidateName checks that name can be used as a variable or formula name.
+func ValidateName(name string) error {
+	if !isIdentifier(name) {
+		return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%q", name))
+	}
+	return nil
+}
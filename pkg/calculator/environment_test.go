@@ -0,0 +1,90 @@
+package calculator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnvironmentEvaluate(t *testing.T) {
+	env := NewEnvironment(Options{})
+	env.Set("weight", 2)
+	env.Set("zone", 3)
+	env.Set("x1", 0.5)
+
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"4.5 + weight*0.8*zone", 9.3},
+		{"-weight + zone", 1},
+		{"(weight + zone) * x1", 2.5},
+		{"2 * pi", 6.283185307179586},
+	}
+
+	for _, tc := range testCases {
+		got, err := env.Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	_, err := env.Evaluate("weight + height")
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrUnknownIdentifier {
+		t.Errorf("expected unknown identifier error, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		expr    string
+		params  []string
+		wantErr bool
+	}{
+		{"4.5 + weight*0.8*zone", []string{"weight", "zone"}, false},
+		{"pi * r * r", []string{"r"}, false},
+		{"1 / (x - 1)", []string{"x"}, false},
+		{"weight * zone", []string{"weight"}, true},
+		{"weight +", []string{"weight"}, true},
+		{"(weight", []string{"weight"}, true},
+		{"x", []string{"x", "x"}, true},
+		{"x", []string{"1x"}, true},
+	}
+
+	for _, tc := range testCases {
+		err := Validate(tc.expr, tc.params...)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Validate(%q, %v) error = %v, want error %v", tc.expr, tc.params, err, tc.wantErr)
+		}
+	}
+
+	if err := NewEnvironment(Options{Strict: true}).Validate("2x", "x"); err == nil {
+		t.Error("Validate accepted implicit multiplication in strict mode")
+	}
+	if err := NewEnvironment(Options{Complex: true}).Validate("x + 2i", "x"); err != nil {
+		t.Errorf("Validate rejected i in complex mode: %v", err)
+	}
+	if err := Validate("x + 2i", "x"); err == nil {
+		t.Error("Validate accepted i without complex mode")
+	}
+}
+
+func TestParseDefinition(t *testing.T) {
+	name, params, body, err := ParseDefinition("shipping(weight, zone) = 4.5 + weight*0.8*zone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "shipping" || !reflect.DeepEqual(params, []string{"weight", "zone"}) || body != "4.5 + weight*0.8*zone" {
+		t.Errorf("unexpected definition: %q %v %q", name, params, body)
+	}
+
+	for _, def := range []string{"shipping = 1", "ship ping(x) = x", "f(x, 2) = x", "f(x) + 1"} {
+		if _, _, _, err = ParseDefinition(def); err == nil {
+			t.Errorf("ParseDefinition(%q): expected error", def)
+		}
+	}
+}
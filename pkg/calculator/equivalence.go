@@ -0,0 +1,159 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+const (
+	// DefaultTolerance is the relative difference up to which two values count as equal when Tolerance is not set.
+	DefaultTolerance = 1e-9
+	// DefaultSamples is the number of points two expressions are compared at when Samples is not set.
+	DefaultSamples = 20
+	// MaxSamples bounds the number of points two expressions are compared at.
+	MaxSamples = 1000
+)
+
+// EquivalenceOptions tune Equivalent. The zero value is ready to use.
+type EquivalenceOptions struct {
+	// Tolerance is the largest difference of two values relative to the larger of them, and at least 1,
+	// that counts as equal.
+	Tolerance float64
+	// Samples is the number of points the expressions are compared at when their canonical forms differ.
+	Samples int
+	// Seed selects the points; the same seed gives the same points, and so the same verdict.
+	Seed uint64
+	// Evaluation are the options the expressions are evaluated with.
+	Evaluation Options
+}
+
+// Equivalence is the verdict of Equivalent.
+type Equivalence struct {
+	Equivalent bool
+	// Method is "canonical" if the expanded canonical forms of the expressions are the same,
+	// or "numeric" if the verdict comes from comparing their values at random points.
+	Method string
+	// Confidence is 1 for the same canonical forms and for a point where the values differ. For values that
+	// agree at n points it is (n+1)/(n+2), the chance by Laplace's rule of succession that they agree at the next one.
+	Confidence float64
+	// Samples is the number of points the values were compared at; points where either expression is undefined,
+	// like ln(x) for a negative x, are skipped.
+	Samples int
+	// Counterexample are the values of the variables at a point where the expressions differ.
+	Counterexample map[string]float64
+}
+
+// Equivalent decides whether two expressions have the same value for all values of their variables. It compares
+// their canonical forms with products of sums multiplied out first, so 2(x + 1) and 2x + 2 are equivalent without
+// any evaluation, and otherwise their values at random points: half of them with every variable between -10 and 10,
+// half between 0.1 and 5, where the square root and the logarithm are defined.
+func Equivalent(a, b *Expression, options EquivalenceOptions) (Equivalence, error) {
+	if options.Tolerance == 0 {
+		options.Tolerance = DefaultTolerance
+	}
+	if options.Samples == 0 {
+		options.Samples = DefaultSamples
+	}
+	switch {
+	case options.Tolerance < 0 || math.IsNaN(options.Tolerance):
+		return Equivalence{}, NewCalcError(ErrDomain, fmt.Sprintf("tolerance %g", options.Tolerance))
+	case options.Samples < 0 || options.Samples > MaxSamples:
+		return Equivalence{}, NewCalcError(ErrDomain, fmt.Sprintf("%d samples, at most %d", options.Samples, MaxSamples))
+	}
+
+	if difference := canonicalize(minus(a.root, b.root), true); isLiteral(difference, 0) {
+		return Equivalence{Equivalent: true, Method: "canonical", Confidence: 1}, nil
+	}
+
+	variables := slices.Compact(slices.Sorted(slices.Values(slices.Concat(a.Variables(), b.Variables()))))
+	rng := rand.New(rand.NewPCG(options.Seed, 0))
+	result := Equivalence{Method: "numeric"}
+	var lastErr error
+
+	// Points where an expression is undefined do not count, so try a few more than needed.
+	for attempt := 0; attempt < 4*options.Samples && result.Samples < options.Samples; attempt++ {
+		point := make(map[string]float64, len(variables))
+		for _, name := range variables {
+			if attempt%2 == 0 {
+				point[name] = -10 + 20*rng.Float64()
+			} else {
+				point[name] = 0.1 + 4.9*rng.Float64()
+			}
+		}
+
+		va, vb, err := evaluatePair(a, b, point, options.Evaluation)
+		if err != nil {
+			if !isUndefined(err) {
+				return Equivalence{}, err
+			}
+			lastErr = err
+			continue
+		}
+
+		result.Samples++
+		if math.Abs(va-vb) > options.Tolerance*math.Max(1, math.Max(math.Abs(va), math.Abs(vb))) {
+			result.Confidence = 1
+			result.Counterexample = point
+			return result, nil
+		}
+	}
+
+	if result.Samples == 0 {
+		if lastErr == nil {
+			lastErr = NewCalcError(ErrDomain, "no point to compare the expressions at")
+		}
+		return Equivalence{}, lastErr
+	}
+	result.Equivalent = true
+	result.Confidence = float64(result.Samples+1) / float64(result.Samples+2)
+	return result, nil
+}
+
+func evaluatePair(a, b *Expression, point map[string]float64, options Options) (float64, float64, error) {
+	va, err := evaluateAt(a, point, options)
+	if err != nil {
+		return 0, 0, err
+	}
+	vb, err := evaluateAt(b, point, options)
+	if err != nil {
+		return 0, 0, err
+	}
+	return va, vb, nil
+}
+
+// evaluateAt evaluates an expression at a point, failing for a value that is not a finite real number.
+func evaluateAt(x *Expression, point map[string]float64, options Options) (float64, error) {
+	env := NewEnvironment(options)
+	for name, v := range point {
+		env.Set(name, v)
+	}
+	v, err := env.EvaluateExpression(x)
+	if err != nil {
+		return 0, err
+	}
+	f, err := toReal(v)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, NewCalcError(ErrDomain, x.String())
+	}
+	return f, nil
+}
+
+// isUndefined reports whether an error only means that an expression has no value at a point,
+// like a division by zero, rather than that it can not be evaluated at all.
+func isUndefined(err error) bool {
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) {
+		return false
+	}
+	switch calcErr.Type {
+	case ErrDomain, ErrDivisionByZero, ErrOverflow, ErrTooLargeNumber, ErrNotInteger, ErrNegativeArgument, ErrNoConvergence:
+		return true
+	}
+	return false
+}
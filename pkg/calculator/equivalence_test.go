@@ -0,0 +1,84 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	testCases := []struct {
+		a, b   string
+		want   bool
+		method string
+	}{
+		{"2(x+1)", "2x+2", true, "canonical"},
+		{"(x + 1)^2", "x^2 + 2x + 1", true, "canonical"},
+		{"(a + b)*(a - b)", "a^2 - b^2", true, "canonical"},
+		{"x/2 + y/2", "(y + x)/2", true, "canonical"},
+		{"sin(x)^2 + cos(x)^2", "1", true, "numeric"},
+		{"(x^2 - 1)/(x - 1)", "x + 1", true, "numeric"},
+		{"ln(x*y)", "ln(x) + ln(y)", true, "numeric"},
+		{"2(x+1)", "2x+1", false, "numeric"},
+		{"x^2", "x^3", false, "numeric"},
+		{"sqrt(x^2)", "x", false, "numeric"},
+	}
+
+	for _, tc := range testCases {
+		a, err := Parse(tc.a)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.a, err)
+		}
+		b, err := Parse(tc.b)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.b, err)
+		}
+		got, err := Equivalent(a, b, EquivalenceOptions{})
+		if err != nil {
+			t.Errorf("Equivalent(%q, %q) returned unexpected error: %v", tc.a, tc.b, err)
+			continue
+		}
+		if got.Equivalent != tc.want || got.Method != tc.method {
+			t.Errorf("Equivalent(%q, %q) = %+v, want %v by %s", tc.a, tc.b, got, tc.want, tc.method)
+		}
+		if !got.Equivalent && got.Counterexample == nil {
+			t.Errorf("Equivalent(%q, %q) has no counterexample", tc.a, tc.b)
+		}
+		if got.Method == "numeric" && got.Equivalent && (got.Samples != DefaultSamples || got.Confidence < 0.95) {
+			t.Errorf("Equivalent(%q, %q) compared %d points with confidence %v", tc.a, tc.b, got.Samples, got.Confidence)
+		}
+	}
+}
+
+func TestEquivalentTolerance(t *testing.T) {
+	a, _ := Parse("x + 0.001")
+	b, _ := Parse("x")
+	if got, _ := Equivalent(a, b, EquivalenceOptions{}); got.Equivalent {
+		t.Errorf("expressions 0.001 apart are equivalent with the default tolerance")
+	}
+	if got, _ := Equivalent(a, b, EquivalenceOptions{Tolerance: 0.01}); !got.Equivalent {
+		t.Errorf("expressions 0.001 apart are not equivalent with tolerance 0.01")
+	}
+}
+
+func TestEquivalentErrors(t *testing.T) {
+	testCases := []struct {
+		a, b    string
+		options EquivalenceOptions
+		errType ErrorType
+	}{
+		{"f(x)", "x", EquivalenceOptions{}, ErrUnknownIdentifier},
+		{"ln(-1 - x^2)", "x", EquivalenceOptions{}, ErrDomain},
+		{"x", "x + 1", EquivalenceOptions{Samples: MaxSamples + 1}, ErrDomain},
+		{"x", "x + 1", EquivalenceOptions{Tolerance: -1}, ErrDomain},
+	}
+
+	for _, tc := range testCases {
+		a, _ := Parse(tc.a)
+		b, _ := Parse(tc.b)
+		_, err := Equivalent(a, b, tc.options)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Equivalent(%q, %q) error = %v, want error type %v", tc.a, tc.b, err, tc.errType)
+		}
+	}
+}
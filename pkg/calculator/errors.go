@@ -14,9 +14,52 @@ const (
 	ErrTooManyValues
 	ErrTooLargeNumber
 	ErrMismatchOperator
+	ErrUnknownIdentifier
+	ErrInvalidIdentifier
+	ErrArgumentCount
+	ErrRecursionLimit
+	ErrDomain
+	ErrNotInteger
+	ErrNegativeArgument
+	ErrOverflow
+	ErrTypeMismatch
+	ErrDimension
+	ErrNoConvergence
+	ErrEvaluationLimit
 	ErrUnknown
 )
 
+var errorTypeNames = map[ErrorType]string{
+	ErrInvalidCharacter:      "invalid_character",
+	ErrMismatchedParentheses: "mismatched_parentheses",
+	ErrInsufficientValues:    "insufficient_values",
+	ErrDivisionByZero:        "division_by_zero",
+	ErrTooManyValues:         "too_many_values",
+	ErrTooLargeNumber:        "too_large_number",
+	ErrMismatchOperator:      "mismatched_operator",
+	ErrUnknownIdentifier:     "unknown_identifier",
+	ErrInvalidIdentifier:     "invalid_identifier",
+	ErrArgumentCount:         "argument_count",
+	ErrRecursionLimit:        "recursion_limit",
+	ErrDomain:                "domain",
+	ErrNotInteger:            "not_integer",
+	ErrNegativeArgument:      "negative_argument",
+	ErrOverflow:              "overflow",
+	ErrTypeMismatch:          "type_mismatch",
+	ErrDimension:             "dimension",
+	ErrNoConvergence:         "no_convergence",
+	ErrEvaluationLimit:       "evaluation_limit",
+	ErrUnknown:               "unknown",
+}
+
+// String returns a stable snake_case name of the error type, suitable for storage and filtering.
+func (t ErrorType) String() string {
+	if name, ok := errorTypeNames[t]; ok {
+		return name
+	}
+	return errorTypeNames[ErrUnknown]
+}
+
 type CalcError struct {
 	Type    ErrorType
 	Message string
@@ -52,6 +95,30 @@ func NewCalcError(errType ErrorType, details string) error {
 		message = fmt.Sprintf("number too large: %s", details)
 	case ErrMismatchOperator:
 		message = fmt.Sprintf("mismatched operator: %s", details)
+	case ErrUnknownIdentifier:
+		message = fmt.Sprintf("unknown identifier: %s", details)
+	case ErrInvalidIdentifier:
+		message = fmt.Sprintf("invalid identifier: %s", details)
+	case ErrArgumentCount:
+		message = fmt.Sprintf("wrong number of arguments: %s", details)
+	case ErrRecursionLimit:
+		message = fmt.Sprintf("recursion depth limit exceeded: %s", details)
+	case ErrDomain:
+		message = fmt.Sprintf("argument out of domain: %s", details)
+	case ErrNotInteger:
+		message = fmt.Sprintf("integer expected: %s", details)
+	case ErrNegativeArgument:
+		message = fmt.Sprintf("non-negative argument expected: %s", details)
+	case ErrOverflow:
+		message = fmt.Sprintf("integer overflow: %s", details)
+	case ErrTypeMismatch:
+		message = fmt.Sprintf("type mismatch: %s", details)
+	case ErrDimension:
+		message = fmt.Sprintf("incompatible dimensions: %s", details)
+	case ErrNoConvergence:
+		message = fmt.Sprintf("no convergence: %s", details)
+	case ErrEvaluationLimit:
+		message = fmt.Sprintf("evaluation limit exceeded: %s", details)
 	default:
 		err.Type = ErrUnknown
 		message = "unknown error"
@@ -0,0 +1,232 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Expression is a parsed expression that can be printed, inspected as a tree and transformed symbolically,
+// e.g. differentiated. Its names are free: any name that is not a function is a variable.
+type Expression struct {
+	root *node
+}
+
+// Parse parses a single expression, without assignments or definitions.
+func Parse(expr string) (*Expression, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if tokenType(t) == Semicolon || tokenType(t) == Assign {
+			return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("expected a single expression, found %s", t))
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, NewCalcError(ErrInsufficientValues, "no expression")
+	}
+
+	rpn, err := toRPN(tokens)
+	if err != nil {
+		return nil, err
+	}
+	root, err := buildTree(rpn)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{root: root}, nil
+}
+
+// String prints the expression with as few parentheses as its operators need. The result parses
+// back into an expression with the same value.
+func (x *Expression) String() string {
+	return format(x.root)
+}
+
+// Variables returns the names the expression uses as variables, sorted, without the constants pi and e.
+func (x *Expression) Variables() []string {
+	names := make(map[string]bool)
+	collectVariables(x.root, names)
+	return slices.Sorted(maps.Keys(names))
+}
+
+func collectVariables(n *node, names map[string]bool) {
+	if n.kind == identifierNode {
+		if _, ok := constants[n.token]; !ok {
+			names[n.token] = true
+		}
+	}
+	args := n.args
+	if form, ok := specialForms[n.token]; ok && n.kind == callNode && len(args) >= 2 && form.binds != nil && form.binds(args) {
+		// The variable of series(i^2, i, 1, n) is not one of the expression.
+		inner := make(map[string]bool)
+		collectVariables(args[0], inner)
+		delete(inner, args[1].token)
+		maps.Copy(names, inner)
+		args = args[2:]
+	}
+	for _, arg := range args {
+		collectVariables(arg, names)
+	}
+}
+
+// EvaluateExpression evaluates a parsed expression with the variables, functions and options of the environment.
+func (e *Environment) EvaluateExpression(x *Expression) (Value, error) {
+	e.reset()
+	if e.options.Integer.Enabled() {
+		return nil, NewCalcError(ErrTypeMismatch, "a parsed expression can not be evaluated in integer mode")
+	}
+	return e.evalExpression(x.root)
+}
+
+// Node is the tree of an expression, e.g. for a client that renders it.
+type Node struct {
+	// Type is number, identifier, operator (binary), prefix, postfix, conditional (a ? b : c), call, list or string.
+	Type string
+	// Value is the number, the name, the operator as it is written or the called function; empty for a list.
+	Value string
+	Args  []Node
+}
+
+// Tree returns the tree of the expression.
+func (x *Expression) Tree() Node {
+	return exportNode(x.root)
+}
+
+func exportNode(n *node) Node {
+	out := Node{Value: n.token}
+	switch n.kind {
+	case numberNode:
+		out.Type = "number"
+		out.Value = formatNumber(n)
+	case identifierNode:
+		out.Type = "identifier"
+	case stringNode:
+		out.Type = "string"
+	case listNode:
+		out.Type = "list"
+	case callNode:
+		out.Type = "call"
+	case operatorNode:
+		out.Value = operatorSpelling(n.token)
+		switch {
+		case tokenType(n.token) == Question:
+			out.Type = "conditional"
+			out.Value = "?:"
+		case isPrefixOperator(n.token):
+			out.Type = "prefix"
+		case isPostfixOperator(n.token):
+			out.Type = "postfix"
+		default:
+			out.Type = "operator"
+		}
+	}
+	for _, arg := range n.args {
+		out.Args = append(out.Args, exportNode(arg))
+	}
+	return out
+}
+
+// isUnaryMinus reports whether n is a unary minus, which the tokenizer spells -1*x.
+func isUnaryMinus(n *node) bool {
+	return n.kind == operatorNode && tokenType(n.token) == Multi && n.args[0].kind == numberNode &&
+		(n.args[0].token == unaryMinus || n.args[0].token == literalMinus)
+}
+
+// operatorSpelling returns an operator as it is written in an expression.
+func operatorSpelling(op string) string {
+	switch tokenType(op) {
+	case Neg:
+		return string(Sub)
+	case Factorial:
+		return string(Not)
+	case Xor:
+		return string(Pow)
+	case Rem:
+		return string(Percent)
+	case Convert:
+		return "to"
+	}
+	return op
+}
+
+// formatNumber prints a number literal in a form the tokenizer reads back: as written, or in positional notation.
+func formatNumber(n *node) string {
+	if n.token != "" {
+		return n.token
+	}
+	return strconv.FormatFloat(n.value, 'f', -1, 64)
+}
+
+// printPrecedence is the precedence of the operator at the top of a node; operands that are not operators bind tightest.
+func printPrecedence(n *node) int {
+	const atom = 10
+	switch n.kind {
+	case numberNode:
+		if n.value < 0 {
+			// A negative literal reads as a unary minus, which the tokenizer spells -1*x.
+			return precedence(string(Multi))
+		}
+		return atom
+	case operatorNode:
+		return precedence(n.token)
+	}
+	return atom
+}
+
+// format prints a node, see Expression.String.
+func format(n *node) string {
+	switch n.kind {
+	case numberNode:
+		return formatNumber(n)
+	case identifierNode:
+		return n.token
+	case stringNode:
+		return `"` + n.token + `"`
+	case listNode, callNode:
+		args := make([]string, len(n.args))
+		for i, arg := range n.args {
+			args[i] = format(arg)
+		}
+		if n.kind == listNode {
+			return "[" + strings.Join(args, ", ") + "]"
+		}
+		return n.token + "(" + strings.Join(args, ", ") + ")"
+	}
+
+	p := precedence(n.token)
+	switch {
+	case tokenType(n.token) == Question:
+		// The condition and the branches are parenthesized unless they bind tighter than the conditional.
+		return formatOperand(n.args[0], p+1) + " ? " + formatOperand(n.args[1], p+1) + " : " + formatOperand(n.args[2], p)
+	case isPrefixOperator(n.token):
+		return operatorSpelling(n.token) + formatOperand(n.args[0], p)
+	case isPostfixOperator(n.token):
+		return formatOperand(n.args[0], p+1) + operatorSpelling(n.token)
+	}
+
+	if isUnaryMinus(n) {
+		return "-" + formatOperand(n.args[1], p+1)
+	}
+
+	left, right := p, p+1
+	if isRightAssociative(n.token) {
+		left, right = p+1, p
+	}
+	op := operatorSpelling(n.token)
+	if p <= precedence(string(Add)) {
+		op = " " + op + " "
+	}
+	return formatOperand(n.args[0], left) + op + formatOperand(n.args[1], right)
+}
+
+// formatOperand prints an operand, in parentheses if it binds looser than minPrecedence.
+func formatOperand(n *node, minPrecedence int) string {
+	if printPrecedence(n) < minPrecedence {
+		return "(" + format(n) + ")"
+	}
+	return format(n)
+}
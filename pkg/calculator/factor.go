@@ -0,0 +1,476 @@
+package calculator
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"slices"
+)
+
+const (
+	// maxFactorValue bounds the integers factor splits into divisors: the coefficients of the rational root test
+	// and the values of Kronecker's method.
+	maxFactorValue = 1e12
+	// maxFactorCandidates bounds the candidate factors factor tries.
+	maxFactorCandidates = 100000
+	// maxAberthIterations bounds the iterations of the Aberth method that finds the roots of a factor of degree 3 or more.
+	maxAberthIterations = 500
+)
+
+// PolynomialFactor is an irreducible factor of a factorization and the number of times it divides the polynomial.
+type PolynomialFactor struct {
+	Polynomial   Polynomial
+	Multiplicity int
+}
+
+// Factorization is a polynomial factored over the rationals, the value of factor(p): a constant times
+// powers of irreducible polynomials with integer coefficients, whose greatest common divisor is 1
+// and whose leading coefficient is positive, such as 2*(x - 1)^2*(x^2 + 1).
+type Factorization struct {
+	Constant *big.Rat
+	// Factors are ordered by degree, then by coefficients from the constant up: x*(x - 1)*(x + 1).
+	Factors  []PolynomialFactor
+	variable string
+}
+
+// String formats the factorization as a product, like 1/2*x*(x - 1)^2.
+func (fz Factorization) String() string {
+	var terms []*node
+	constant := new(big.Rat).Abs(fz.Constant).Cmp(big.NewRat(1, 1)) != 0 || len(fz.Factors) == 0
+	if constant {
+		// The constant carries the sign, and is a fraction like 1/2*x rather than 0.5*x, as the factors
+		// have integer coefficients.
+		c := ratNode(new(big.Rat).SetInt(fz.Constant.Num()))
+		if !fz.Constant.IsInt() {
+			c = quotient(c, ratNode(new(big.Rat).SetInt(fz.Constant.Denom())))
+		}
+		terms = append(terms, c)
+	}
+	for _, f := range fz.Factors {
+		term := f.Polynomial.node()
+		if f.Multiplicity > 1 {
+			term = power(term, number(float64(f.Multiplicity)))
+		}
+		terms = append(terms, term)
+	}
+	if !constant && fz.Constant.Sign() < 0 {
+		// Without a constant the sign goes to the first factor: -(x - 1)*(x + 1).
+		terms[0] = negation(terms[0])
+	}
+
+	n := terms[0]
+	for _, term := range terms[1:] {
+		n = product(n, term)
+	}
+	return format(n)
+}
+
+// Polynomial multiplies the factorization out.
+func (fz Factorization) Polynomial() Polynomial {
+	coeffs := []*big.Rat{fz.Constant}
+	for _, f := range fz.Factors {
+		for range f.Multiplicity {
+			coeffs = mulCoeffs(coeffs, f.Polynomial.coeffs)
+		}
+	}
+	return newPolynomial(fz.variable, coeffs)
+}
+
+// Factor factors the polynomial into irreducible polynomials over the rationals; the polynomial 0 fails with ErrDomain.
+func (p Polynomial) Factor() (Factorization, error) {
+	if len(p.coeffs) == 0 {
+		return Factorization{}, NewCalcError(ErrDomain, "0 has no factorization")
+	}
+
+	var factors []PolynomialFactor
+	for i, part := range squareFree(primitive(p.coeffs)) {
+		if len(part) < 2 {
+			continue
+		}
+		irreducible, err := factorSquareFree(part)
+		if err != nil {
+			return Factorization{}, NewCalcError(ErrDomain, fmt.Sprintf("%s is too large to factor: %v", p, err))
+		}
+		for _, f := range irreducible {
+			factors = append(factors, PolynomialFactor{Polynomial: newPolynomial(p.variable, f), Multiplicity: i + 1})
+		}
+	}
+	slices.SortFunc(factors, func(a, b PolynomialFactor) int {
+		return compareCoeffs(a.Polynomial.coeffs, b.Polynomial.coeffs)
+	})
+
+	fz := Factorization{Constant: big.NewRat(1, 1), Factors: factors, variable: p.variable}
+	multiplied := fz.Polynomial().coeffs
+	fz.Constant = new(big.Rat).Quo(p.coeffs[len(p.coeffs)-1], multiplied[len(multiplied)-1])
+	return fz, nil
+}
+
+// compareCoeffs orders polynomials by degree, then by their coefficients from the constant up, the smaller
+// magnitude and then the negative one first: x, x - 1, x + 1.
+func compareCoeffs(a, b []*big.Rat) int {
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	for i := range a {
+		if c := new(big.Rat).Abs(a[i]).Cmp(new(big.Rat).Abs(b[i])); c != 0 {
+			return c
+		}
+		if c := a[i].Cmp(b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// primitive divides a polynomial by the rational number that leaves integer coefficients with the greatest common
+// divisor 1 and a positive leading coefficient.
+func primitive(a []*big.Rat) []*big.Rat {
+	if len(a) == 0 {
+		return nil
+	}
+	num, den := new(big.Int), big.NewInt(1)
+	for _, c := range a {
+		num.GCD(nil, nil, num, c.Num())
+		g := new(big.Int).GCD(nil, nil, den, c.Denom())
+		den.Mul(den, c.Denom()).Quo(den, g)
+	}
+	content := new(big.Rat).SetFrac(num, den)
+	if a[len(a)-1].Sign() < 0 {
+		content.Neg(content)
+	}
+	return scaleCoeffs(a, content.Inv(content))
+}
+
+// squareFree splits a primitive polynomial by Yun's algorithm into primitive polynomials without repeated factors,
+// the i-th being the product of the factors of multiplicity i+1.
+func squareFree(f []*big.Rat) [][]*big.Rat {
+	df := derivativeCoeffs(f)
+	a := gcdCoeffs(f, df)
+	if len(a) == 0 {
+		return nil
+	}
+	b, _ := divCoeffs(f, a)
+	c, _ := divCoeffs(df, a)
+	d := addCoeffs(c, derivativeCoeffs(b), -1)
+
+	var parts [][]*big.Rat
+	for len(b) > 1 {
+		a = gcdCoeffs(b, d)
+		parts = append(parts, primitive(a))
+		b, _ = divCoeffs(b, a)
+		c, _ = divCoeffs(d, a)
+		d = addCoeffs(c, derivativeCoeffs(b), -1)
+	}
+	return parts
+}
+
+// factorSquareFree splits a primitive polynomial without repeated factors into irreducible ones.
+func factorSquareFree(f []*big.Rat) ([][]*big.Rat, error) {
+	var factors [][]*big.Rat
+	x := []*big.Rat{new(big.Rat), big.NewRat(1, 1)}
+	if f[0].Sign() == 0 {
+		factors = append(factors, x)
+		f, _ = divCoeffs(f, x)
+	}
+
+	// A rational root p/q has a numerator p that divides the constant coefficient and a denominator q
+	// that divides the leading one.
+	if len(f) > 1 {
+		numerators, err := divisors(f[0].Num())
+		if err != nil {
+			return nil, err
+		}
+		denominators, err := divisors(f[len(f)-1].Num())
+		if err != nil {
+			return nil, err
+		}
+		if 2*len(numerators)*len(denominators) > maxFactorCandidates {
+			return nil, fmt.Errorf("%d candidate roots", 2*len(numerators)*len(denominators))
+		}
+		for _, q := range denominators {
+			for _, p := range numerators {
+				for _, sign := range []int64{1, -1} {
+					root := big.NewRat(sign*p, q)
+					if len(f) < 2 || root.Denom().Int64() != q || evalCoeffs(f, root).Sign() != 0 {
+						continue
+					}
+					linear := []*big.Rat{big.NewRat(-sign*p, 1), big.NewRat(q, 1)}
+					factors = append(factors, linear)
+					f, _ = divCoeffs(f, linear)
+				}
+			}
+		}
+	}
+
+	// A polynomial of degree 2 or 3 without rational roots is irreducible. For the others Kronecker's method tries
+	// every polynomial of degree k whose values at k+1 points divide those of f there, from the lowest k up.
+	for k := 2; 2*k <= len(f)-1; {
+		g, err := kroneckerFactor(f, k)
+		if err != nil {
+			return nil, err
+		}
+		if g == nil {
+			k++
+			continue
+		}
+		factors = append(factors, g)
+		f, _ = divCoeffs(f, g)
+	}
+	if len(f) > 1 {
+		factors = append(factors, primitive(f))
+	}
+	return factors, nil
+}
+
+// kroneckerFactor returns a primitive factor of degree k of a polynomial with integer coefficients and
+// no rational roots, or nil if there is none.
+func kroneckerFactor(f []*big.Rat, k int) ([]*big.Rat, error) {
+	points := make([]int64, k+1)
+	choices := make([][]int64, k+1)
+	candidates := 1
+	for i := range points {
+		// 0, 1, -1, 2, -2, ...: f has no rational roots, so it is not 0 there.
+		points[i] = int64((i + 1) / 2)
+		if i%2 == 0 {
+			points[i] = -points[i]
+		}
+		ds, err := divisors(evalCoeffs(f, big.NewRat(points[i], 1)).Num())
+		if err != nil {
+			return nil, err
+		}
+		// The first value of a factor can be taken positive, -g being a factor as well.
+		choices[i] = ds
+		if i > 0 {
+			for _, d := range ds {
+				choices[i] = append(choices[i], -d)
+			}
+		}
+		candidates *= len(choices[i])
+		if candidates > maxFactorCandidates {
+			return nil, fmt.Errorf("more than %d candidate factors of degree %d", maxFactorCandidates, k)
+		}
+	}
+
+	// The leading coefficient of the polynomial through the values, sum values[i]*weights[i], has to divide that
+	// of f, which rules out most candidates before they are interpolated.
+	weights := make([]*big.Rat, k+1)
+	for i := range points {
+		weights[i] = big.NewRat(1, 1)
+		for j := range points {
+			if j != i {
+				weights[i].Quo(weights[i], big.NewRat(points[i]-points[j], 1))
+			}
+		}
+	}
+	lead := f[len(f)-1].Num()
+
+	values := make([]int64, k+1)
+	var try func(i int) []*big.Rat
+	try = func(i int) []*big.Rat {
+		if i == len(points) {
+			c := new(big.Rat)
+			for j, v := range values {
+				c.Add(c, new(big.Rat).Mul(weights[j], big.NewRat(v, 1)))
+			}
+			if c.Sign() == 0 || !c.IsInt() || new(big.Int).Rem(lead, c.Num()).Sign() != 0 {
+				return nil
+			}
+			g := interpolate(points, values)
+			if len(g) != k+1 || slices.ContainsFunc(g, func(c *big.Rat) bool { return !c.IsInt() }) {
+				return nil
+			}
+			if _, rem := divCoeffs(f, g); len(rem) != 0 {
+				return nil
+			}
+			return primitive(g)
+		}
+		for _, v := range choices[i] {
+			values[i] = v
+			if g := try(i + 1); g != nil {
+				return g
+			}
+		}
+		return nil
+	}
+	return try(0), nil
+}
+
+// interpolate returns the polynomial of the least degree through the points (xs[i], ys[i]), by Lagrange's formula.
+func interpolate(xs, ys []int64) []*big.Rat {
+	var sum []*big.Rat
+	for i := range xs {
+		basis := []*big.Rat{big.NewRat(ys[i], 1)}
+		for j := range xs {
+			if j != i {
+				linear := []*big.Rat{big.NewRat(-xs[j], xs[i]-xs[j]), big.NewRat(1, xs[i]-xs[j])}
+				basis = mulCoeffs(basis, linear)
+			}
+		}
+		sum = addCoeffs(sum, basis, 1)
+	}
+	return sum
+}
+
+// divisors returns the positive divisors of a non-zero integer in ascending order.
+func divisors(n *big.Int) ([]int64, error) {
+	abs := new(big.Int).Abs(n)
+	if !abs.IsInt64() || abs.Int64() > maxFactorValue {
+		return nil, fmt.Errorf("%s has too many divisors", n)
+	}
+	m := abs.Int64()
+	var small, large []int64
+	for d := int64(1); d*d <= m; d++ {
+		if m%d == 0 {
+			small = append(small, d)
+			if d*d != m {
+				large = append(large, m/d)
+			}
+		}
+	}
+	slices.Reverse(large)
+	return append(small, large...), nil
+}
+
+// Roots returns the distinct roots of the polynomial, the real ones in ascending order, then the complex ones.
+func (p Polynomial) Roots() ([]complex128, error) {
+	if len(p.coeffs) == 0 {
+		return nil, NewCalcError(ErrDomain, "every number is a root of 0")
+	}
+
+	var factors [][]*big.Rat
+	if fz, err := p.Factor(); err == nil {
+		for _, f := range fz.Factors {
+			factors = append(factors, f.Polynomial.coeffs)
+		}
+	} else {
+		parts, _ := divCoeffs(p.coeffs, gcdCoeffs(p.coeffs, derivativeCoeffs(p.coeffs)))
+		factors = append(factors, parts)
+	}
+
+	var roots []complex128
+	for _, f := range factors {
+		switch len(f) - 1 {
+		case 0:
+		case 1:
+			root, _ := new(big.Rat).Quo(new(big.Rat).Neg(f[0]), f[1]).Float64()
+			roots = append(roots, complex(root, 0))
+		case 2:
+			roots = append(roots, quadraticRoots(f)...)
+		default:
+			found, err := aberth(f)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, found...)
+		}
+	}
+
+	slices.SortFunc(roots, func(a, b complex128) int {
+		if c := cmp.Compare(boolean(imag(a) != 0), boolean(imag(b) != 0)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(real(a), real(b)); c != 0 {
+			return c
+		}
+		return cmp.Compare(imag(a), imag(b))
+	})
+	return roots, nil
+}
+
+func quadraticRoots(f []*big.Rat) []complex128 {
+	a, _ := f[2].Float64()
+	b, _ := f[1].Float64()
+	c, _ := f[0].Float64()
+	d := b*b - 4*a*c
+	if d < 0 {
+		re, im := -b/(2*a), math.Sqrt(-d)/(2*a)
+		return []complex128{complex(re, -math.Abs(im)), complex(re, math.Abs(im))}
+	}
+	if b == 0 {
+		root := math.Sqrt(d) / (2 * math.Abs(a))
+		return []complex128{complex(-root, 0), complex(root, 0)}
+	}
+	// The root of the larger magnitude first, the other from the product of the roots, to avoid cancellation.
+	q := -(b + math.Copysign(math.Sqrt(d), b)) / 2
+	return []complex128{complex(q/a, 0), complex(c/q, 0)}
+}
+
+// aberth finds the roots of a polynomial without repeated factors with the Aberth method, which moves all
+// approximations at once, each by Newton's step corrected for the others.
+func aberth(f []*big.Rat) ([]complex128, error) {
+	n := len(f) - 1
+	coeffs := make([]complex128, len(f))
+	lead, _ := f[n].Float64()
+	bound := 0.0
+	for i, c := range f {
+		x, _ := c.Float64()
+		coeffs[i] = complex(x/lead, 0)
+		if i < n {
+			bound = math.Max(bound, math.Abs(x/lead))
+		}
+	}
+	eval := func(z complex128) (complex128, complex128) {
+		var p, dp complex128
+		for i := n; i >= 0; i-- {
+			dp = dp*z + p
+			p = p*z + coeffs[i]
+		}
+		return p, dp
+	}
+
+	// Start on a circle within Cauchy's bound of the roots, turned off the real axis.
+	roots := make([]complex128, n)
+	for i := range roots {
+		roots[i] = cmplx.Rect((1+bound)/2, 2*math.Pi*float64(i)/float64(n)+0.4)
+	}
+	for range maxAberthIterations {
+		converged := true
+		for i, z := range roots {
+			p, dp := eval(z)
+			if p == 0 {
+				continue
+			}
+			ratio := p / dp
+			var sum complex128
+			for j, w := range roots {
+				if j != i {
+					sum += 1 / (z - w)
+				}
+			}
+			step := ratio / (1 - ratio*sum)
+			roots[i] = z - step
+			if cmplx.Abs(step) > 1e-14*math.Max(1, cmplx.Abs(z)) {
+				converged = false
+			}
+		}
+		if converged {
+			return conjugatePairs(roots), nil
+		}
+	}
+	return nil, NewCalcError(ErrNoConvergence, fmt.Sprintf("roots of a polynomial of degree %d", n))
+}
+
+// conjugatePairs cleans up the roots of a polynomial with real coefficients: a root whose imaginary part is lost
+// in rounding is real, and the others come in pairs of exact conjugates.
+func conjugatePairs(roots []complex128) []complex128 {
+	var reals, upper, lower []complex128
+	for _, z := range roots {
+		switch {
+		case math.Abs(imag(z)) <= 1e-10*math.Max(1, cmplx.Abs(z)):
+			reals = append(reals, complex(real(z), 0))
+		case imag(z) > 0:
+			upper = append(upper, z)
+		default:
+			lower = append(lower, z)
+		}
+	}
+	if len(upper) != len(lower) {
+		return append(reals, append(upper, lower...)...)
+	}
+	for _, z := range upper {
+		reals = append(reals, z, cmplx.Conj(z))
+	}
+	return reals
+}
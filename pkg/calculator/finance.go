@@ -0,0 +1,242 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// maxPeriods bounds the number of rows of an amortization schedule: 100 years of monthly payments.
+const maxPeriods = 1200
+
+// The time value of money functions follow the conventions of spreadsheets: rate is the interest rate
+// per period, nper the number of periods, and money paid out is negative, money received positive,
+// so pmt(5%/12, 360, 100000), the monthly payment of a loan of 100000 received, is -536.82.
+// The optional due argument is 0 for payments at the end of each period (default) and 1 for payments
+// at the beginning.
+var financialFunctions = map[string]builtin{
+	// pv(rate, nper, pmt, [fv], [due]) is the present value of a series of payments.
+	"pv": {minArgs: 3, maxArgs: 5, fn: func(args []float64) (float64, error) {
+		r, n, pmt, fv, due, err := tvmArgs(args)
+		if err != nil {
+			return 0, err
+		}
+		if r == 0 {
+			return -(fv + pmt*n), nil
+		}
+		g := math.Pow(1+r, n)
+		return -(fv + pmt*(1+r*due)*(g-1)/r) / g, nil
+	}},
+	// fv(rate, nper, pmt, [pv], [due]) is the future value of a series of payments.
+	"fv": {minArgs: 3, maxArgs: 5, fn: func(args []float64) (float64, error) {
+		r, n, pmt, pv, due, err := tvmArgs(args)
+		if err != nil {
+			return 0, err
+		}
+		return futureValue(r, n, pmt, pv, due), nil
+	}},
+	// pmt(rate, nper, pv, [fv], [due]) is the payment per period that pays off pv, leaving fv.
+	"pmt": {minArgs: 3, maxArgs: 5, fn: func(args []float64) (float64, error) {
+		r, n, pv, fv, due, err := tvmArgs(args)
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, NewCalcError(ErrDomain, formatCall("pmt", args))
+		}
+		return payment(r, n, pv, fv, due), nil
+	}},
+	// nper(rate, pmt, pv, [fv], [due]) is the number of periods until pv is paid off.
+	"nper": {minArgs: 3, maxArgs: 5, fn: func(args []float64) (float64, error) {
+		r, pmt, pv, fv, due, err := tvmArgs(args)
+		if err != nil {
+			return 0, err
+		}
+		if r == 0 {
+			return -(pv + fv) / pmt, nil
+		}
+		k := pmt * (1 + r*due)
+		return math.Log((k-fv*r)/(k+pv*r)) / math.Log(1+r), nil
+	}},
+	// ipmt(rate, per, nper, pv, [fv], [due]) is the interest part of the payment of period per.
+	"ipmt": {minArgs: 4, maxArgs: 6, fn: func(args []float64) (float64, error) {
+		ip, _, err := paymentParts("ipmt", args)
+		return ip, err
+	}},
+	// ppmt(rate, per, nper, pv, [fv], [due]) is the principal part of the payment of period per.
+	"ppmt": {minArgs: 4, maxArgs: 6, fn: func(args []float64) (float64, error) {
+		_, pp, err := paymentParts("ppmt", args)
+		return pp, err
+	}},
+	// npv(rate, v1, v2, ...) is the present value of cash flows at the end of periods 1, 2, ...
+	"npv": variadic(2, func(args []float64) (float64, error) {
+		if args[0] <= -1 {
+			return 0, NewCalcError(ErrDomain, formatCall("npv", args))
+		}
+		return presentValue(args[0], args[1:], 1), nil
+	}),
+	// irr(v0, v1, ...) is the rate at which the cash flows of periods 0, 1, ... have a present value of 0.
+	"irr": variadic(2, irr),
+}
+
+func init() {
+	for name, f := range financialFunctions {
+		builtins[name] = f
+	}
+}
+
+// tvmArgs reads the rate, two more required arguments and the optional value and due arguments,
+// which default to 0.
+func tvmArgs(args []float64) (r, x, y, v, due float64, err error) {
+	r, x, y = args[0], args[1], args[2]
+	if len(args) > 3 {
+		v = args[3]
+	}
+	if len(args) > 4 {
+		due = args[4]
+	}
+	if r <= -1 || (due != 0 && due != 1) {
+		return 0, 0, 0, 0, 0, NewCalcError(ErrDomain, formatCall("rate and due", []float64{r, due}))
+	}
+	return r, x, y, v, due, nil
+}
+
+func futureValue(r, n, pmt, pv, due float64) float64 {
+	if r == 0 {
+		return -(pv + pmt*n)
+	}
+	g := math.Pow(1+r, n)
+	return -(pv*g + pmt*(1+r*due)*(g-1)/r)
+}
+
+func payment(r, n, pv, fv, due float64) float64 {
+	if r == 0 {
+		return -(pv + fv) / n
+	}
+	g := math.Pow(1+r, n)
+	return -r * (pv*g + fv) / ((1 + r*due) * (g - 1))
+}
+
+// paymentParts splits the payment of a period into its interest and its principal part.
+func paymentParts(name string, args []float64) (interest, principal float64, err error) {
+	per := args[1]
+	r, n, pv, fv, due, err := tvmArgs(slices.Delete(slices.Clone(args), 1, 2))
+	if err != nil {
+		return 0, 0, err
+	}
+	if per != math.Trunc(per) || per < 1 || per > n {
+		return 0, 0, NewCalcError(ErrDomain, formatCall(name, args))
+	}
+
+	pmt := payment(r, n, pv, fv, due)
+	if due == 1 && per == 1 {
+		// The first payment in advance is made before any interest accrues.
+		return 0, pmt, nil
+	}
+	// The interest of a period is charged on the balance at its start, which is the negated future value
+	// of the periods before.
+	interest = futureValue(r, per-1, pmt, pv, due) * r
+	if due == 1 {
+		interest /= 1 + r
+	}
+	return interest, pmt - interest, nil
+}
+
+// presentValue discounts the cash flows, the first of which is at the end of period first.
+func presentValue(r float64, flows []float64, first int) float64 {
+	var sum float64
+	for i, v := range flows {
+		sum += v / math.Pow(1+r, float64(i+first))
+	}
+	return sum
+}
+
+// irrCandidates are the rates between which irr looks for a sign change of the present value, in the
+// order of distance from the usual guess of 10%, so it finds the root closest to it when there are several.
+var irrCandidates = []float64{0.1, 0.2, 0, 0.5, -0.2, 1, -0.5, 2, -0.9, 5, -0.99, 10, 100, -0.999999}
+
+func irr(flows []float64) (float64, error) {
+	hasIn := false
+	hasOut := false
+	for _, v := range flows {
+		hasIn = hasIn || v > 0
+		hasOut = hasOut || v < 0
+	}
+	if !hasIn || !hasOut {
+		return 0, NewCalcError(ErrDomain, "irr needs both positive and negative cash flows")
+	}
+
+	npv := func(r float64) float64 { return presentValue(r, flows, 0) }
+
+	// Widen a bracket around the guess one candidate at a time until the present value changes its sign.
+	lo, hi := irrCandidates[0], irrCandidates[0]
+	for _, c := range irrCandidates[1:] {
+		lo, hi = math.Min(lo, c), math.Max(hi, c)
+		if math.Signbit(npv(lo)) != math.Signbit(npv(hi)) {
+			// Narrow the bracket to the adjacent candidates that hold the sign change nearest to the guess.
+			a, b := bracketNear(npv, lo, hi, irrCandidates[0])
+			return findRoot(npv, a, b, 1e-12)
+		}
+	}
+	return 0, NewCalcError(ErrNoConvergence, fmt.Sprintf("irr of %d cash flows", len(flows)))
+}
+
+// bracketNear returns the pair of adjacent candidates in [lo, hi] closest to guess over which f changes its sign.
+func bracketNear(f func(float64) float64, lo, hi, guess float64) (float64, float64) {
+	var points []float64
+	for _, c := range irrCandidates {
+		if c >= lo && c <= hi {
+			points = append(points, c)
+		}
+	}
+	slices.Sort(points)
+
+	bestA, bestB, best := lo, hi, math.Inf(1)
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if math.Signbit(f(a)) == math.Signbit(f(b)) {
+			continue
+		}
+		if d := math.Min(math.Abs(a-guess), math.Abs(b-guess)); d < best {
+			bestA, bestB, best = a, b, d
+		}
+	}
+	return bestA, bestB
+}
+
+// amortize(rate, nper, pv) is the schedule of a loan of pv paid off in nper equal payments at the end
+// of each period: a list of rows [period, payment, interest, principal, balance], in the amounts of the loan,
+// so a loan of 100000 has positive payments and a balance that falls to 0.
+func amortize(_ *Environment, args []Value) (Value, error) {
+	nums := make([]float64, len(args))
+	for i, a := range args {
+		x, err := toReal(a)
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = x
+	}
+
+	r, n, pv := nums[0], nums[1], nums[2]
+	switch {
+	case n != math.Trunc(n) || n < 1 || r <= -1:
+		return nil, NewCalcError(ErrDomain, formatCall("amortize", nums))
+	case n > maxPeriods:
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("amortize of %g periods, at most %d", n, maxPeriods))
+	}
+
+	pmt := -payment(r, n, pv, 0, 0)
+	balance := pv
+	schedule := make(List, 0, int(n))
+	for per := 1; per <= int(n); per++ {
+		interest := balance * r
+		principal := pmt - interest
+		balance -= principal
+		if per == int(n) && math.Abs(balance) < 1e-9*math.Abs(pv) {
+			// Round away the error accumulated over the periods.
+			balance = 0
+		}
+		schedule = append(schedule, listOf(float64(per), pmt, interest, principal, balance))
+	}
+	return schedule, nil
+}
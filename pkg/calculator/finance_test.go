@@ -0,0 +1,115 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFinancialFunctions(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"pmt(5%/12, 360, 100000)", -536.821623},
+		{"pmt(5%/12, 360, 100000, 0, 1)", -534.594147},
+		{"pmt(0, 10, 1000)", -100},
+		{"pmt(0.08/12, 120, 0, 50000)", -273.304638},
+		{"pv(0.08/12, 240, -500)", 59777.145851},
+		{"pv(0, 12, -100)", 1200},
+		{"fv(0.06/12, 10, -200, -500, 1)", 2581.403374},
+		{"fv(0.005, 12, -100)", 1233.556237},
+		{"nper(0.01, -100, 1000)", 10.588644},
+		{"nper(0, -100, 1000)", 10},
+		{"ipmt(5%/12, 1, 360, 100000)", -416.666667},
+		{"ppmt(5%/12, 1, 360, 100000)", -120.154956},
+		{"ipmt(5%/12, 360, 360, 100000) + ppmt(5%/12, 360, 360, 100000)", -536.821623},
+		{"ipmt(0.1/12, 1, 36, 8000, 0, 1)", 0},
+		{"ppmt(0.1/12, 1, 36, 8000, 0, 1)", -256.004130},
+		{"npv(0.1, -10000, 3000, 4200, 6800)", 1188.443412},
+		{"irr(-70000, 12000, 15000, 18000, 21000, 26000)", 0.086631},
+		{"irr(-70000, 12000, 15000, 18000, 21000)", -0.021245},
+		{"irr(-100, 230, -132)", 0.1},
+		{"npv(irr(-500, 200, 200, 200), 200, 200, 200) - 500", 0},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFinancialFunctionsErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"pmt(0.05, 0, 1000)", ErrDomain},
+		{"pmt(-1, 10, 1000)", ErrDomain},
+		{"pmt(0.05, 10, 1000, 0, 2)", ErrDomain},
+		{"ipmt(0.05, 11, 10, 1000)", ErrDomain},
+		{"ipmt(0.05, 1.5, 10, 1000)", ErrDomain},
+		{"nper(0.05, -10, 1000)", ErrDomain},
+		{"irr(100, 200)", ErrDomain},
+		{"irr(-100, 1, -1, 1, -1)", ErrNoConvergence},
+		{"npv(0.1)", ErrArgumentCount},
+		{"amortize(0.01, 1.5, 1000)", ErrDomain},
+		{"amortize(0.01, 5000, 1000)", ErrTooLargeNumber},
+		{"amortize(0.01, 12)", ErrArgumentCount},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
+
+func TestAmortize(t *testing.T) {
+	got, err := NewEnvironment(Options{}).EvaluateValue("amortize(10%, 3, 1000)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schedule, ok := got.(List)
+	if !ok || len(schedule) != 3 {
+		t.Fatalf("got %s, want a list of 3 rows", got)
+	}
+
+	want := [][]float64{
+		{1, 402.114804, 100, 302.114804, 697.885196},
+		{2, 402.114804, 69.788520, 332.326284, 365.558912},
+		{3, 402.114804, 36.555891, 365.558912, 0},
+	}
+	for i, row := range schedule {
+		cells, ok := row.(List)
+		if !ok || len(cells) != 5 {
+			t.Fatalf("row %d = %s, want 5 numbers", i+1, row)
+		}
+		for j, cell := range cells {
+			if x := float64(cell.(Real)); math.Abs(x-want[i][j]) > 1e-6 {
+				t.Errorf("row %d column %d = %v, want %v", i+1, j+1, x, want[i][j])
+			}
+		}
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	x, err := findRoot(func(x float64) float64 { return x*x - 2 }, 0, 2, 1e-14)
+	if err != nil || math.Abs(x-math.Sqrt2) > 1e-12 {
+		t.Errorf("findRoot(x^2 - 2) = %v, %v", x, err)
+	}
+
+	_, err = findRoot(func(x float64) float64 { return x*x + 1 }, -1, 1, 1e-12)
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrNoConvergence {
+		t.Errorf("findRoot(x^2 + 1) error = %v, want no_convergence", err)
+	}
+}
@@ -0,0 +1,206 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+)
+
+// builtin is a predefined function. maxArgs < 0 means the function is variadic.
+type builtin struct {
+	minArgs int
+	maxArgs int
+	fn      func(args []float64) (float64, error)
+}
+
+func unary(fn func(float64) float64) builtin {
+	return builtin{minArgs: 1, maxArgs: 1, fn: func(args []float64) (float64, error) { return fn(args[0]), nil }}
+}
+
+func binary(fn func(float64, float64) float64) builtin {
+	return builtin{minArgs: 2, maxArgs: 2, fn: func(args []float64) (float64, error) { return fn(args[0], args[1]), nil }}
+}
+
+// variadic wraps a function of at least minArgs arguments.
+func variadic(minArgs int, fn func([]float64) (float64, error)) builtin {
+	return builtin{minArgs: minArgs, maxArgs: -1, fn: fn}
+}
+
+// builtins are the functions available in every expression. The combinatorics and number theory
+// functions are added from integerFunctions, see integers.go.
+var builtins = map[string]builtin{
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"cbrt":  unary(math.Cbrt),
+	"exp":   unary(math.Exp),
+	"ln":    unary(math.Log),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"sinh":  unary(math.Sinh),
+	"cosh":  unary(math.Cosh),
+	"tanh":  unary(math.Tanh),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"trunc": unary(math.Trunc),
+	// The parts of a real number; see complexFunctions for complex arguments.
+	"re":    unary(func(x float64) float64 { return x }),
+	"im":    unary(func(float64) float64 { return 0 }),
+	"conj":  unary(func(x float64) float64 { return x }),
+	"arg":   unary(func(x float64) float64 { return math.Atan2(0, x) }),
+	"atan2": binary(math.Atan2),
+	"hypot": binary(math.Hypot),
+	// log(x) is the decimal logarithm, log(x, b) the logarithm to base b.
+	"log": {minArgs: 1, maxArgs: 2, fn: func(args []float64) (float64, error) {
+		if len(args) == 1 {
+			return math.Log10(args[0]), nil
+		}
+		return math.Log(args[0]) / math.Log(args[1]), nil
+	}},
+	"min": variadic(1, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, v := range args[1:] {
+			m = math.Min(m, v)
+		}
+		return m, nil
+	}),
+	"max": variadic(1, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, v := range args[1:] {
+			m = math.Max(m, v)
+		}
+		return m, nil
+	}),
+}
+
+// call applies a built-in function, rejecting results outside of the real numbers (sqrt(-1), ln(0)).
+func (b builtin) call(name string, args []float64) (float64, error) {
+	if err := b.checkArity(name, len(args)); err != nil {
+		return 0, err
+	}
+
+	result, err := b.fn(args)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, NewCalcError(ErrDomain, formatCall(name, args))
+	}
+
+	return result, nil
+}
+
+func (b builtin) checkArity(name string, argc int) error {
+	return checkArity(name, argc, b.minArgs, b.maxArgs)
+}
+
+// checkArity rejects a call with a number of arguments outside of [minArgs, maxArgs]; maxArgs < 0 means no limit.
+func checkArity(name string, argc, minArgs, maxArgs int) error {
+	if argc >= minArgs && (maxArgs < 0 || argc <= maxArgs) {
+		return nil
+	}
+
+	var expected string
+	switch {
+	case maxArgs < 0:
+		expected = fmt.Sprintf("at least %d argument(s)", minArgs)
+	case minArgs == maxArgs:
+		expected = fmt.Sprintf("%d argument(s)", minArgs)
+	default:
+		expected = fmt.Sprintf("%d to %d arguments", minArgs, maxArgs)
+	}
+
+	return NewCalcError(ErrArgumentCount, fmt.Sprintf("%s expects %s, got %d", name, expected, argc))
+}
+
+// specialForm is a built-in function that receives its arguments unevaluated and decides itself
+// which of them to evaluate.
+type specialForm struct {
+	minArgs int
+	maxArgs int
+	// binds reports whether the second argument of a call names a variable of the first, as in solve(x^2 = 2, x).
+	binds func(args []*node) bool
+	eval  func(e *Environment, args []*node, f *frame) (Value, error)
+}
+
+// bindsAlways is the binds of a form whose second argument always names a variable.
+func bindsAlways([]*node) bool {
+	return true
+}
+
+func (s specialForm) checkArity(name string, argc int) error {
+	return checkArity(name, argc, s.minArgs, s.maxArgs)
+}
+
+// evalArgs evaluates the arguments of a call.
+func (e *Environment) evalArgs(args []*node, f *frame) ([]Value, error) {
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		v, err := e.eval(arg, f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// valueFunction adapts a function of evaluated arguments to a special form, for the functions that take
+// or return values other than numbers, such as dates, which the built-in functions can not.
+func valueFunction(minArgs, maxArgs int, fn func(e *Environment, args []Value) (Value, error)) specialForm {
+	return specialForm{minArgs: minArgs, maxArgs: maxArgs, eval: func(e *Environment, args []*node, f *frame) (Value, error) {
+		values, err := e.evalArgs(args, f)
+		if err != nil {
+			return nil, err
+		}
+		return fn(e, values)
+	}}
+}
+
+// specialForms are looked up before the built-in functions. They are filled in init,
+// because their implementations call back into the evaluator.
+var specialForms map[string]specialForm
+
+func init() {
+	specialForms = map[string]specialForm{
+		// if(cond, a, b) is the function spelling of cond ? a : b.
+		"if": {minArgs: 3, maxArgs: 3, eval: func(e *Environment, args []*node, f *frame) (Value, error) {
+			cond, err := e.eval(args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(cond) {
+				return e.eval(args[1], f)
+			}
+			return e.eval(args[2], f)
+		}},
+	}
+	maps.Copy(specialForms, timeFunctions)
+	maps.Copy(specialForms, matrixFunctions)
+	maps.Copy(specialForms, polynomialFunctions)
+	specialForms["amortize"] = valueFunction(3, 3, amortize)
+	specialForms["interval"] = valueFunction(2, 2, interval)
+	specialForms["solve"] = solveForm
+	specialForms["integrate"] = integrateForm
+	specialForms["series"] = specialForm{minArgs: 4, maxArgs: 4, binds: bindsAlways, eval: series("series", Add, Real(0))}
+	specialForms["product"] = specialForm{minArgs: 4, maxArgs: 4, binds: bindsAlways, eval: series("product", Multi, Real(1))}
+}
+
+func formatCall(name string, args []float64) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprintf("%g", a)
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(parts, ", "))
+}
+
+// function is a user-defined function, e.g. f(x) = x^2 + 1.
+type function struct {
+	params []string
+	body   *node
+}
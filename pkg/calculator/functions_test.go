@@ -0,0 +1,157 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPower(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"2^3", 8},
+		{"2^3^2", 512},
+		{"-2^2", -4},
+		{"2^-1", 0.5},
+		{"2^-3*4", 0.5},
+		{"2*3^2", 18},
+		{"(2^3)^2", 64},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"sqrt(16)", 4},
+		{"abs(-3) + 1", 4},
+		{"max(1, 7, 3)", 7},
+		{"min(4, -2)", -2},
+		{"log(1000)", 3},
+		{"log(8, 2)", 3},
+		{"round(2.5) + floor(-1.5)", 1},
+		{"-sqrt(4)", -2},
+		{"cos(0) * 2", 2},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestUserFunctions(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"f(x) = x^2 + 1; f(3) + f(4)", 27},
+		{"area(w, h) = w * h; area(2, 3) * 2", 12},
+		{"sq(x) = x*x; quad(x) = sq(sq(x)); quad(2)", 16},
+		{"k() = 42; k()", 42},
+		{"half(x) = x / 2; half(-sqrt(16))", -2},
+		{"f(x) = x + 1;; f(1);", 2},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestUserFunctionErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"f(x) = f(x); f(1)", ErrRecursionLimit},
+		{"f(x) = x; f(1, 2)", ErrArgumentCount},
+		{"sqrt(x) = x; sqrt(4)", ErrInvalidIdentifier},
+		{"f(x, x) = x; f(1, 2)", ErrInvalidIdentifier},
+		{"f(x) = x + 1", ErrInsufficientValues},
+		{"g(2)", ErrUnknownIdentifier},
+		{"sqrt(-1)", ErrDomain},
+		{"max()", ErrArgumentCount},
+		{"1, 2", ErrMismatchOperator},
+		{"(1, 2)", ErrMismatchOperator},
+	}
+
+	for _, tc := range testCases {
+		_, err := Evaluate(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Evaluate(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
+
+func TestEnvironmentKeepsFunctions(t *testing.T) {
+	env := NewEnvironment(Options{MaxDepth: 5})
+
+	if _, err := env.Evaluate("f(x) = x * 2; f(1)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := env.Evaluate("f(21)")
+	if err != nil || got != 42 {
+		t.Errorf("expected f to stay defined: got %v, %v", got, err)
+	}
+
+	if _, err = env.Evaluate("g(x) = x; g(1) / 0"); err == nil {
+		t.Fatalf("expected division by zero")
+	}
+	if _, err = env.Evaluate("g(1)"); err == nil {
+		t.Errorf("expected g to be discarded after a failed evaluation")
+	}
+
+	_, err = env.Evaluate("d(n) = d(n); d(1)")
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrRecursionLimit {
+		t.Errorf("expected recursion limit error, got %v", err)
+	}
+}
+
+func TestUserFunctionBudget(t *testing.T) {
+	// 2^25 calls, far beyond the budget, but never deeper than 25.
+	const input = "f(n) = n < 1 ? 1 : f(n-1) + f(n-1); f(24)"
+
+	for _, options := range []Options{{}, {Integer: IntegerMode{Bits: 64}, MaxEvaluations: 10000}} {
+		start := time.Now()
+		_, err := NewEnvironment(options).Evaluate(input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != ErrEvaluationLimit {
+			t.Errorf("Evaluate(%q) with %+v error = %v, want %v", input, options, err, ErrEvaluationLimit)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Evaluate(%q) with %+v took %v to fail", input, options, elapsed)
+		}
+	}
+
+	got, err := NewEnvironment(Options{}).Evaluate("f(n) = n < 1 ? 1 : f(n-1) + f(n-1); f(10)")
+	if err != nil || got != 1024 {
+		t.Errorf("expected f(10) within the budget = 1024, got %v, %v", got, err)
+	}
+}
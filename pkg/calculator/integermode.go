@@ -0,0 +1,514 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"strings"
+)
+
+// IntegerMode switches the evaluation to fixed-width integer arithmetic. In integer mode "^" is the bitwise
+// exclusive or instead of the power, "%" is the remainder instead of the percentage, and "/" truncates toward zero.
+type IntegerMode struct {
+	// Bits is the width: 8, 16, 32 or 64. Zero leaves integer mode off.
+	Bits int
+	// Unsigned reads the bits as an unsigned number instead of a two's complement one.
+	Unsigned bool
+	// Wrap makes an out of range result wrap around like the machine does, instead of failing with ErrOverflow.
+	Wrap bool
+}
+
+// Enabled reports whether the mode is on.
+func (m IntegerMode) Enabled() bool {
+	return m.Bits != 0
+}
+
+// Validate checks that the mode is on and has a supported width.
+func (m IntegerMode) Validate() error {
+	switch m.Bits {
+	case 8, 16, 32, 64:
+		return nil
+	case 0:
+		return errors.New("integer mode is off")
+	}
+	return fmt.Errorf("unsupported integer width %d, expected 8, 16, 32 or 64", m.Bits)
+}
+
+// String returns the Go name of the type, e.g. int32 or uint8.
+func (m IntegerMode) String() string {
+	if m.Unsigned {
+		return fmt.Sprintf("uint%d", m.Bits)
+	}
+	return fmt.Sprintf("int%d", m.Bits)
+}
+
+// mask returns 2^Bits - 1.
+func (m IntegerMode) mask() *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(m.Bits)), big.NewInt(1))
+}
+
+// bounds returns the smallest and the largest value of the mode.
+func (m IntegerMode) bounds() (*big.Int, *big.Int) {
+	if m.Unsigned {
+		return new(big.Int), m.mask()
+	}
+	hi := new(big.Int).Lsh(big.NewInt(1), uint(m.Bits-1))
+	lo := new(big.Int).Neg(hi)
+	return lo, hi.Sub(hi, big.NewInt(1))
+}
+
+// fit brings an exact result into the range of the mode: it wraps around or fails with ErrOverflow.
+func (m IntegerMode) fit(n *big.Int) (*big.Int, error) {
+	lo, hi := m.bounds()
+	if n.Cmp(lo) >= 0 && n.Cmp(hi) <= 0 {
+		return n, nil
+	}
+	if !m.Wrap {
+		return nil, NewCalcError(ErrOverflow, fmt.Sprintf("%s does not fit in %s", n, m))
+	}
+	return m.fromBits(m.toBits(n)), nil
+}
+
+// toBits returns the lowest Bits bits of n in two's complement.
+func (m IntegerMode) toBits(n *big.Int) uint64 {
+	return new(big.Int).And(n, m.mask()).Uint64()
+}
+
+// fromBits reads bits as a number of the mode.
+func (m IntegerMode) fromBits(bits uint64) *big.Int {
+	n := new(big.Int).SetUint64(bits)
+	if !m.Unsigned && m.Bits > 0 && bits>>(m.Bits-1)&1 == 1 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(m.Bits)))
+	}
+	return n
+}
+
+// Integer is a fixed-width integer, the result of an evaluation in integer mode.
+type Integer struct {
+	bits uint64
+	mode IntegerMode
+}
+
+func newInteger(n *big.Int, mode IntegerMode) Integer {
+	return Integer{bits: mode.toBits(n), mode: mode}
+}
+
+// Mode returns the width and signedness of the integer.
+func (i Integer) Mode() IntegerMode {
+	return i.mode
+}
+
+// Big returns the value of the integer.
+func (i Integer) Big() *big.Int {
+	return i.mode.fromBits(i.bits)
+}
+
+// Float64 returns the nearest number to the integer; it is exact up to 2^53.
+func (i Integer) Float64() float64 {
+	f, _ := new(big.Float).SetInt(i.Big()).Float64()
+	return f
+}
+
+// String returns the decimal value of the integer.
+func (i Integer) String() string {
+	return i.Big().String()
+}
+
+// Format returns the integer in base 10, or its bits in base 2, 8 or 16 with a 0b, 0o or 0x prefix.
+// Binary and hexadecimal bits are padded with zeros to the full width.
+func (i Integer) Format(base int) string {
+	var prefix string
+	var width int
+	switch base {
+	case 2:
+		prefix, width = "0b", i.mode.Bits
+	case 8:
+		prefix = "0o"
+	case 16:
+		prefix, width = "0x", i.mode.Bits/4
+	default:
+		return i.String()
+	}
+
+	digits := new(big.Int).SetUint64(i.bits).Text(base)
+	if pad := width - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	return prefix + digits
+}
+
+// EvaluateInteger evaluates a program like Evaluate, in the fixed-width integer arithmetic of Options.Integer.
+// Fractional numbers are rejected with ErrNotInteger. Variables assigned by the program keep their exact value.
+func (e *Environment) EvaluateInteger(expr string) (Integer, error) {
+	mode := e.options.Integer
+	if err := mode.Validate(); err != nil {
+		return Integer{}, err
+	}
+
+	statements, err := parseProgram(expr, e.options)
+	if err != nil {
+		return Integer{}, err
+	}
+
+	if len(statements) == 0 || statements[len(statements)-1].kind == functionStatement {
+		return Integer{}, NewCalcError(ErrInsufficientValues, "no expression to evaluate")
+	}
+
+	vars, ints, funcs := maps.Clone(e.vars), maps.Clone(e.ints), maps.Clone(e.funcs)
+
+	var result *big.Int
+	for _, stmt := range statements {
+		switch stmt.kind {
+		case functionStatement:
+			err = e.define(stmt.name, stmt.params, stmt.body)
+		case assignmentStatement:
+			result, err = e.assignInteger(stmt.name, stmt.body)
+		case expressionStatement:
+			result, err = e.evalInteger(stmt.body, nil)
+		}
+		if err != nil {
+			e.vars, e.ints, e.funcs = vars, ints, funcs
+			return Integer{}, err
+		}
+	}
+
+	return newInteger(result, mode), nil
+}
+
+// IntegerVariables returns a copy of the variables assigned in integer mode.
+func (e *Environment) IntegerVariables() map[string]Integer {
+	return maps.Clone(e.ints)
+}
+
+func (e *Environment) assignInteger(name string, body *node) (*big.Int, error) {
+	if _, ok := constants[name]; ok {
+		return nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%s is a constant", name))
+	}
+
+	v, err := e.evalInteger(body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(e.vars, name)
+	e.ints[name] = newInteger(v, e.options.Integer)
+
+	return v, nil
+}
+
+// intFrame holds the arguments of the user-defined function being evaluated in integer mode and the call depth.
+type intFrame struct {
+	args  map[string]*big.Int
+	depth int
+}
+
+func (e *Environment) evalInteger(n *node, f *intFrame) (*big.Int, error) {
+	mode := e.options.Integer
+
+	switch n.kind {
+	case numberNode:
+		return e.integerLiteral(n.token, false)
+	case stringNode, listNode:
+		return nil, NewCalcError(ErrTypeMismatch, "integer mode has only integers, no strings or lists")
+	case identifierNode:
+		if f != nil {
+			if v, ok := f.args[n.token]; ok {
+				return v, nil
+			}
+		}
+		return e.lookupInteger(n.token)
+	case operatorNode:
+		if isUnaryMinus(n) {
+			// -128 is a literal in int8, although 128 is out of range; -(128) is not.
+			if n.args[0].token == literalMinus && n.args[1].kind == numberNode {
+				return e.integerLiteral(n.args[1].token, true)
+			}
+			v, err := e.evalInteger(n.args[1], f)
+			if err != nil {
+				return nil, err
+			}
+			return applyIntegerUnary(mode, string(Neg), v)
+		}
+		switch tokenType(n.token) {
+		case Neg, Not, BitNot, Factorial, Percent:
+			v, err := e.evalInteger(n.args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			return applyIntegerUnary(mode, n.token, v)
+		case And, Or, Question:
+			return e.evalIntegerLazy(n, f)
+		}
+
+		a, err := e.evalInteger(n.args[0], f)
+		if err != nil {
+			return nil, err
+		}
+		b, err := e.evalInteger(n.args[1], f)
+		if err != nil {
+			return nil, err
+		}
+		return applyIntegerOperator(mode, n.token, a, b)
+	case callNode:
+		if n.token == "if" && e.funcs[n.token] == nil {
+			if err := specialForms["if"].checkArity(n.token, len(n.args)); err != nil {
+				return nil, err
+			}
+			cond, err := e.evalInteger(n.args[0], f)
+			if err != nil {
+				return nil, err
+			}
+			if cond.Sign() != 0 {
+				return e.evalInteger(n.args[1], f)
+			}
+			return e.evalInteger(n.args[2], f)
+		}
+
+		args := make([]*big.Int, len(n.args))
+		for i, arg := range n.args {
+			v, err := e.evalInteger(arg, f)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return e.callInteger(n.token, args, f)
+	}
+
+	return nil, NewErrUnknown()
+}
+
+// integerLiteral reads a number literal, negated if it follows a unary minus. As in Go, every literal is a value,
+// decimal or not, and must be in the range of the mode unless it wraps around: 0xff is 255, which does not fit
+// in int8, and -128 does.
+func (e *Environment) integerLiteral(token string, negative bool) (*big.Int, error) {
+	n, ok := parseIntegerLiteral(token)
+	if !ok {
+		return nil, NewCalcError(ErrNotInteger, token)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return e.options.Integer.fit(n)
+}
+
+// lookupInteger resolves a variable: one assigned in integer mode, then a number variable or a constant,
+// which must have no fractional part.
+func (e *Environment) lookupInteger(name string) (*big.Int, error) {
+	if v, ok := e.ints[name]; ok {
+		return e.options.Integer.fit(v.Big())
+	}
+
+	v, err := e.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	x, ok := v.(Real)
+	if !ok {
+		return nil, NewCalcError(ErrNotInteger, fmt.Sprintf("%s = %s", name, v))
+	}
+	n, err := toInteger(float64(x))
+	if err != nil {
+		return nil, NewCalcError(ErrNotInteger, fmt.Sprintf("%s = %s", name, v))
+	}
+	return e.options.Integer.fit(n)
+}
+
+// evalIntegerLazy is evalLazy for integer mode.
+func (e *Environment) evalIntegerLazy(n *node, f *intFrame) (*big.Int, error) {
+	cond, err := e.evalInteger(n.args[0], f)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenType(n.token) {
+	case And:
+		if cond.Sign() == 0 {
+			return new(big.Int), nil
+		}
+	case Or:
+		if cond.Sign() != 0 {
+			return big.NewInt(1), nil
+		}
+	case Question:
+		if cond.Sign() != 0 {
+			return e.evalInteger(n.args[1], f)
+		}
+		return e.evalInteger(n.args[2], f)
+	}
+
+	v, err := e.evalInteger(n.args[1], f)
+	if err != nil {
+		return nil, err
+	}
+	return integerBoolean(v.Sign() != 0), nil
+}
+
+// callInteger applies a user-defined function or, if there is none with that name, an integer function.
+func (e *Environment) callInteger(name string, args []*big.Int, f *intFrame) (*big.Int, error) {
+	fn, ok := e.funcs[name]
+	if !ok {
+		b, ok := integerModeFunctions[name]
+		if !ok {
+			if _, ok := builtins[name]; ok {
+				return nil, NewCalcError(ErrNotInteger, fmt.Sprintf("function %s is not available in integer mode", name))
+			}
+			return nil, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("function %s", name))
+		}
+		if err := checkArity(name, len(args), b.minArgs, b.maxArgs); err != nil {
+			return nil, err
+		}
+		result, err := b.fn(args)
+		if err != nil {
+			return nil, err
+		}
+		return e.options.Integer.fit(result)
+	}
+
+	if len(args) != len(fn.params) {
+		return nil, NewCalcError(ErrArgumentCount, fmt.Sprintf("%s expects %d argument(s), got %d", name, len(fn.params), len(args)))
+	}
+
+	depth := 1
+	if f != nil {
+		depth = f.depth + 1
+	}
+	if depth > e.options.MaxDepth {
+		return nil, NewCalcError(ErrRecursionLimit, fmt.Sprintf("%s nested deeper than %d calls", name, e.options.MaxDepth))
+	}
+	if err := e.spend(name, 1); err != nil {
+		return nil, err
+	}
+
+	inner := &intFrame{args: make(map[string]*big.Int, len(args)), depth: depth}
+	for i, p := range fn.params {
+		inner.args[p] = args[i]
+	}
+
+	return e.evalInteger(fn.body, inner)
+}
+
+// integerModeFunctions are the functions available in integer mode: the integer functions and a few more.
+var integerModeFunctions map[string]integerFunction
+
+func init() {
+	integerModeFunctions = maps.Clone(integerFunctions)
+	integerModeFunctions["abs"] = unaryInteger(func(n *big.Int) (*big.Int, error) {
+		return new(big.Int).Abs(n), nil
+	})
+	integerModeFunctions["min"] = integerFunction{minArgs: 1, maxArgs: -1, fn: func(args []*big.Int) (*big.Int, error) {
+		m := args[0]
+		for _, v := range args[1:] {
+			if v.Cmp(m) < 0 {
+				m = v
+			}
+		}
+		return m, nil
+	}}
+	integerModeFunctions["max"] = integerFunction{minArgs: 1, maxArgs: -1, fn: func(args []*big.Int) (*big.Int, error) {
+		m := args[0]
+		for _, v := range args[1:] {
+			if v.Cmp(m) > 0 {
+				m = v
+			}
+		}
+		return m, nil
+	}}
+}
+
+func integerBoolean(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+// applyIntegerUnary applies a unary operator in integer mode.
+func applyIntegerUnary(mode IntegerMode, op string, v *big.Int) (*big.Int, error) {
+	switch tokenType(op) {
+	case Neg:
+		return mode.fit(new(big.Int).Neg(v))
+	case Not:
+		return integerBoolean(v.Sign() == 0), nil
+	case BitNot:
+		// Inverting every bit can not overflow: it is -v-1 in two's complement and 2^Bits-1-v unsigned.
+		return mode.fromBits(mode.toBits(new(big.Int).Not(v))), nil
+	case Factorial:
+		result, err := factorial(v)
+		if err != nil {
+			return nil, err
+		}
+		return mode.fit(result)
+	}
+	return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("%s in integer mode", op))
+}
+
+// applyIntegerOperator applies a binary operator in integer mode. The exact result is brought into
+// the range of the mode, so an overflow either wraps around or fails.
+func applyIntegerOperator(mode IntegerMode, op string, a, b *big.Int) (*big.Int, error) {
+	result := new(big.Int)
+	switch tokenType(op) {
+	case Add:
+		result.Add(a, b)
+	case Sub:
+		result.Sub(a, b)
+	case Multi:
+		result.Mul(a, b)
+	case Div, Rem:
+		if b.Sign() == 0 {
+			return nil, NewCalcError(ErrDivisionByZero, "")
+		}
+		if tokenType(op) == Div {
+			result.Quo(a, b)
+		} else {
+			result.Rem(a, b)
+		}
+	case Pow:
+		// Only a function defined outside of integer mode can hold a power.
+		p, err := intPow([]*big.Int{a, b})
+		if err != nil {
+			return nil, err
+		}
+		result = p
+	case BitAnd:
+		result.And(a, b)
+	case BitOr:
+		result.Or(a, b)
+	case Xor:
+		result.Xor(a, b)
+	case ShiftLeft, ShiftRight:
+		if err := checkNonNegative(b); err != nil {
+			return nil, err
+		}
+		// Shifting by the width or more moves every bit out; Rsh keeps the sign of a negative number.
+		shift := uint(mode.Bits)
+		if b.IsUint64() && b.Uint64() < uint64(shift) {
+			shift = uint(b.Uint64())
+		}
+		if tokenType(op) == ShiftRight {
+			result.Rsh(a, shift)
+			break
+		}
+		if shift == uint(mode.Bits) && a.Sign() != 0 && !mode.Wrap {
+			return nil, NewCalcError(ErrOverflow, fmt.Sprintf("%s << %s does not fit in %s", a, b, mode))
+		}
+		result.Lsh(a, shift)
+	case Eq:
+		return integerBoolean(a.Cmp(b) == 0), nil
+	case NotEq:
+		return integerBoolean(a.Cmp(b) != 0), nil
+	case Less:
+		return integerBoolean(a.Cmp(b) < 0), nil
+	case LessEq:
+		return integerBoolean(a.Cmp(b) <= 0), nil
+	case Greater:
+		return integerBoolean(a.Cmp(b) > 0), nil
+	case GreaterEq:
+		return integerBoolean(a.Cmp(b) >= 0), nil
+	default:
+		return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("%s in integer mode", op))
+	}
+
+	return mode.fit(result)
+}
@@ -0,0 +1,168 @@
+package calculator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateInteger(t *testing.T) {
+	int8Wrap := IntegerMode{Bits: 8, Wrap: true}
+	uint8Wrap := IntegerMode{Bits: 8, Unsigned: true, Wrap: true}
+	int64Mode := IntegerMode{Bits: 64}
+	uint64Mode := IntegerMode{Bits: 64, Unsigned: true}
+
+	testCases := []struct {
+		mode  IntegerMode
+		input string
+		want  string
+	}{
+		{int64Mode, "0xff & 0x0f", "15"},
+		{int64Mode, "0b1010 | 0b0101", "15"},
+		{int64Mode, "6 ^ 3", "5"},
+		{int64Mode, "~0", "-1"},
+		{int64Mode, "1 << 10", "1024"},
+		{int64Mode, "-16 >> 2", "-4"},
+		{int64Mode, "-1 >> 100", "-1"},
+		{int64Mode, "7 / 2", "3"},
+		{int64Mode, "-7 / 2", "-3"},
+		{int64Mode, "-7 % 3", "-1"},
+		{int64Mode, "0o17", "15"},
+		{int64Mode, "1 + 2 & 3", "3"},
+		{int64Mode, "x = 0xf0; x & 0x30 == 0x30", "1"},
+		{int64Mode, "9007199254740993 + 2", "9007199254740995"},
+		{int64Mode, "-9223372036854775808", "-9223372036854775808"},
+		{int64Mode, "-0x8000000000000000", "-9223372036854775808"},
+		{int64Mode, "gcd(12, 18) + 5!", "126"},
+		{int64Mode, "max(3, 9, 4) - abs(-2)", "7"},
+		{int64Mode, "f(x) = x < 2 ? x : f(x - 1) + f(x - 2); f(20)", "6765"},
+		{uint64Mode, "0xffffffffffffffff", "18446744073709551615"},
+		{uint64Mode, "~0 >> 63", "1"},
+		{int8Wrap, "127 + 1", "-128"},
+		{int8Wrap, "0xff", "-1"},
+		{int8Wrap, "200", "-56"},
+		{IntegerMode{Bits: 8}, "-128", "-128"},
+		{IntegerMode{Bits: 8}, "-0x80", "-128"},
+		{IntegerMode{Bits: 8}, "-127 - 1", "-128"},
+		{IntegerMode{Bits: 8}, "x = 3; -0x + 1", "1"},
+		{IntegerMode{Bits: 8}, "-5!", "-120"},
+		{int8Wrap, "-128 / -1", "-128"},
+		{int8Wrap, "1 << 8", "0"},
+		{uint8Wrap, "0 - 1", "255"},
+		{uint8Wrap, "~0x0f", "240"},
+		{uint8Wrap, "200 * 2", "144"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Integer: tc.mode}).EvaluateInteger(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateInteger(%q) in %s returned unexpected error: %v", tc.input, tc.mode, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateInteger(%q) in %s = %s, want %s", tc.input, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluateIntegerErrors(t *testing.T) {
+	int8Mode := IntegerMode{Bits: 8}
+	uint32Mode := IntegerMode{Bits: 32, Unsigned: true}
+
+	testCases := []struct {
+		mode    IntegerMode
+		input   string
+		errType ErrorType
+	}{
+		{int8Mode, "127 + 1", ErrOverflow},
+		{int8Mode, "128", ErrOverflow},
+		{int8Mode, "0x1ff", ErrOverflow},
+		{int8Mode, "0xff", ErrOverflow},
+		{int8Mode, "-129", ErrOverflow},
+		{int8Mode, "-(128)", ErrOverflow},
+		{IntegerMode{Bits: 64}, "0xffffffffffffffff", ErrOverflow},
+		{int8Mode, "10%", ErrInsufficientValues},
+		{int8Mode, "-128 / -1", ErrOverflow},
+		{int8Mode, "1 << 8", ErrOverflow},
+		{int8Mode, "1 << 7", ErrOverflow},
+		{int8Mode, "1 << -1", ErrNegativeArgument},
+		{int8Mode, "1.5 + 1", ErrNotInteger},
+		{int8Mode, "pi", ErrNotInteger},
+		{int8Mode, "sqrt(4)", ErrNotInteger},
+		{int8Mode, "1 / 0", ErrDivisionByZero},
+		{int8Mode, "1 % 0", ErrDivisionByZero},
+		{uint32Mode, "0 - 1", ErrOverflow},
+		{uint32Mode, "-1", ErrOverflow},
+		{uint32Mode, "0b102", ErrInvalidCharacter},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Integer: tc.mode}).EvaluateInteger(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateInteger(%q) in %s error = %v, want error type %v", tc.input, tc.mode, err, tc.errType)
+		}
+	}
+
+	// The remainder operator is spelled as written.
+	if _, err := NewEnvironment(Options{Integer: int8Mode}).EvaluateInteger("10%"); err == nil || !strings.HasSuffix(err.Error(), ": %") {
+		t.Errorf(`EvaluateInteger("10%%") error = %v, want one naming %%`, err)
+	}
+
+	if _, err := NewEnvironment(Options{}).EvaluateInteger("1"); err == nil {
+		t.Error("expected an error with integer mode off")
+	}
+	if _, err := NewEnvironment(Options{Integer: IntegerMode{Bits: 12}}).EvaluateInteger("1"); err == nil {
+		t.Error("expected an error for an unsupported width")
+	}
+}
+
+func TestIntegerFormat(t *testing.T) {
+	testCases := []struct {
+		mode  IntegerMode
+		input string
+		base  int
+		want  string
+	}{
+		{IntegerMode{Bits: 8}, "-1", 16, "0xff"},
+		{IntegerMode{Bits: 8}, "-1", 10, "-1"},
+		{IntegerMode{Bits: 16}, "10", 2, "0b0000000000001010"},
+		{IntegerMode{Bits: 32}, "255", 16, "0x000000ff"},
+		{IntegerMode{Bits: 32}, "8", 8, "0o10"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Integer: tc.mode}).EvaluateInteger(tc.input)
+		if err != nil {
+			t.Fatalf("EvaluateInteger(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if s := got.Format(tc.base); s != tc.want {
+			t.Errorf("Format(%d) of %q in %s = %s, want %s", tc.base, tc.input, tc.mode, s, tc.want)
+		}
+	}
+}
+
+func TestIntegerModeVariables(t *testing.T) {
+	env := NewEnvironment(Options{Integer: IntegerMode{Bits: 64}})
+	env.Set("x", 3)
+
+	if _, err := env.EvaluateInteger("big = 9007199254740993; y = x << 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ints := env.IntegerVariables()
+	if ints["big"].String() != "9007199254740993" || ints["y"].String() != "12" {
+		t.Errorf("unexpected integer variables %v", ints)
+	}
+
+	env.SetOptions(Options{})
+	if got, err := env.Evaluate("y / 8"); err != nil || got != 1.5 {
+		t.Errorf("Evaluate(\"y / 8\") = %v, %v, want 1.5", got, err)
+	}
+	if got, err := env.Evaluate("2 ^ 3"); err != nil || got != 8 {
+		t.Errorf("Evaluate(\"2 ^ 3\") = %v, %v, want 8", got, err)
+	}
+	if _, err := env.Evaluate("6 & 3"); err == nil {
+		t.Error("expected bitwise operators to fail outside of integer mode")
+	}
+}
@@ -0,0 +1,251 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// maxIntegerBits bounds the intermediate results of the integer functions: a longer integer
+// can not be represented as a float64 anyway.
+const maxIntegerBits = 1024
+
+// maxFactorial and maxFibonacci are the largest arguments whose results still fit in a float64.
+const (
+	maxFactorial = 170
+	maxFibonacci = 1476
+)
+
+// integerFunction is a function of integers. It is available both in the usual evaluation,
+// where its arguments must have no fractional part, and in integer mode.
+type integerFunction struct {
+	minArgs int
+	maxArgs int
+	fn      func(args []*big.Int) (*big.Int, error)
+}
+
+func unaryInteger(fn func(n *big.Int) (*big.Int, error)) integerFunction {
+	return integerFunction{minArgs: 1, maxArgs: 1, fn: func(args []*big.Int) (*big.Int, error) { return fn(args[0]) }}
+}
+
+func binaryInteger(fn func(a, b *big.Int) (*big.Int, error)) integerFunction {
+	return integerFunction{minArgs: 2, maxArgs: 2, fn: func(args []*big.Int) (*big.Int, error) { return fn(args[0], args[1]) }}
+}
+
+// integerFunctions are the combinatorics and number theory functions.
+var integerFunctions = map[string]integerFunction{
+	"nCr":     binaryInteger(choose),
+	"nPr":     binaryInteger(permutations),
+	"gcd":     {minArgs: 1, maxArgs: -1, fn: gcd},
+	"lcm":     {minArgs: 1, maxArgs: -1, fn: lcm},
+	"isprime": unaryInteger(isPrime),
+	"fib":     unaryInteger(fibonacci),
+	// pow(b, n) is the exact integer power, pow(b, n, m) the power modulo m.
+	"pow": {minArgs: 2, maxArgs: 3, fn: intPow},
+}
+
+func init() {
+	for name, f := range integerFunctions {
+		builtins[name] = f.builtin()
+	}
+}
+
+// builtin adapts the function to numbers: the arguments are converted into integers and the result back.
+func (f integerFunction) builtin() builtin {
+	return builtin{minArgs: f.minArgs, maxArgs: f.maxArgs, fn: func(args []float64) (float64, error) {
+		ints, err := toIntegers(args)
+		if err != nil {
+			return 0, err
+		}
+		return fromInteger(f.fn(ints))
+	}}
+}
+
+// toInteger converts a number without a fractional part into an integer.
+func toInteger(x float64) (*big.Int, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) || x != math.Trunc(x) {
+		return nil, NewCalcError(ErrNotInteger, fmt.Sprintf("%g", x))
+	}
+	n, _ := new(big.Float).SetFloat64(x).Int(nil)
+	return n, nil
+}
+
+func toIntegers(args []float64) ([]*big.Int, error) {
+	ints := make([]*big.Int, len(args))
+	for i, a := range args {
+		n, err := toInteger(a)
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+// fromInteger converts the result of an integer function back into a number.
+func fromInteger(n *big.Int, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if err := checkIntegerSize(n); err != nil {
+		return 0, err
+	}
+
+	f, _ := new(big.Float).SetInt(n).Float64()
+	if f > 1e308 || f < -1e308 {
+		return 0, NewCalcError(ErrTooLargeNumber, new(big.Float).SetInt(n).Text('g', 6))
+	}
+	return f, nil
+}
+
+func checkIntegerSize(n *big.Int) error {
+	if n.BitLen() > maxIntegerBits {
+		return NewCalcError(ErrTooLargeNumber, fmt.Sprintf("integer of %d bits", n.BitLen()))
+	}
+	return nil
+}
+
+func checkNonNegative(ints ...*big.Int) error {
+	for _, n := range ints {
+		if n.Sign() < 0 {
+			return NewCalcError(ErrNegativeArgument, n.String())
+		}
+	}
+	return nil
+}
+
+// factorial returns n! for a non-negative integer n.
+func factorial(n *big.Int) (*big.Int, error) {
+	if err := checkNonNegative(n); err != nil {
+		return nil, err
+	}
+	if n.Cmp(big.NewInt(maxFactorial)) > 0 {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%s!", n))
+	}
+	return new(big.Int).MulRange(1, n.Int64()), nil
+}
+
+// choose returns the number of ways to choose k items out of n, ignoring the order.
+func choose(n, k *big.Int) (*big.Int, error) {
+	if err := checkNonNegative(n, k); err != nil {
+		return nil, err
+	}
+	if k.Cmp(n) > 0 {
+		return new(big.Int), nil
+	}
+	if rest := new(big.Int).Sub(n, k); rest.Cmp(k) < 0 {
+		k = rest
+	}
+
+	// C(n, i) = C(n, i-1) * (n-i+1) / i is exact at every step, and C(n, i) >= 2^i while i <= n/2,
+	// so the size check stops a huge k after at most maxIntegerBits steps.
+	result := big.NewInt(1)
+	factor := new(big.Int)
+	for i := big.NewInt(1); i.Cmp(k) <= 0; i.Add(i, big.NewInt(1)) {
+		factor.Sub(n, i).Add(factor, big.NewInt(1))
+		result.Mul(result, factor).Quo(result, i)
+		if err := checkIntegerSize(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// permutations returns the number of ordered arrangements of k items out of n.
+func permutations(n, k *big.Int) (*big.Int, error) {
+	if err := checkNonNegative(n, k); err != nil {
+		return nil, err
+	}
+	if k.Cmp(n) > 0 {
+		return new(big.Int), nil
+	}
+
+	result := big.NewInt(1)
+	factor := new(big.Int).Set(n)
+	for i := new(big.Int); i.Cmp(k) < 0; i.Add(i, big.NewInt(1)) {
+		result.Mul(result, factor)
+		if err := checkIntegerSize(result); err != nil {
+			return nil, err
+		}
+		factor.Sub(factor, big.NewInt(1))
+	}
+	return result, nil
+}
+
+// gcd returns the greatest common divisor of its arguments, always non-negative.
+func gcd(ints []*big.Int) (*big.Int, error) {
+	result := new(big.Int).Abs(ints[0])
+	for _, n := range ints[1:] {
+		result.GCD(nil, nil, result, n)
+	}
+	return result, nil
+}
+
+// lcm returns the least common multiple of its arguments, always non-negative; it is 0 if any argument is 0.
+func lcm(ints []*big.Int) (*big.Int, error) {
+	result := new(big.Int).Abs(ints[0])
+	for _, n := range ints[1:] {
+		if result.Sign() == 0 || n.Sign() == 0 {
+			return new(big.Int), nil
+		}
+		d := new(big.Int).GCD(nil, nil, result, n)
+		result.Mul(result, new(big.Int).Abs(n)).Quo(result, d)
+		if err := checkIntegerSize(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// isPrime returns 1 for a prime number and 0 otherwise.
+func isPrime(n *big.Int) (*big.Int, error) {
+	if n.Sign() <= 0 {
+		return new(big.Int), nil
+	}
+	// ProbablyPrime(0) runs the Baillie-PSW test, which has no known errors and is proven exact below 2^64.
+	if n.ProbablyPrime(0) {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int), nil
+}
+
+// fibonacci returns the n-th Fibonacci number: fib(0) = 0, fib(1) = 1.
+func fibonacci(n *big.Int) (*big.Int, error) {
+	if err := checkNonNegative(n); err != nil {
+		return nil, err
+	}
+	if n.Cmp(big.NewInt(maxFibonacci)) > 0 {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("fib(%s)", n))
+	}
+
+	a, b := new(big.Int), big.NewInt(1)
+	for i := int64(0); i < n.Int64(); i++ {
+		a.Add(a, b)
+		a, b = b, a
+	}
+	return a, nil
+}
+
+// intPow returns b^n for integers b and n >= 0, or b^n mod m with the result in [0, |m|).
+func intPow(ints []*big.Int) (*big.Int, error) {
+	base, exp := ints[0], ints[1]
+	if err := checkNonNegative(exp); err != nil {
+		return nil, err
+	}
+
+	if len(ints) == 3 {
+		mod := new(big.Int).Abs(ints[2])
+		if mod.Sign() == 0 {
+			return nil, NewCalcError(ErrDivisionByZero, "")
+		}
+		result := new(big.Int).Exp(base, exp, mod)
+		return result.Mod(result, mod), nil
+	}
+
+	// Only 0, 1 and -1 keep a small size with a large exponent.
+	if new(big.Int).Abs(base).Cmp(big.NewInt(1)) > 0 &&
+		(exp.Cmp(big.NewInt(maxIntegerBits)) > 0 || exp.Int64()*int64(base.BitLen()-1) > maxIntegerBits) {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("pow(%s, %s)", base, exp))
+	}
+	return new(big.Int).Exp(base, exp, nil), nil
+}
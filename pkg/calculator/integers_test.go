@@ -0,0 +1,102 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIntegerFunctions(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"0!", 1},
+		{"5!", 120},
+		{"3!!", 720},
+		{"-3!", -6},
+		{"2^3!", 64},
+		{"(1+2)!", 6},
+		{"x = 4; x! / 2", 12},
+		{"5! == 120", 1},
+		{"!0 + 1", 2},
+		{"170! > 7 * 10^306", 1},
+		{"nCr(5, 2)", 10},
+		{"nCr(5, 0)", 1},
+		{"nCr(5, 7)", 0},
+		{"nCr(1000, 998)", 499500},
+		{"nCr(60, 30)", 118264581564861424},
+		{"nPr(5, 2)", 20},
+		{"nPr(5, 5)", 120},
+		{"nPr(5, 6)", 0},
+		{"gcd(12, 18)", 6},
+		{"gcd(-12, 18, 8)", 2},
+		{"gcd(0, 7)", 7},
+		{"lcm(4, 6)", 12},
+		{"lcm(4, -6, 10)", 60},
+		{"lcm(0, 5)", 0},
+		{"isprime(2)", 1},
+		{"isprime(97)", 1},
+		{"isprime(91)", 0},
+		{"isprime(1)", 0},
+		{"isprime(-7)", 0},
+		{"isprime(9007199254740881)", 1},
+		{"fib(0)", 0},
+		{"fib(1)", 1},
+		{"fib(10)", 55},
+		{"fib(90)", 2880067194370816000},
+		{"pow(2, 10)", 1024},
+		{"pow(-3, 3)", -27},
+		{"pow(1, 10^300)", 1},
+		{"pow(2, 10, 1000)", 24},
+		{"pow(-2, 3, 5)", 2},
+		{"pow(4, 13, 497)", 445},
+		{"pow(123456789, 10^18, 1000000007)", 228100152},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestIntegerFunctionsErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"2.5!", ErrNotInteger},
+		{"(-1)!", ErrNegativeArgument},
+		{"171!", ErrTooLargeNumber},
+		{"nCr(5.5, 2)", ErrNotInteger},
+		{"nCr(-5, 2)", ErrNegativeArgument},
+		{"nCr(5, -2)", ErrNegativeArgument},
+		{"nCr(10^15, 5 * 10^14)", ErrTooLargeNumber},
+		{"nPr(10^6, 10^6)", ErrTooLargeNumber},
+		{"gcd(1.5, 3)", ErrNotInteger},
+		{"lcm(10^300, 10^300 + 10^285)", ErrTooLargeNumber},
+		{"isprime(sqrt(2))", ErrNotInteger},
+		{"fib(-1)", ErrNegativeArgument},
+		{"fib(1477)", ErrTooLargeNumber},
+		{"pow(2, -1)", ErrNegativeArgument},
+		{"pow(2, 0.5)", ErrNotInteger},
+		{"pow(2, 1024)", ErrTooLargeNumber},
+		{"pow(3, 10^18)", ErrTooLargeNumber},
+		{"pow(2, 10, 0)", ErrDivisionByZero},
+		{"pow(2)", ErrArgumentCount},
+		{"nCr(5)", ErrArgumentCount},
+		{"!", ErrInsufficientValues},
+	}
+
+	for _, tc := range testCases {
+		_, err := Evaluate(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Evaluate(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
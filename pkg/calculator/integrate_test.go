@@ -0,0 +1,153 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestIntegrate(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"integrate(x^2, x, 0, 1)", 1.0 / 3},
+		{"integrate(x, x, 1, 0)", -0.5},
+		{"integrate(x, x, 2, 2)", 0},
+		{"integrate(sin(x), x, 0, pi)", 2},
+		{"integrate(exp(-x^2), x, -10, 10)", math.Sqrt(math.Pi)},
+		{"integrate(1/sqrt(x), x, 0, 1)", 2},
+		{"integrate(abs(x), x, -1, 2)", 2.5},
+		{"integrate(ln(x), x, 1, e)", 1},
+		{"integrate(x^2, x, 0, 1, 0.001)", 1.0 / 3},
+		{"a = 2; integrate(a*t, t, 0, 3)", 9},
+		{"f(b) = integrate(x, x, 0, b); f(4)", 8},
+		{"integrate(integrate(x*y, y, 0, 1), x, 0, 2)", 1},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-8 {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestIntegrals(t *testing.T) {
+	env := NewEnvironment(Options{})
+	if _, err := env.Evaluate("integrate(1/sqrt(x), x, 0, 1) + integrate(x, x, 0, 1)"); err != nil {
+		t.Fatalf("Evaluate returned unexpected error: %v", err)
+	}
+
+	integrals := env.Integrals()
+	if len(integrals) != 2 {
+		t.Fatalf("Integrals() = %v, want 2 integrals", integrals)
+	}
+	first := integrals[0]
+	if first.Expression != "integrate(1/sqrt(x), x, 0, 1)" || first.Evaluations <= 15 || first.Evaluations%15 != 0 {
+		t.Errorf("Integrals()[0] = %+v", first)
+	}
+	if actual := math.Abs(first.Value - 2); first.Error <= 0 || first.Error > 2e-10 || actual > first.Error {
+		t.Errorf("Integrals()[0] = %+v, the error estimate does not bound the error %g", first, actual)
+	}
+
+	if _, err := env.Evaluate("1 + 1"); err != nil {
+		t.Fatalf("Evaluate returned unexpected error: %v", err)
+	}
+	if integrals := env.Integrals(); len(integrals) != 0 {
+		t.Errorf("Integrals() = %v after an evaluation without integrals", integrals)
+	}
+}
+
+func TestSeries(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"series(i^2, i, 1, 10)", "385"},
+		{"series(i, i, 5, 4)", "0"},
+		{"series(i, i, -2, 2)", "0"},
+		{"product(i, i, 1, 5)", "120"},
+		{"product(i, i, 1, 0)", "1"},
+		{"series(1/2^k, k, 0, 10)", "1.9990234375"},
+		{"v = 1/1.05; series(100 * v^t, t, 1, 3)", "272.3248029370478"},
+		{"series(k * product(j, j, 1, k), k, 1, 3)", "23"},
+		{"series(1 km, i, 1, 3)", "3 km"},
+		{"sum(1, 2, 3, 4)", "10"},
+		{"x = 1; y = 2; z = 3; w = 4; sum(x, y, z, w)", "10"},
+		{"f(a, b) = sum(a, b, a, b); f(1, 2)", "6"},
+		{"sum([1, 2], 3)", "6"},
+		{"i = 10; series(i, i, 1, 3) + i", "16"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestCalculusErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  ErrorType
+	}{
+		{"series(i, i, 1, 2000000)", ErrEvaluationLimit},
+		{"series(series(j, j, 1, 1000), i, 1, 1000)", ErrEvaluationLimit},
+		{"integrate(sin(1/x), x, 0.0001, 1)", ErrNoConvergence},
+		{"integrate(sqrt(x), x, -1, 1)", ErrDomain},
+		{"integrate(1/x, x, -1, 1)", ErrDivisionByZero},
+		{"integrate(x, 2, 0, 1)", ErrInvalidIdentifier},
+		{"integrate(x, pi, 0, 1)", ErrInvalidIdentifier},
+		{"integrate(x, x, 0)", ErrArgumentCount},
+		{"integrate(x, x, 0, 1, 0)", ErrDomain},
+		{"series(i, i, 1, 2.5)", ErrNotInteger},
+		{"product(i, 2, 1, 3)", ErrInvalidIdentifier},
+		{"product(i, i, 1)", ErrArgumentCount},
+		{"series(i, j, 1, 3)", ErrUnknownIdentifier},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+			t.Errorf("EvaluateValue(%q) error = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+
+	env := NewEnvironment(Options{MaxEvaluations: 100})
+	if _, err := env.Evaluate("integrate(1/sqrt(x), x, 0, 1)"); err == nil {
+		t.Error("integrate exceeded an evaluation budget of 100")
+	}
+	if _, err := env.Evaluate("series(i, i, 1, 100)"); err != nil {
+		t.Errorf("the budget was not reset for the next evaluation: %v", err)
+	}
+}
+
+func TestValidateCalculus(t *testing.T) {
+	for _, expr := range []string{"integrate(t^2, t, 0, x)", "series(t^k, k, 0, 10)", "product(1 + t, k, 1, 3)", "sum(t, 2, 3)"} {
+		if err := Validate(expr, "x", "t"); err != nil {
+			t.Errorf("Validate(%q) returned unexpected error: %v", expr, err)
+		}
+	}
+	if err := Validate("series(k, k, 1, 3) + k"); err == nil {
+		t.Error("Validate accepted a variable outside of series")
+	}
+
+	x, err := Parse("integrate(t^k, t, 0, x) + series(k, k, 1, n)")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	if got := x.Variables(); len(got) != 3 || got[0] != "k" || got[1] != "n" || got[2] != "x" {
+		t.Errorf("Variables() = %v, want [k n x]", got)
+	}
+}
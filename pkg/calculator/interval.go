@@ -0,0 +1,580 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Interval is a closed interval of real numbers, the value of a number in interval mode. Every operation
+// rounds its bounds outwards, so the interval is guaranteed to hold the exact result for any values
+// within the bounds of the operands. A bound can be infinite, after a division by an interval that contains 0.
+type Interval struct {
+	lo, hi float64
+}
+
+// NewInterval returns the interval [lo, hi].
+func NewInterval(lo, hi float64) (Interval, error) {
+	if math.IsNaN(lo) || math.IsNaN(hi) || lo > hi || math.IsInf(lo, 1) || math.IsInf(hi, -1) {
+		return Interval{}, NewCalcError(ErrDomain, fmt.Sprintf("interval [%g, %g]", lo, hi))
+	}
+	return Interval{lo: lo, hi: hi}, nil
+}
+
+// Lower returns the lower bound.
+func (x Interval) Lower() float64 {
+	return x.lo
+}
+
+// Upper returns the upper bound.
+func (x Interval) Upper() float64 {
+	return x.hi
+}
+
+// String formats the interval as [1.9, 2.1], with the bounds exact.
+func (x Interval) String() string {
+	return "[" + formatFloat(x.lo) + ", " + formatFloat(x.hi) + "]"
+}
+
+func isInterval(v Value) bool {
+	_, ok := v.(Interval)
+	return ok
+}
+
+func point(x float64) Interval {
+	return Interval{lo: x, hi: x}
+}
+
+// entire is the interval of all real numbers.
+var entire = Interval{lo: math.Inf(-1), hi: math.Inf(1)}
+
+func (x Interval) contains(y float64) bool {
+	return x.lo <= y && y <= x.hi
+}
+
+// toInterval returns a number as an interval, a real number as the interval of just that number.
+func toInterval(v Value) (Interval, error) {
+	switch v := v.(type) {
+	case Interval:
+		return v, nil
+	case Real:
+		if math.IsNaN(float64(v)) {
+			return Interval{}, NewCalcError(ErrDomain, "")
+		}
+		return point(float64(v)), nil
+	case Integer:
+		return point(v.Float64()), nil
+	}
+	return Interval{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where an interval is expected", typeName(v), v))
+}
+
+// literalInterval is the interval of a number literal: the float64 it is read as, if that is exact,
+// otherwise the two float64 around it, so 0.1 is [0.09999999999999999, 0.1]. A literal too small to read exactly,
+// 1e-99999, is between 0 and the smallest float64.
+func literalInterval(n *node) Interval {
+	exact, ok := literalValue(n.token)
+	if !ok && n.value == 0 {
+		return Interval{lo: 0, hi: math.SmallestNonzeroFloat64}
+	}
+	if !ok || math.IsInf(n.value, 0) {
+		return point(n.value)
+	}
+	switch exact.Cmp(new(big.Rat).SetFloat64(n.value)) {
+	case -1:
+		return Interval{lo: down(n.value), hi: n.value}
+	case 1:
+		return Interval{lo: n.value, hi: up(n.value)}
+	}
+	return point(n.value)
+}
+
+// constantInterval encloses a constant such as pi, which its float64 only approximates.
+func constantInterval(x float64) Interval {
+	return Interval{lo: down(x), hi: up(x)}
+}
+
+// Directed rounding. An operation on float64 rounds to the nearest number; its bounds step to the next number
+// away from the result, unless the exact error of the operation shows that the result is already on that side.
+// The error is exact unless the result is tiny, where the rounding always steps.
+const tinyResult = 0x1p-968
+
+func down(x float64) float64 {
+	return math.Nextafter(x, math.Inf(-1))
+}
+
+func up(x float64) float64 {
+	return math.Nextafter(x, math.Inf(1))
+}
+
+// rounded returns the bound of a result r of finite operands whose exact value is r + err (err only by its sign),
+// stepping down for a lower bound and up for an upper one. An overflow of a lower bound to +Inf is the largest
+// float64, of an upper bound to -Inf the smallest.
+func rounded(r, err float64, upper bool) float64 {
+	switch {
+	case math.IsInf(r, 1) && !upper:
+		return math.MaxFloat64
+	case math.IsInf(r, -1) && upper:
+		return -math.MaxFloat64
+	case math.IsInf(r, 0):
+		return r
+	case math.Abs(r) < tinyResult:
+		if upper {
+			return up(r)
+		}
+		return down(r)
+	case upper && err > 0:
+		return up(r)
+	case !upper && err < 0:
+		return down(r)
+	}
+	return r
+}
+
+func addBound(a, b float64, upper bool) float64 {
+	s := a + b
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return s
+	}
+	// Knuth's TwoSum: a + b = s + err exactly.
+	bb := s - a
+	err := (a - (s - bb)) + (b - bb)
+	if s == 0 && err == 0 {
+		return 0
+	}
+	return rounded(s, err, upper)
+}
+
+func mulBound(a, b float64, upper bool) float64 {
+	if a == 0 || b == 0 {
+		// 0 * Inf is 0: the infinite bound stands for large finite numbers.
+		return 0
+	}
+	p := a * b
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return p
+	}
+	return rounded(p, math.FMA(a, b, -p), upper)
+}
+
+func divBound(a, b float64, upper bool) float64 {
+	q := a / b
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return q
+	}
+	// a = q*b + r exactly, so a/b = q + r/b.
+	r := math.FMA(-q, b, a)
+	if math.Signbit(b) {
+		r = -r
+	}
+	return rounded(q, r, upper)
+}
+
+func sqrtBound(x float64, upper bool) float64 {
+	s := math.Sqrt(x)
+	if math.IsInf(x, 0) || s == 0 {
+		return s
+	}
+	return rounded(s, math.FMA(-s, s, x), upper)
+}
+
+// hull returns the smallest interval that holds all the bounds, ignoring NaN from Inf/Inf,
+// whose limit the other bounds already cover.
+func hull(los, his []float64) Interval {
+	x := Interval{lo: math.Inf(1), hi: math.Inf(-1)}
+	for _, lo := range los {
+		if !math.IsNaN(lo) {
+			x.lo = math.Min(x.lo, lo)
+		}
+	}
+	for _, hi := range his {
+		if !math.IsNaN(hi) {
+			x.hi = math.Max(x.hi, hi)
+		}
+	}
+	return x
+}
+
+func (x Interval) neg() Interval {
+	return Interval{lo: -x.hi, hi: -x.lo}
+}
+
+func (x Interval) add(y Interval) Interval {
+	return Interval{lo: addBound(x.lo, y.lo, false), hi: addBound(x.hi, y.hi, true)}
+}
+
+func (x Interval) sub(y Interval) Interval {
+	return x.add(y.neg())
+}
+
+func (x Interval) mul(y Interval) Interval {
+	var los, his []float64
+	for _, a := range []float64{x.lo, x.hi} {
+		for _, b := range []float64{y.lo, y.hi} {
+			los = append(los, mulBound(a, b, false))
+			his = append(his, mulBound(a, b, true))
+		}
+	}
+	return hull(los, his)
+}
+
+// div divides by an interval. If it contains 0 at a bound, the quotient is unbounded on one side:
+// 1/[0, 2] is [0.5, Inf]. If it contains 0 inside, the quotient is made of two unbounded pieces, and the interval
+// holding both is the entire real line, as is any quotient of two intervals that both contain 0.
+func (x Interval) div(y Interval) (Interval, error) {
+	switch {
+	case y.lo == 0 && y.hi == 0:
+		return Interval{}, NewCalcError(ErrDivisionByZero, "")
+	case !y.contains(0):
+		var los, his []float64
+		for _, a := range []float64{x.lo, x.hi} {
+			for _, b := range []float64{y.lo, y.hi} {
+				los = append(los, divBound(a, b, false))
+				his = append(his, divBound(a, b, true))
+			}
+		}
+		return hull(los, his), nil
+	case x.contains(0) || (y.lo < 0 && y.hi > 0):
+		return entire, nil
+	case y.lo == 0 && x.hi < 0:
+		return Interval{lo: math.Inf(-1), hi: divBound(x.hi, y.hi, true)}, nil
+	case y.lo == 0:
+		return Interval{lo: divBound(x.lo, y.hi, false), hi: math.Inf(1)}, nil
+	case x.hi < 0:
+		return Interval{lo: divBound(x.hi, y.lo, false), hi: math.Inf(1)}, nil
+	}
+	return Interval{lo: math.Inf(-1), hi: divBound(x.lo, y.lo, true)}, nil
+}
+
+// mig and mag are the smallest and the largest absolute value in the interval.
+func (x Interval) mig() float64 {
+	if x.contains(0) {
+		return 0
+	}
+	return math.Min(math.Abs(x.lo), math.Abs(x.hi))
+}
+
+func (x Interval) mag() float64 {
+	return math.Max(math.Abs(x.lo), math.Abs(x.hi))
+}
+
+// powBound raises a non-negative number to a positive integer power by squaring.
+func powBound(a float64, n int64, upper bool) float64 {
+	result := 1.0
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = mulBound(result, a, upper)
+		}
+		a = mulBound(a, a, upper)
+	}
+	return result
+}
+
+// pow raises an interval to a power: an integer power of any interval, a negative one by dividing 1 by the positive
+// one, and any other power of a non-negative interval as exp(y*ln(x)).
+func (x Interval) pow(y Interval) (Interval, error) {
+	if y.lo == y.hi && y.lo == math.Trunc(y.lo) && math.Abs(y.lo) <= maxExactInteger {
+		n := int64(y.lo)
+		switch {
+		case n == 0:
+			return point(1), nil
+		case n < 0:
+			p, err := x.pow(point(-y.lo))
+			if err != nil {
+				return Interval{}, err
+			}
+			return point(1).div(p)
+		case n%2 == 0:
+			return Interval{lo: powBound(x.mig(), n, false), hi: powBound(x.mag(), n, true)}, nil
+		}
+		// An odd power is increasing: a negative bound is the negated power of its absolute value.
+		odd := func(a float64, upper bool) float64 {
+			if a < 0 {
+				return -powBound(-a, n, !upper)
+			}
+			return powBound(a, n, upper)
+		}
+		return Interval{lo: odd(x.lo, false), hi: odd(x.hi, true)}, nil
+	}
+
+	switch {
+	case x.lo < 0:
+		return Interval{}, NewCalcError(ErrDomain, fmt.Sprintf("%s^%s, a fractional power of a negative number", x, y))
+	case x.hi == 0 && y.lo < 0:
+		return Interval{}, NewCalcError(ErrDivisionByZero, fmt.Sprintf("%s^%s", x, y))
+	case x.hi == 0 && y.lo == 0:
+		// 0^0 is 1 and 0^y is 0 for any y > 0.
+		return Interval{lo: 0, hi: 1}, nil
+	case x.hi == 0:
+		return point(0), nil
+	}
+	ln, err := callInterval("ln", []Interval{x})
+	if err != nil {
+		return Interval{}, err
+	}
+	p, err := callInterval("exp", []Interval{y.mul(ln)})
+	if err != nil {
+		return Interval{}, err
+	}
+	// The outward rounding of exp(-Inf) must not make the power negative.
+	p.lo = math.Max(p.lo, 0)
+	return p, nil
+}
+
+// numericalInIntervalMode rejects a numerical method in interval mode: its result is an estimate, which
+// an interval could only pretend to bound.
+func numericalInIntervalMode(name string) error {
+	return NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s is not available in interval mode, its result has no guaranteed bounds", name))
+}
+
+// compareIntervals decides a comparison for every pair of values within the bounds; a comparison that holds for some
+// of them but not for others, such as [1, 3] < 2, fails.
+func compareIntervals(op string, x, y Interval) (Value, error) {
+	var always, never bool
+	switch tokenType(op) {
+	case Eq, NotEq:
+		always = x.lo == x.hi && y.lo == y.hi && x.lo == y.lo
+		never = x.hi < y.lo || y.hi < x.lo
+		if tokenType(op) == NotEq {
+			always, never = never, always
+		}
+	case Less:
+		always, never = x.hi < y.lo, x.lo >= y.hi
+	case LessEq:
+		always, never = x.hi <= y.lo, x.lo > y.hi
+	case Greater:
+		always, never = x.lo > y.hi, x.hi <= y.lo
+	case GreaterEq:
+		always, never = x.lo >= y.hi, x.hi < y.lo
+	default:
+		return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("%s on intervals", op))
+	}
+
+	switch {
+	case always:
+		return Real(1), nil
+	case never:
+		return Real(0), nil
+	}
+	return nil, NewCalcError(ErrDomain, fmt.Sprintf("%s %s %s depends on the values within the bounds", x, operatorSpelling(op), y))
+}
+
+// applyIntervalOperator applies a binary operator to intervals or real numbers.
+func applyIntervalOperator(op string, a, b Value) (Value, error) {
+	x, err := toInterval(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toInterval(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenType(op) {
+	case Add:
+		return x.add(y), nil
+	case Sub:
+		return x.sub(y), nil
+	case Multi:
+		return x.mul(y), nil
+	case Div:
+		return intervalValue(x.div(y))
+	case Pow:
+		return intervalValue(x.pow(y))
+	case PlusMinus:
+		if y.lo < 0 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("%s ± %s, a negative bound", x, y))
+		}
+		return Interval{lo: addBound(x.lo, -y.hi, false), hi: addBound(x.hi, y.hi, true)}, nil
+	}
+	return compareIntervals(op, x, y)
+}
+
+func intervalValue(x Interval, err error) (Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// monotone is an interval function increasing or decreasing on its domain [min, max]. Unless it is exact,
+// its bounds are widened by two units in the last place for the error of the math functions; a result of
+// 0 or 1 at 0 or 1, like exp(0) or ln(1), is exact.
+type monotone struct {
+	fn         func(float64) float64
+	min, max   float64
+	decreasing bool
+	exact      bool
+}
+
+func (m monotone) apply(name string, x Interval) (Interval, error) {
+	if x.lo < m.min || x.hi > m.max {
+		return Interval{}, NewCalcError(ErrDomain, fmt.Sprintf("%s(%s)", name, x))
+	}
+	lo, hi := m.bound(x.lo, false), m.bound(x.hi, true)
+	if m.decreasing {
+		lo, hi = m.bound(x.hi, false), m.bound(x.lo, true)
+	}
+	return Interval{lo: lo, hi: hi}, nil
+}
+
+func (m monotone) bound(x float64, upper bool) float64 {
+	y := m.fn(x)
+	if m.exact || math.IsInf(y, 0) || (x == 0 || x == 1) && (y == 0 || y == 1) {
+		return y
+	}
+	if upper {
+		return up(up(y))
+	}
+	return down(down(y))
+}
+
+func increasing(fn func(float64) float64, min, max float64) monotone {
+	return monotone{fn: fn, min: min, max: max}
+}
+
+var inf = math.Inf(1)
+
+var monotoneFunctions = map[string]monotone{
+	"exp":   increasing(math.Exp, -inf, inf),
+	"ln":    increasing(math.Log, 0, inf),
+	"log":   increasing(math.Log10, 0, inf),
+	"cbrt":  increasing(math.Cbrt, -inf, inf),
+	"asin":  increasing(math.Asin, -1, 1),
+	"acos":  {fn: math.Acos, min: -1, max: 1, decreasing: true},
+	"atan":  increasing(math.Atan, -inf, inf),
+	"sinh":  increasing(math.Sinh, -inf, inf),
+	"tanh":  increasing(math.Tanh, -inf, inf),
+	"floor": {fn: math.Floor, min: -inf, max: inf, exact: true},
+	"ceil":  {fn: math.Ceil, min: -inf, max: inf, exact: true},
+	"round": {fn: math.Round, min: -inf, max: inf, exact: true},
+	"trunc": {fn: math.Trunc, min: -inf, max: inf, exact: true},
+}
+
+// containsPeriodic reports whether the interval may hold a point offset + k*period for an integer k;
+// when the rounding of the test is in doubt, it does.
+func containsPeriodic(x Interval, offset, period float64) bool {
+	if x.hi-x.lo >= period || math.IsInf(x.lo, 0) || math.IsInf(x.hi, 0) {
+		return true
+	}
+	const slack = 1e-9
+	return math.Floor((x.hi-offset)/period+slack) >= math.Ceil((x.lo-offset)/period-slack)
+}
+
+// periodic is sin or cos: the values at the bounds, and 1 or -1 where the interval holds a maximum or a minimum.
+func periodic(fn func(float64) float64, maxAt, minAt float64, x Interval) Interval {
+	m := increasing(fn, -inf, inf)
+	ends := []float64{m.bound(x.lo, false), m.bound(x.lo, true), m.bound(x.hi, false), m.bound(x.hi, true)}
+	y := hull(ends, ends)
+	if containsPeriodic(x, maxAt, 2*math.Pi) {
+		y.hi = 1
+	}
+	if containsPeriodic(x, minAt, 2*math.Pi) {
+		y.lo = -1
+	}
+	return Interval{lo: math.Max(y.lo, -1), hi: math.Min(y.hi, 1)}
+}
+
+// callInterval applies the interval variant of a built-in function. Functions without one fail with ErrTypeMismatch.
+func callInterval(name string, args []Interval) (Interval, error) {
+	b, ok := builtins[name]
+	if !ok {
+		return Interval{}, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("function %s", name))
+	}
+	if err := b.checkArity(name, len(args)); err != nil {
+		return Interval{}, err
+	}
+	if m, ok := monotoneFunctions[name]; ok && len(args) == 1 {
+		return m.apply(name, args[0])
+	}
+
+	x := args[0]
+	switch name {
+	case "abs":
+		return Interval{lo: x.mig(), hi: x.mag()}, nil
+	case "sqrt":
+		if x.lo < 0 {
+			return Interval{}, NewCalcError(ErrDomain, fmt.Sprintf("sqrt(%s)", x))
+		}
+		return Interval{lo: sqrtBound(x.lo, false), hi: sqrtBound(x.hi, true)}, nil
+	case "cosh":
+		m := increasing(math.Cosh, 0, inf)
+		return Interval{lo: m.bound(x.mig(), false), hi: m.bound(x.mag(), true)}, nil
+	case "sin":
+		return periodic(math.Sin, math.Pi/2, -math.Pi/2, x), nil
+	case "cos":
+		return periodic(math.Cos, 0, math.Pi, x), nil
+	case "tan":
+		if containsPeriodic(x, math.Pi/2, math.Pi) {
+			return entire, nil
+		}
+		return increasing(math.Tan, -inf, inf).apply(name, x)
+	case "log":
+		// log(x, b) = ln(x)/ln(b)
+		ln := monotoneFunctions["ln"]
+		num, err := ln.apply("log", x)
+		if err != nil {
+			return Interval{}, err
+		}
+		base, err := ln.apply("log", args[1])
+		if err != nil {
+			return Interval{}, err
+		}
+		return num.div(base)
+	case "hypot":
+		y := args[1]
+		m := increasing(func(a float64) float64 { return a }, -inf, inf)
+		return Interval{
+			lo: m.bound(math.Hypot(x.mig(), y.mig()), false),
+			hi: m.bound(math.Hypot(x.mag(), y.mag()), true),
+		}, nil
+	case "min", "max":
+		result := x
+		for _, y := range args[1:] {
+			if name == "min" {
+				result = Interval{lo: math.Min(result.lo, y.lo), hi: math.Min(result.hi, y.hi)}
+			} else {
+				result = Interval{lo: math.Max(result.lo, y.lo), hi: math.Max(result.hi, y.hi)}
+			}
+		}
+		return result, nil
+	}
+	return Interval{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s is not defined for intervals", name))
+}
+
+// callIntervalValues converts the arguments of callInterval.
+func callIntervalValues(name string, args []Value) (Value, error) {
+	xs := make([]Interval, len(args))
+	for i, a := range args {
+		x, err := toInterval(a)
+		if err != nil {
+			return nil, err
+		}
+		xs[i] = x
+	}
+	return intervalValue(callInterval(name, xs))
+}
+
+// interval returns interval(lo, hi), the numbers from lo to hi, in interval mode. [lo, hi] stays a list of two numbers.
+func interval(e *Environment, args []Value) (Value, error) {
+	if !e.options.Interval {
+		return nil, NewCalcError(ErrTypeMismatch, "interval(lo, hi) outside of interval mode")
+	}
+	if !isNumeric(args[0]) || !isNumeric(args[1]) {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("interval(%s, %s)", typeName(args[0]), typeName(args[1])))
+	}
+	lo, _ := toInterval(args[0])
+	hi, _ := toInterval(args[1])
+	if lo.lo > hi.hi {
+		return nil, NewCalcError(ErrDomain, fmt.Sprintf("interval(%s, %s), the lower bound is above the upper one",
+			formatFloat(lo.lo), formatFloat(hi.hi)))
+	}
+	return Interval{lo: lo.lo, hi: hi.hi}, nil
+}
+
+func isNumeric(v Value) bool {
+	switch v.(type) {
+	case Real, Interval:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package calculator
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestInterval(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"interval(1.9, 2.1)", "[1.9, 2.1]"},
+		{"2 ± 0.1", "[1.9, 2.1]"},
+		{"2 +/- 0.1", "[1.9, 2.1]"},
+		{"1 + 2", "[3, 3]"},
+		{"0.1", "[0.09999999999999999, 0.1]"},
+		{"interval(1, 2) - interval(0, 1)", "[0, 2]"},
+		{"interval(1, 2) * interval(-3, 4)", "[-6, 8]"},
+		{"1 / interval(1, 2)", "[0.5, 1]"},
+		{"1 / interval(0, 2)", "[0.5, +Inf]"},
+		{"1 / interval(-2, 0)", "[-Inf, -0.5]"},
+		{"interval(-1, -0.5) / interval(0, 2)", "[-Inf, -0.25]"},
+		{"1 / interval(-1, 1)", "[-Inf, +Inf]"},
+		{"interval(-1, 1) / interval(-1, 1)", "[-Inf, +Inf]"},
+		{"interval(-2, 3)^2", "[0, 9]"},
+		{"interval(-2, 3)^3", "[-8, 27]"},
+		{"interval(1, 2)^-1", "[0.5, 1]"},
+		{"0^interval(0, 1)", "[0, 1]"},
+		{"0^interval(0.5, 1)", "[0, 0]"},
+		{"interval(0, 1)^interval(0, 1)", "[0, 1]"},
+		{"-interval(1, 2)", "[-2, -1]"},
+		{"sqrt(interval(1, 4))", "[1, 2]"},
+		{"abs(interval(-2, 1))", "[0, 2]"},
+		{"exp(0) + ln(1)", "[1, 1]"},
+		{"ln(interval(0, 1))", "[-Inf, 0]"},
+		{"cos(interval(0, 2*pi))", "[-1, 1]"},
+		{"tan(interval(1, 2))", "[-Inf, +Inf]"},
+		{"round(interval(1.4, 2.6))", "[1, 3]"},
+		{"max(interval(1, 3), interval(2, 2))", "[2, 3]"},
+		{"x = 9.81 ± 0.02; x > 9", "1"},
+		{"interval(1, 2) < 3", "1"},
+		{"interval(1, 2) == 3", "0"},
+		{"series(k*interval(1, 2), k, 1, 3)", "[6, 12]"},
+		{"[1, 2] * 2", "[[2, 2], [4, 4]]"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Interval: true}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestIntervalBounds(t *testing.T) {
+	testCases := []struct {
+		input string
+		exact *big.Rat
+	}{
+		{"0.1 + 0.2", big.NewRat(3, 10)},
+		{"0.1 * 3", big.NewRat(3, 10)},
+		{"1 / 3", big.NewRat(1, 3)},
+		{"2 / 3 - 1 / 3", big.NewRat(1, 3)},
+		{"(1 / 7)^5", big.NewRat(1, 16807)},
+		{"10^-300 / 3", new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Mul(big.NewInt(3), new(big.Int).Exp(big.NewInt(10), big.NewInt(300), nil)))},
+		{"5%", big.NewRat(1, 20)},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Interval: true}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		x, ok := got.(Interval)
+		if !ok {
+			t.Errorf("EvaluateValue(%q) = %s, want an interval", tc.input, got)
+			continue
+		}
+		if new(big.Rat).SetFloat64(x.Lower()).Cmp(tc.exact) > 0 || new(big.Rat).SetFloat64(x.Upper()).Cmp(tc.exact) < 0 {
+			t.Errorf("EvaluateValue(%q) = %s, which does not hold %s", tc.input, x, tc.exact.RatString())
+		}
+		if x.Lower() == x.Upper() {
+			t.Errorf("EvaluateValue(%q) = %s, an inexact result without width", tc.input, x)
+		}
+	}
+}
+
+func TestIntervalErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  ErrorType
+	}{
+		{"interval(2, 1)", ErrDomain},
+		{"2 ± -1", ErrDomain},
+		{"1 / interval(0, 0)", ErrDivisionByZero},
+		{"sqrt(interval(-1, 4))", ErrDomain},
+		{"ln(interval(-1, 1))", ErrDomain},
+		{"interval(-1, 1)^0.5", ErrDomain},
+		{"0^interval(-1, 1)", ErrDivisionByZero},
+		{"integrate(x, x, 0, 1) + 1", ErrTypeMismatch},
+		{"integrate(0.1, x, 0, 1)", ErrTypeMismatch},
+		{"solve(x^2 - 2, x, 0, 2)", ErrTypeMismatch},
+		{"interval(1, 3) < 2", ErrDomain},
+		{"interval(1, 2)!", ErrTypeMismatch},
+		{"atan2(interval(1, 2), 1)", ErrTypeMismatch},
+		{"interval(1, 2) + 1 km", ErrTypeMismatch},
+		{"interval(1, [2, 3])", ErrTypeMismatch},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Interval: true}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+			t.Errorf("EvaluateValue(%q) error = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+
+	_, err := NewEnvironment(Options{}).EvaluateValue("2 ± 0.1")
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrMismatchOperator {
+		t.Errorf("EvaluateValue(%q) outside of interval mode error = %v, want %v", "2 ± 0.1", err, ErrMismatchOperator)
+	}
+	if _, err := NewEnvironment(Options{}).EvaluateValue("interval(1, 2)"); err == nil {
+		t.Error("interval(1, 2) was accepted outside of interval mode")
+	}
+}
@@ -0,0 +1,26 @@
+package calculator
+
+import (
+	"strings"
+)
+
+// List is an ordered list of values, such as the schedule of amortize.
+type List []Value
+
+// String formats the list as [1, 2, 3].
+func (l List) String() string {
+	items := make([]string, len(l))
+	for i, v := range l {
+		items[i] = v.String()
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// listOf makes a list of numbers.
+func listOf(xs ...float64) List {
+	l := make(List, len(xs))
+	for i, x := range xs {
+		l[i] = Real(x)
+	}
+	return l
+}
@@ -0,0 +1,578 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxMatrixSize bounds the rows and columns of the matrices computed with, such as identity(n).
+const maxMatrixSize = 1000
+
+// singularTolerance is the relative size below which a pivot counts as zero, making a matrix singular.
+const singularTolerance = 1e-12
+
+// A list of numbers is a vector, a list of lists of numbers of the same length a matrix of rows:
+// [[1, 2], [3, 4]]. matrix is the dense form the linear algebra is computed in.
+type matrix struct {
+	rows, cols int
+	a          []float64
+}
+
+func newMatrix(rows, cols int) matrix {
+	return matrix{rows: rows, cols: cols, a: make([]float64, rows*cols)}
+}
+
+func (m matrix) at(i, j int) float64 {
+	return m.a[i*m.cols+j]
+}
+
+func (m matrix) set(i, j int, x float64) {
+	m.a[i*m.cols+j] = x
+}
+
+// String describes the shape of the matrix, e.g. 2x3.
+func (m matrix) String() string {
+	return fmt.Sprintf("%dx%d", m.rows, m.cols)
+}
+
+// value converts the matrix back into a list of rows.
+func (m matrix) value() List {
+	rows := make(List, m.rows)
+	for i := range m.rows {
+		rows[i] = listOf(m.a[i*m.cols : (i+1)*m.cols]...)
+	}
+	return rows
+}
+
+// toVector reads a list of numbers.
+func toVector(v Value) ([]float64, error) {
+	l, ok := v.(List)
+	if !ok {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a vector is expected", typeName(v), v))
+	}
+	xs := make([]float64, len(l))
+	for i, item := range l {
+		x, ok := item.(Real)
+		if !ok {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s in a vector, expected a number", typeName(item), item))
+		}
+		xs[i] = float64(x)
+	}
+	return xs, nil
+}
+
+// isMatrix reports whether v is a non-empty list of lists.
+func isMatrix(v Value) bool {
+	l, ok := v.(List)
+	return ok && len(l) > 0 && isList(l[0])
+}
+
+// toMatrix reads a list of rows of numbers of the same length.
+func toMatrix(v Value) (matrix, error) {
+	if !isMatrix(v) {
+		return matrix{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a matrix is expected", typeName(v), v))
+	}
+	rows := v.(List)
+	first, err := toVector(rows[0])
+	if err != nil {
+		return matrix{}, err
+	}
+
+	m := newMatrix(len(rows), len(first))
+	for i, row := range rows {
+		xs, err := toVector(row)
+		if err != nil {
+			return matrix{}, err
+		}
+		if len(xs) != m.cols {
+			return matrix{}, NewCalcError(ErrDimension, fmt.Sprintf("row %d has %d columns, row 1 has %d", i+1, len(xs), m.cols))
+		}
+		copy(m.a[i*m.cols:], xs)
+	}
+	return m, nil
+}
+
+// columnOf makes a matrix of a single column from a vector.
+func columnOf(xs []float64) matrix {
+	return matrix{rows: len(xs), cols: 1, a: xs}
+}
+
+func identity(n int) matrix {
+	m := newMatrix(n, n)
+	for i := range n {
+		m.set(i, i, 1)
+	}
+	return m
+}
+
+func (m matrix) mul(o matrix) (matrix, error) {
+	if m.cols != o.rows {
+		return matrix{}, NewCalcError(ErrDimension, fmt.Sprintf("matrix %s * matrix %s", m, o))
+	}
+	p := newMatrix(m.rows, o.cols)
+	for i := range m.rows {
+		for k := range m.cols {
+			x := m.at(i, k)
+			for j := range o.cols {
+				p.a[i*p.cols+j] += x * o.at(k, j)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (m matrix) transpose() matrix {
+	t := newMatrix(m.cols, m.rows)
+	for i := range m.rows {
+		for j := range m.cols {
+			t.set(j, i, m.at(i, j))
+		}
+	}
+	return t
+}
+
+func (m matrix) checkSquare(name string) error {
+	if m.rows != m.cols {
+		return NewCalcError(ErrDimension, fmt.Sprintf("%s of a %s matrix, expected a square one", name, m))
+	}
+	return nil
+}
+
+// eliminate reduces m to row echelon form by Gaussian elimination with partial pivoting, applying the same
+// row operations to rhs if it is given. It returns the determinant of m; a singular matrix has determinant 0.
+func (m matrix) eliminate(rhs *matrix) float64 {
+	scale := 0.0
+	for _, x := range m.a {
+		scale = math.Max(scale, math.Abs(x))
+	}
+
+	det := 1.0
+	for col := range m.cols {
+		pivot := col
+		for i := col + 1; i < m.rows; i++ {
+			if math.Abs(m.at(i, col)) > math.Abs(m.at(pivot, col)) {
+				pivot = i
+			}
+		}
+		if math.Abs(m.at(pivot, col)) <= singularTolerance*scale || scale == 0 {
+			return 0
+		}
+		if pivot != col {
+			m.swapRows(pivot, col)
+			if rhs != nil {
+				rhs.swapRows(pivot, col)
+			}
+			det = -det
+		}
+
+		p := m.at(col, col)
+		det *= p
+		for i := col + 1; i < m.rows; i++ {
+			f := m.at(i, col) / p
+			if f == 0 {
+				continue
+			}
+			for j := col; j < m.cols; j++ {
+				m.set(i, j, m.at(i, j)-f*m.at(col, j))
+			}
+			if rhs != nil {
+				for j := range rhs.cols {
+					rhs.set(i, j, rhs.at(i, j)-f*rhs.at(col, j))
+				}
+			}
+		}
+	}
+	return det
+}
+
+func (m matrix) swapRows(i, j int) {
+	for k := range m.cols {
+		m.a[i*m.cols+k], m.a[j*m.cols+k] = m.a[j*m.cols+k], m.a[i*m.cols+k]
+	}
+}
+
+// solve returns x with m x = rhs for a square m, failing for a singular one. m and rhs are overwritten.
+func (m matrix) solve(rhs matrix) (matrix, error) {
+	if m.eliminate(&rhs) == 0 {
+		return matrix{}, NewCalcError(ErrDomain, "singular matrix")
+	}
+	x := newMatrix(rhs.rows, rhs.cols)
+	for j := range rhs.cols {
+		for i := m.rows - 1; i >= 0; i-- {
+			s := rhs.at(i, j)
+			for k := i + 1; k < m.cols; k++ {
+				s -= m.at(i, k) * x.at(k, j)
+			}
+			x.set(i, j, s/m.at(i, i))
+		}
+	}
+	return x, nil
+}
+
+func (m matrix) clone() matrix {
+	return matrix{rows: m.rows, cols: m.cols, a: append([]float64(nil), m.a...)}
+}
+
+func (m matrix) inverse() (matrix, error) {
+	if err := m.checkSquare("inverse"); err != nil {
+		return matrix{}, err
+	}
+	return m.clone().solve(identity(m.rows))
+}
+
+// pow raises a square matrix to an integer power by repeated squaring; a negative power is one of the inverse.
+func (m matrix) pow(n float64) (matrix, error) {
+	if err := m.checkSquare("power"); err != nil {
+		return matrix{}, err
+	}
+	if n != math.Trunc(n) || math.Abs(n) > math.MaxInt32 {
+		return matrix{}, NewCalcError(ErrNotInteger, fmt.Sprintf("power %g of a matrix", n))
+	}
+	if n < 0 {
+		inv, err := m.inverse()
+		if err != nil {
+			return matrix{}, err
+		}
+		m, n = inv, -n
+	}
+
+	result := identity(m.rows)
+	for k := int(n); k > 0; k >>= 1 {
+		var err error
+		if k&1 == 1 {
+			if result, err = result.mul(m); err != nil {
+				return matrix{}, err
+			}
+		}
+		if m, err = m.mul(m); err != nil {
+			return matrix{}, err
+		}
+	}
+	return result, nil
+}
+
+// checkFinite rejects a result that overflowed.
+func checkFinite(xs []float64, what string) error {
+	for _, x := range xs {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return NewCalcError(ErrTooLargeNumber, what)
+		}
+	}
+	return nil
+}
+
+// describeList describes the shape of a list for an error message: matrix 2x3, vector of 4 or list of 4.
+func describeList(v Value) string {
+	if m, err := toMatrix(v); err == nil {
+		return "matrix " + m.String()
+	}
+	if xs, err := toVector(v); err == nil {
+		return fmt.Sprintf("vector of %d", len(xs))
+	}
+	if l, ok := v.(List); ok {
+		return fmt.Sprintf("list of %d", len(l))
+	}
+	return typeName(v)
+}
+
+// applyListOperator applies a binary operator to operands of which at least one is a list.
+// A number or another single value combined with a list is combined with each item: 2 * [1, 2] is [2, 4].
+// Lists of the same length are added and subtracted item by item. Two lists multiply as matrices, a vector
+// standing for a column on the right and a row on the left, and a square matrix can be raised to an integer power.
+// Lists are equal if their items are.
+func (e *Environment) applyListOperator(op string, a, b Value) (Value, error) {
+	x, xList := a.(List)
+	y, yList := b.(List)
+
+	switch tokenType(op) {
+	case Eq, NotEq:
+		equal, err := e.listsEqual(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return Real(boolean(equal == (tokenType(op) == Eq))), nil
+	case Pow:
+		if !xList || yList {
+			break
+		}
+		n, err := toReal(b)
+		if err != nil {
+			return nil, err
+		}
+		m, err := toMatrix(a)
+		if err != nil {
+			return nil, err
+		}
+		p, err := m.pow(n)
+		if err != nil {
+			return nil, err
+		}
+		return p.value(), checkFinite(p.a, fmt.Sprintf("matrix %s ^ %g", m, n))
+	case Multi:
+		if xList && yList {
+			return matrixProduct(a, b)
+		}
+		fallthrough
+	case Add, Sub, Div:
+		switch {
+		case xList && yList:
+			if tokenType(op) == Div {
+				break
+			}
+			if len(x) != len(y) {
+				return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s %s %s", describeList(a), op, describeList(b)))
+			}
+			return e.mapList(len(x), func(i int) (Value, error) { return e.operate(op, x[i], y[i]) })
+		case xList:
+			return e.mapList(len(x), func(i int) (Value, error) { return e.operate(op, x[i], b) })
+		case yList && tokenType(op) != Div:
+			return e.mapList(len(y), func(i int) (Value, error) { return e.operate(op, a, y[i]) })
+		}
+	}
+	return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", describeList(a), op, describeList(b)))
+}
+
+// mapList builds a list of n items computed by item.
+func (e *Environment) mapList(n int, item func(i int) (Value, error)) (Value, error) {
+	l := make(List, n)
+	for i := range n {
+		v, err := item(i)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = v
+	}
+	return l, nil
+}
+
+func (e *Environment) listsEqual(a, b Value) (bool, error) {
+	x, xList := a.(List)
+	y, yList := b.(List)
+	if !xList || !yList {
+		return false, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s == %s", typeName(a), typeName(b)))
+	}
+	if len(x) != len(y) {
+		return false, nil
+	}
+	for i := range x {
+		eq, err := e.operate(string(Eq), x[i], y[i])
+		if err != nil {
+			return false, err
+		}
+		if !truthy(eq) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matrixProduct multiplies matrices and vectors. A vector on the right is a column, so the product
+// of a matrix and a vector is a vector; a vector on the left is a row.
+func matrixProduct(a, b Value) (Value, error) {
+	if !isMatrix(a) && !isMatrix(b) {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s * %s, use dot(u, v) or hadamard(u, v)", describeList(a), describeList(b)))
+	}
+
+	left, right := a, b
+	var m, o matrix
+	var err error
+	if isMatrix(left) {
+		m, err = toMatrix(left)
+	} else {
+		var xs []float64
+		xs, err = toVector(left)
+		m = columnOf(xs).transpose()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isMatrix(right) {
+		o, err = toMatrix(right)
+	} else {
+		var xs []float64
+		xs, err = toVector(right)
+		o = columnOf(xs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.cols != o.rows {
+		return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s * %s, %d columns and %d rows", describeList(a), describeList(b), m.cols, o.rows))
+	}
+	p, _ := m.mul(o)
+	if err := checkFinite(p.a, "matrix product"); err != nil {
+		return nil, err
+	}
+	if !isMatrix(a) || !isMatrix(b) {
+		// A row times a matrix or a matrix times a column is a vector again.
+		return listOf(p.a...), nil
+	}
+	return p.value(), nil
+}
+
+// matrixFunction adapts a function of matrices to a value function.
+func matrixFunction(argc int, fn func(args []Value) (Value, error)) specialForm {
+	return valueFunction(argc, argc, func(_ *Environment, args []Value) (Value, error) { return fn(args) })
+}
+
+// matrixFunctions are the linear algebra functions. They are added to the special forms in init.
+var matrixFunctions = map[string]specialForm{
+	"transpose": matrixFunction(1, func(args []Value) (Value, error) {
+		if !isMatrix(args[0]) {
+			// A vector is a row, its transpose a column.
+			xs, err := toVector(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return columnOf(xs).value(), nil
+		}
+		m, err := toMatrix(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return m.transpose().value(), nil
+	}),
+	"det": matrixFunction(1, func(args []Value) (Value, error) {
+		m, err := toMatrix(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := m.checkSquare("det"); err != nil {
+			return nil, err
+		}
+		det := m.clone().eliminate(nil)
+		return Real(det), checkFinite([]float64{det}, "det")
+	}),
+	"inv": matrixFunction(1, func(args []Value) (Value, error) {
+		m, err := toMatrix(args[0])
+		if err != nil {
+			return nil, err
+		}
+		inv, err := m.inverse()
+		if err != nil {
+			return nil, err
+		}
+		return inv.value(), checkFinite(inv.a, "inv")
+	}),
+	// linsolve(A, b) solves the linear system A x = b for a square A and a vector b.
+	"linsolve": matrixFunction(2, func(args []Value) (Value, error) {
+		m, err := toMatrix(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := m.checkSquare("linsolve"); err != nil {
+			return nil, err
+		}
+		b, err := toVector(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != m.rows {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("linsolve of a %s matrix and a vector of %d", m, len(b)))
+		}
+		x, err := m.clone().solve(columnOf(append([]float64(nil), b...)))
+		if err != nil {
+			return nil, err
+		}
+		return listOf(x.a...), checkFinite(x.a, "linsolve")
+	}),
+	"dot": matrixFunction(2, func(args []Value) (Value, error) {
+		u, v, err := twoVectors("dot", args)
+		if err != nil {
+			return nil, err
+		}
+		var s float64
+		for i := range u {
+			s += u[i] * v[i]
+		}
+		return Real(s), nil
+	}),
+	"cross": matrixFunction(2, func(args []Value) (Value, error) {
+		u, v, err := twoVectors("cross", args)
+		if err != nil {
+			return nil, err
+		}
+		if len(u) != 3 {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("cross of vectors of %d, expected 3", len(u)))
+		}
+		return listOf(u[1]*v[2]-u[2]*v[1], u[2]*v[0]-u[0]*v[2], u[0]*v[1]-u[1]*v[0]), nil
+	}),
+	// hadamard(a, b) multiplies two vectors or matrices of the same shape item by item.
+	"hadamard": matrixFunction(2, func(args []Value) (Value, error) {
+		return hadamard(args[0], args[1])
+	}),
+	// norm(v) is the Euclidean length of a vector, or the Frobenius norm of a matrix.
+	"norm": matrixFunction(1, func(args []Value) (Value, error) {
+		var xs []float64
+		if isMatrix(args[0]) {
+			m, err := toMatrix(args[0])
+			if err != nil {
+				return nil, err
+			}
+			xs = m.a
+		} else {
+			v, err := toVector(args[0])
+			if err != nil {
+				return nil, err
+			}
+			xs = v
+		}
+		var s float64
+		for _, x := range xs {
+			s = math.Hypot(s, x)
+		}
+		return Real(s), nil
+	}),
+	// identity(n) is the n x n identity matrix.
+	"identity": matrixFunction(1, func(args []Value) (Value, error) {
+		n, err := toInt(args[0], maxMatrixSize)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("identity(%d)", n))
+		}
+		return identity(n).value(), nil
+	}),
+}
+
+func twoVectors(name string, args []Value) ([]float64, []float64, error) {
+	u, err := toVector(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := toVector(args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(u) != len(v) {
+		return nil, nil, NewCalcError(ErrDimension, fmt.Sprintf("%s of vectors of %d and %d", name, len(u), len(v)))
+	}
+	return u, v, nil
+}
+
+func hadamard(a, b Value) (Value, error) {
+	x, xList := a.(List)
+	y, yList := b.(List)
+	if !xList || !yList {
+		if xr, ok := a.(Real); ok {
+			if yr, ok := b.(Real); ok {
+				return xr * yr, nil
+			}
+		}
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("hadamard of %s and %s", describeList(a), describeList(b)))
+	}
+	if len(x) != len(y) {
+		return nil, NewCalcError(ErrDimension, fmt.Sprintf("hadamard of %s and %s", describeList(a), describeList(b)))
+	}
+	l := make(List, len(x))
+	for i := range x {
+		v, err := hadamard(x[i], y[i])
+		if err != nil {
+			return nil, err
+		}
+		l[i] = v
+	}
+	return l, nil
+}
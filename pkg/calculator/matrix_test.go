@@ -0,0 +1,107 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMatrices(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"[1, 2] + [3, 4]", "[4, 6]"},
+		{"[[1, 2], [3, 4]] - [[1, 1], [1, 1]]", "[[0, 1], [2, 3]]"},
+		{"2 * [1, 2]", "[2, 4]"},
+		{"[1, 2] / 2", "[0.5, 1]"},
+		{"-[1, 2]", "[-1, -2]"},
+		{"[1 m, 2 m] + [1 km, 1 km]", "[1001 m, 1002 m]"},
+		{"[[1, 2], [3, 4]] * [[5, 6], [7, 8]]", "[[19, 22], [43, 50]]"},
+		{"[[1, 2], [3, 4]] * [1, 1]", "[3, 7]"},
+		{"[1, 1] * [[1, 2], [3, 4]]", "[4, 6]"},
+		{"[[1, 2, 3]] * [[1], [2], [3]]", "[[14]]"},
+		{"[[1, 2], [3, 4]]^2", "[[7, 10], [15, 22]]"},
+		{"[[1, 2], [3, 4]]^0", "[[1, 0], [0, 1]]"},
+		{"[[2, 0], [0, 4]]^-1", "[[0.5, 0], [0, 0.25]]"},
+		{"[1, 2] == [1, 2]", "1"},
+		{"[1, 2] != [1, 2, 3]", "1"},
+		{"transpose([[1, 2, 3], [4, 5, 6]])", "[[1, 4], [2, 5], [3, 6]]"},
+		{"transpose([1, 2])", "[[1], [2]]"},
+		{"det([[1, 2], [3, 4]])", "-2"},
+		{"det([[2, 0, 0], [0, 3, 0], [0, 0, 4]])", "24"},
+		{"det([[1, 2], [2, 4]])", "0"},
+		{"norm(inv([[4, 7], [2, 6]]) * [[4, 7], [2, 6]] - identity(2)) < 0.000000001", "1"},
+		{"linsolve([[2, 1], [1, 3]], [3, 5])", "[0.8, 1.4]"},
+		{"dot([1, 2, 3], [4, 5, 6])", "32"},
+		{"cross([1, 0, 0], [0, 1, 0])", "[0, 0, 1]"},
+		{"hadamard([[1, 2], [3, 4]], [[2, 2], [2, 2]])", "[[2, 4], [6, 8]]"},
+		{"norm([3, 4])", "5"},
+		{"norm([[1, 1], [1, 1]])", "2"},
+		{"identity(3)", "[[1, 0, 0], [0, 1, 0], [0, 0, 1]]"},
+		{"A = [[1, 2], [3, 4]]; b = [5, 6]; norm(A * linsolve(A, b) - b) < 0.000000001", "1"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	got, err := NewEnvironment(Options{}).EvaluateValue("inv([[1, 2], [3, 4]])")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := toMatrix(got)
+	if err != nil {
+		t.Fatalf("inv returned %s: %v", got, err)
+	}
+	for i, want := range []float64{-2, 1, 1.5, -0.5} {
+		if math.Abs(m.a[i]-want) > 1e-12 {
+			t.Errorf("inv([[1, 2], [3, 4]]) = %s, want [[-2, 1], [1.5, -0.5]]", got)
+			break
+		}
+	}
+}
+
+func TestMatricesErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"[1, 2] + [1, 2, 3]", ErrDimension},
+		{"[[1, 2], [3, 4]] * [[1, 2, 3]]", ErrDimension},
+		{"[[1, 2], [3, 4]] * [1, 2, 3]", ErrDimension},
+		{"[[1, 2], [3]] * [1, 1]", ErrDimension},
+		{"det([[1, 2, 3], [4, 5, 6]])", ErrDimension},
+		{"[[1, 2, 3]]^2", ErrDimension},
+		{"linsolve([[1, 0], [0, 1]], [1, 2, 3])", ErrDimension},
+		{"dot([1], [1, 2])", ErrDimension},
+		{"cross([1, 2], [3, 4])", ErrDimension},
+		{"hadamard([1, 2], [1])", ErrDimension},
+		{"inv([[1, 2], [2, 4]])", ErrDomain},
+		{"linsolve([[1, 2], [2, 4]], [1, 2])", ErrDomain},
+		{"[[2]]^0.5", ErrNotInteger},
+		{"[1, 2] * [3, 4]", ErrTypeMismatch},
+		{"2 / [1, 2]", ErrTypeMismatch},
+		{"[1, 2] < [1, 3]", ErrTypeMismatch},
+		{"det([1, 2])", ErrTypeMismatch},
+		{"dot([1 m], [1 m])", ErrTypeMismatch},
+		{"identity(0)", ErrDomain},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDecimalPlaces is the number of fraction digits an amount is shown with when it is not a short decimal,
+// e.g. 100 USD / 3.
+const maxDecimalPlaces = 10
+
+// Rate is the exchange rate of a currency: the value of one unit of it in the base currency of the table.
+type Rate struct {
+	Currency  string
+	Rate      *big.Rat
+	UpdatedAt time.Time
+}
+
+// Rates is an exchange-rate table. It is never modified after NewRates, so a table can be shared
+// between goroutines and replaced as a whole.
+type Rates struct {
+	base  string
+	rates map[string]Rate
+}
+
+// NewRates returns a table of the given rates against the base currency. Currency codes are
+// three upper-case letters, e.g. USD; every rate must be positive.
+func NewRates(base string, rates []Rate) (*Rates, error) {
+	if !isCurrencyCode(base) {
+		return nil, fmt.Errorf("invalid base currency %q", base)
+	}
+
+	table := &Rates{base: base, rates: make(map[string]Rate, len(rates))}
+	for _, r := range rates {
+		switch {
+		case !isCurrencyCode(r.Currency):
+			return nil, fmt.Errorf("invalid currency %q", r.Currency)
+		case r.Currency == base:
+			return nil, fmt.Errorf("rate of the base currency %s", base)
+		case r.Rate == nil || r.Rate.Sign() <= 0:
+			return nil, fmt.Errorf("rate of %s must be positive", r.Currency)
+		}
+		if _, ok := table.rates[r.Currency]; ok {
+			return nil, fmt.Errorf("duplicate rate of %s", r.Currency)
+		}
+		r.Rate = new(big.Rat).Set(r.Rate)
+		table.rates[r.Currency] = r
+	}
+
+	return table, nil
+}
+
+// Base returns the currency the rates are given in.
+func (r *Rates) Base() string {
+	return r.base
+}
+
+// Rates returns the rates ordered by currency.
+func (r *Rates) Rates() []Rate {
+	rates := make([]Rate, 0, len(r.rates))
+	for _, currency := range slices.Sorted(maps.Keys(r.rates)) {
+		rates = append(rates, r.rate(currency))
+	}
+	return rates
+}
+
+// has reports whether the table knows the currency.
+func (r *Rates) has(currency string) bool {
+	_, ok := r.rates[currency]
+	return ok || currency == r.base
+}
+
+// rate returns the rate of a known currency; the base currency has rate 1.
+func (r *Rates) rate(currency string) Rate {
+	if currency == r.base {
+		return Rate{Currency: currency, Rate: big.NewRat(1, 1)}
+	}
+	rate := r.rates[currency]
+	rate.Rate = new(big.Rat).Set(rate.Rate)
+	return rate
+}
+
+// isCurrencyCode reports whether s looks like an ISO 4217 code: three upper-case letters.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseDecimal reads an exact decimal number such as 1.0825.
+func ParseDecimal(s string) (*big.Rat, error) {
+	x, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsRune(s, '/') {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	return x, nil
+}
+
+// FormatDecimal writes x with as few fraction digits as it takes, at most maxDecimalPlaces.
+func FormatDecimal(x *big.Rat) string {
+	return formatDecimal(x, 0)
+}
+
+// formatDecimal writes x exactly if it has at most maxDecimalPlaces fraction digits, with at least minPlaces of them,
+// and rounds it to maxDecimalPlaces digits otherwise. A number too small for that is rounded to its first
+// significant digit instead of zero: 0.000000000001 is not 0.0000000000.
+func formatDecimal(x *big.Rat, minPlaces int) string {
+	limit := maxDecimalPlaces
+	scaled := new(big.Rat).Abs(x)
+	for places := 0; scaled.Sign() != 0 && scaled.Cmp(big.NewRat(1, 1)) < 0; places++ {
+		scaled.Mul(scaled, big.NewRat(10, 1))
+		limit = max(limit, places+1)
+	}
+
+	for places := minPlaces; places <= limit; places++ {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+		if scaled.Mul(x, new(big.Rat).SetInt(scale)).IsInt() {
+			return x.FloatString(places)
+		}
+	}
+	return x.FloatString(limit)
+}
+
+// Money is an amount of a currency. Amounts are exact: 0.1 USD + 0.2 USD is 0.30 USD.
+type Money struct {
+	amount   *big.Rat
+	currency string
+}
+
+// Amount returns the amount as a decimal number with at least two fraction digits.
+func (m Money) Amount() string {
+	return formatDecimal(m.amount, 2)
+}
+
+// Currency returns the currency code.
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// String formats the amount as 150.00 USD.
+func (m Money) String() string {
+	return m.Amount() + " " + m.currency
+}
+
+// moneyValue checks the size of an amount computed by an operation.
+func moneyValue(amount *big.Rat, currency string) (Value, error) {
+	if amount.Num().BitLen() > maxIntegerBits || amount.Denom().BitLen() > maxIntegerBits {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("amount of %s", currency))
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// toRat reads a real number as the shortest decimal that represents it, so 0.1 is exactly one tenth.
+func toRat(v Value) (*big.Rat, bool) {
+	x, ok := v.(Real)
+	if !ok {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(float64(x), 'g', -1, 64))
+	return r, ok
+}
+
+// applyMoneyLiteral multiplies or divides an amount by a number literal from the digits of the literal rather than
+// its float64, so 1234567890123.456789 USD keeps all of them. It reports false for any other operation.
+func (e *Environment) applyMoneyLiteral(n *node, a, b Value) (Value, bool, error) {
+	op := tokenType(n.token)
+	if op != Multi && op != Div {
+		return nil, false, nil
+	}
+	m, literal := a, n.args[1]
+	if !isMoney(m) && op == Multi {
+		m, literal = b, n.args[0]
+	}
+	if !isMoney(m) || literal.kind != numberNode {
+		return nil, false, nil
+	}
+	k, ok := literalValue(literal.token)
+	if !ok {
+		return nil, false, nil
+	}
+	v, err := scaleMoney(op, m.(Money), k)
+	return v, true, err
+}
+
+func isMoney(v Value) bool {
+	_, ok := v.(Money)
+	return ok
+}
+
+// isCurrency reports whether name is a currency of the environment's exchange-rate table.
+func (e *Environment) isCurrency(name string) bool {
+	return e.options.Rates != nil && e.options.Rates.has(name)
+}
+
+// exchange converts an amount into another currency through the base currency of the table and remembers
+// the rates it used, see UsedRates.
+func (e *Environment) exchange(v Value, currency string) (Value, error) {
+	m, ok := v.(Money)
+	if !ok {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s to %s", typeName(v), v, currency))
+	}
+	if m.currency == currency {
+		return m, nil
+	}
+	// An amount kept in a session may outlive the table it was computed with.
+	if !e.isCurrency(m.currency) {
+		return nil, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("exchange rate of %s", m.currency))
+	}
+
+	from, to := e.options.Rates.rate(m.currency), e.options.Rates.rate(currency)
+	for _, r := range []Rate{from, to} {
+		if r.Currency != e.options.Rates.base {
+			e.used[r.Currency] = r
+		}
+	}
+
+	amount := new(big.Rat).Mul(m.amount, from.Rate)
+	return moneyValue(amount.Quo(amount, to.Rate), currency)
+}
+
+// UsedRates returns the exchange rates the last evaluation converted amounts with, ordered by currency.
+func (e *Environment) UsedRates() []Rate {
+	rates := make([]Rate, 0, len(e.used))
+	for _, currency := range slices.Sorted(maps.Keys(e.used)) {
+		rates = append(rates, e.used[currency])
+	}
+	return rates
+}
+
+// applyMoneyOperator applies a binary operator to operands of which at least one is an amount.
+// Amounts in different currencies are added, subtracted, compared and divided in the currency of the left one;
+// an amount can be multiplied or divided by a number.
+func (e *Environment) applyMoneyOperator(op string, a, b Value) (Value, error) {
+	x, xMoney := a.(Money)
+	y, yMoney := b.(Money)
+	mismatch := NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", typeName(a), operatorSpelling(op), typeName(b)))
+
+	if xMoney && yMoney {
+		switch tokenType(op) {
+		case Add, Sub, Div, Eq, NotEq, Less, LessEq, Greater, GreaterEq:
+		default:
+			return nil, mismatch
+		}
+
+		converted, err := e.exchange(y, x.currency)
+		if err != nil {
+			return nil, err
+		}
+		y = converted.(Money)
+
+		switch tokenType(op) {
+		case Add:
+			return moneyValue(new(big.Rat).Add(x.amount, y.amount), x.currency)
+		case Sub:
+			return moneyValue(new(big.Rat).Sub(x.amount, y.amount), x.currency)
+		case Div:
+			if y.amount.Sign() == 0 {
+				return nil, NewCalcError(ErrDivisionByZero, "")
+			}
+			ratio, _ := new(big.Rat).Quo(x.amount, y.amount).Float64()
+			return Real(ratio), nil
+		case Eq, NotEq, Less, LessEq, Greater, GreaterEq:
+			return Real(boolean(compare(op, x.amount.Cmp(y.amount)))), nil
+		}
+	}
+
+	switch {
+	case xMoney && tokenType(op) == Multi, xMoney && tokenType(op) == Div:
+		k, ok := toRat(b)
+		if !ok {
+			return nil, mismatch
+		}
+		return scaleMoney(tokenType(op), x, k)
+	case yMoney && tokenType(op) == Multi:
+		return e.applyMoneyOperator(op, b, a)
+	}
+	return nil, mismatch
+}
+
+// scaleMoney multiplies or divides an amount by k, which it overwrites.
+func scaleMoney(op tokenType, x Money, k *big.Rat) (Value, error) {
+	if op == Multi {
+		return moneyValue(k.Mul(x.amount, k), x.currency)
+	}
+	if k.Sign() == 0 {
+		return nil, NewCalcError(ErrDivisionByZero, "")
+	}
+	return moneyValue(k.Quo(x.amount, k), x.currency)
+}
+
+// compare tells whether the result of a Cmp satisfies a comparison operator.
+func compare(op string, cmp int) bool {
+	switch tokenType(op) {
+	case Eq:
+		return cmp == 0
+	case NotEq:
+		return cmp != 0
+	case Less:
+		return cmp < 0
+	case LessEq:
+		return cmp <= 0
+	case Greater:
+		return cmp > 0
+	case GreaterEq:
+		return cmp >= 0
+	}
+	return false
+}
+
+// callMoney applies the built-in functions that accept amounts: abs, and min, max and sum, which compare and add
+// amounts in different currencies through the exchange rates.
+func (e *Environment) callMoney(name string, args []Value) (Value, error) {
+	switch name {
+	case "abs":
+		if err := checkArity(name, len(args), 1, 1); err != nil {
+			return nil, err
+		}
+		m, ok := args[0].(Money)
+		if !ok {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s(%s)", name, typeName(args[0])))
+		}
+		return Money{amount: new(big.Rat).Abs(m.amount), currency: m.currency}, nil
+	case "min", "max":
+		result := args[0]
+		for _, a := range args[1:] {
+			less, err := e.applyMoneyOperator(string(Less), a, result)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(less) == (name == "min") {
+				result = a
+			}
+		}
+		return result, nil
+	case "sum":
+		result := args[0]
+		for _, a := range args[1:] {
+			var err error
+			if result, err = e.operate(string(Add), result, a); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s of an amount", name))
+}
@@ -0,0 +1,160 @@
+package calculator
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRates(t *testing.T) *Rates {
+	t.Helper()
+
+	updated := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	rates, err := NewRates("USD", []Rate{
+		{Currency: "EUR", Rate: big.NewRat(108, 100), UpdatedAt: updated},
+		{Currency: "GBP", Rate: big.NewRat(125, 100), UpdatedAt: updated},
+		{Currency: "JPY", Rate: big.NewRat(1, 150), UpdatedAt: updated},
+	})
+	if err != nil {
+		t.Fatalf("NewRates: %v", err)
+	}
+	return rates
+}
+
+func TestMoney(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+		used  []string
+	}{
+		{"100 USD + 50 EUR", "154.00 USD", []string{"EUR"}},
+		{"100 USD + 50 EUR in GBP", "123.20 GBP", []string{"EUR", "GBP"}},
+		{"0.1 USD + 0.2 USD", "0.30 USD", nil},
+		{"0.1 USD + 0.2 USD == 0.3 USD", "1", nil},
+		{"100 USD / 3", "33.3333333333 USD", nil},
+		{"100 USD / 3 * 3", "100.00 USD", nil},
+		{"19.99 EUR * 3", "59.97 EUR", nil},
+		{"3 * 19.99 EUR", "59.97 EUR", nil},
+		{"-5 EUR", "-5.00 EUR", nil},
+		{"1.075 EUR", "1.075 EUR", nil},
+		{"15000 JPY to USD", "100.00 USD", []string{"JPY"}},
+		{"100 EUR / (50 EUR)", "2", nil},
+		{"100 USD / 50 USD", "2", nil},
+		{"0.000000000001 USD", "0.000000000001 USD", nil},
+		{"1 USD / 3000000000000", "0.0000000000003 USD", nil},
+		{"108 USD / (100 EUR)", "1", []string{"EUR"}},
+		{"1 GBP > 1 EUR", "1", []string{"EUR", "GBP"}},
+		{"200 USD * 10%", "20.00 USD", nil},
+		{"abs(-3 EUR)", "3.00 EUR", nil},
+		{"max(100 USD, 95 EUR)", "95.00 EUR", []string{"EUR"}},
+		{"sum(1 USD, 2 USD)", "3.00 USD", nil},
+		{"sum([100 USD, 50 EUR])", "154.00 USD", []string{"EUR"}},
+		{"1234567890123.456789 USD", "1234567890123.456789 USD", nil},
+		{"12345678901234567.89 USD + 0.01 USD", "12345678901234567.90 USD", nil},
+		{"-98765432109876543.21 EUR * 2", "-197530864219753086.42 EUR", nil},
+		{"100 USD * 1.0000000000000001 > 100 USD", "1", nil},
+		{"price = 20 EUR; qty = 3; price * qty in USD", "64.80 USD", []string{"EUR"}},
+		{"total(p, n) = p * n; total(2.5 GBP, 4)", "10.00 GBP", nil},
+	}
+
+	rates := testRates(t)
+	for _, tc := range testCases {
+		env := NewEnvironment(Options{Rates: rates})
+		got, err := env.EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+
+		var used []string
+		for _, r := range env.UsedRates() {
+			used = append(used, r.Currency)
+		}
+		if !reflect.DeepEqual(used, tc.used) {
+			t.Errorf("EvaluateValue(%q) used rates %v, want %v", tc.input, used, tc.used)
+		}
+	}
+}
+
+func TestMoneyErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"100 USD + 5", ErrTypeMismatch},
+		{"100 USD * 5 EUR", ErrTypeMismatch},
+		{"5 / (100 USD)", ErrTypeMismatch},
+		{"100 USD + 3 m", ErrTypeMismatch},
+		{"100 in USD", ErrTypeMismatch},
+		{"sqrt(100 USD)", ErrTypeMismatch},
+		{"100 USD / 0", ErrDivisionByZero},
+		{"100 USD + 5 CHF", ErrUnknownIdentifier},
+	}
+
+	rates := testRates(t)
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Rates: rates}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+
+	// The message names the operator as written.
+	if _, err := NewEnvironment(Options{Rates: rates}).EvaluateValue("5 / (100 USD)"); err == nil || !strings.Contains(err.Error(), "number / amount") {
+		t.Errorf(`EvaluateValue("5 / (100 USD)") error = %v, want one naming number / amount`, err)
+	}
+
+	if _, err := NewEnvironment(Options{}).EvaluateValue("100 USD"); err == nil {
+		t.Errorf("expected no currencies without a rates table")
+	}
+}
+
+func TestNewRates(t *testing.T) {
+	rates := testRates(t)
+	if rates.Base() != "USD" || len(rates.Rates()) != 3 || rates.Rates()[0].Currency != "EUR" {
+		t.Errorf("unexpected table %v %v", rates.Base(), rates.Rates())
+	}
+
+	one := big.NewRat(1, 1)
+	for _, tc := range []struct {
+		base  string
+		rates []Rate
+	}{
+		{"usd", nil},
+		{"USD", []Rate{{Currency: "EURO", Rate: one}}},
+		{"USD", []Rate{{Currency: "USD", Rate: one}}},
+		{"USD", []Rate{{Currency: "EUR", Rate: new(big.Rat)}}},
+		{"USD", []Rate{{Currency: "EUR"}}},
+		{"USD", []Rate{{Currency: "EUR", Rate: one}, {Currency: "EUR", Rate: one}}},
+	} {
+		if _, err := NewRates(tc.base, tc.rates); err == nil {
+			t.Errorf("NewRates(%q, %v): expected error", tc.base, tc.rates)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	for _, s := range []string{"1.08", "0.0066666667", "150", "-2.5", "-0.000000000001"} {
+		x, err := ParseDecimal(s)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", s, err)
+			continue
+		}
+		if got := FormatDecimal(x); got != s {
+			t.Errorf("FormatDecimal(ParseDecimal(%q)) = %s", s, got)
+		}
+	}
+
+	for _, s := range []string{"", "1/3", "abc"} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Errorf("ParseDecimal(%q): expected error", s)
+		}
+	}
+}
@@ -0,0 +1,658 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+)
+
+// maxPolynomialDegree bounds the degree of a polynomial, and so the work of its arithmetic.
+const maxPolynomialDegree = 1000
+
+// Polynomial is a polynomial in one variable with rational coefficients, the value of poly(x^2 - 1, x).
+// A polynomial of degree 0 or less, a constant, combines with a polynomial in any variable.
+type Polynomial struct {
+	variable string
+	coeffs   []*big.Rat // coeffs[i] is the coefficient of variable^i, without zeros at the end; none for 0
+}
+
+// newPolynomial returns the polynomial with the coefficients coeffs, lowest power first.
+func newPolynomial(variable string, coeffs []*big.Rat) Polynomial {
+	end := len(coeffs)
+	for end > 0 && coeffs[end-1].Sign() == 0 {
+		end--
+	}
+	return Polynomial{variable: variable, coeffs: coeffs[:end:end]}
+}
+
+// NewPolynomial returns the polynomial in variable with the coefficients coeffs, highest power first,
+// so NewPolynomial("x", 1, 0, -1) is x^2 - 1.
+func NewPolynomial(variable string, coeffs ...*big.Rat) (Polynomial, error) {
+	if err := ValidateName(variable); err != nil {
+		return Polynomial{}, err
+	}
+	if len(coeffs) > maxPolynomialDegree+1 {
+		return Polynomial{}, NewCalcError(ErrDomain, fmt.Sprintf("degree %d, at most %d", len(coeffs)-1, maxPolynomialDegree))
+	}
+	lowest := make([]*big.Rat, len(coeffs))
+	for i, c := range coeffs {
+		lowest[len(coeffs)-1-i] = new(big.Rat).Set(c)
+	}
+	return newPolynomial(variable, lowest), nil
+}
+
+// Variable returns the variable of the polynomial.
+func (p Polynomial) Variable() string {
+	return p.variable
+}
+
+// Degree returns the degree of the polynomial, -1 for the polynomial 0.
+func (p Polynomial) Degree() int {
+	return len(p.coeffs) - 1
+}
+
+// Coefficients returns the coefficients of the polynomial, highest power first; none for the polynomial 0.
+func (p Polynomial) Coefficients() []*big.Rat {
+	coeffs := make([]*big.Rat, len(p.coeffs))
+	for i, c := range p.coeffs {
+		coeffs[len(p.coeffs)-1-i] = new(big.Rat).Set(c)
+	}
+	return coeffs
+}
+
+// String formats the polynomial like a simplified expression, highest power first: x^2 - x/2 + 3.
+func (p Polynomial) String() string {
+	return format(p.node())
+}
+
+func (p Polynomial) node() *node {
+	x := &node{kind: identifierNode, token: p.variable}
+	var terms polynomial
+	for i, c := range p.coeffs {
+		if c.Sign() == 0 {
+			continue
+		}
+		m := monomial{coeff: c}
+		if i > 0 {
+			m.factors = []factor{newFactor(x, big.NewRat(int64(i), 1))}
+		}
+		terms = append(terms, m)
+	}
+	return terms.add(nil).node()
+}
+
+// Equal reports whether two polynomials are the same.
+func (p Polynomial) Equal(q Polynomial) bool {
+	if p.variable != q.variable && p.Degree() > 0 {
+		return false
+	}
+	return slices.EqualFunc(p.coeffs, q.coeffs, func(a, b *big.Rat) bool { return a.Cmp(b) == 0 })
+}
+
+// with returns p in the variable of an operation with q.
+func (p Polynomial) with(q Polynomial) (string, error) {
+	switch {
+	case p.variable == q.variable || q.Degree() <= 0:
+		return p.variable, nil
+	case p.Degree() <= 0:
+		return q.variable, nil
+	}
+	return "", NewCalcError(ErrTypeMismatch, fmt.Sprintf("polynomials in %s and %s", p.variable, q.variable))
+}
+
+// Add adds two polynomials; Sub, Mul, DivMod and GCD fail with ErrTypeMismatch for polynomials in different variables.
+func (p Polynomial) Add(q Polynomial) (Polynomial, error) {
+	variable, err := p.with(q)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	return newPolynomial(variable, addCoeffs(p.coeffs, q.coeffs, 1)), nil
+}
+
+// Sub subtracts q from p.
+func (p Polynomial) Sub(q Polynomial) (Polynomial, error) {
+	variable, err := p.with(q)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	return newPolynomial(variable, addCoeffs(p.coeffs, q.coeffs, -1)), nil
+}
+
+// Mul multiplies two polynomials, failing with ErrDomain above the degree 1000.
+func (p Polynomial) Mul(q Polynomial) (Polynomial, error) {
+	variable, err := p.with(q)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	if p.Degree()+q.Degree() > maxPolynomialDegree {
+		return Polynomial{}, NewCalcError(ErrDomain, fmt.Sprintf("degree %d, at most %d", p.Degree()+q.Degree(), maxPolynomialDegree))
+	}
+	return newPolynomial(variable, mulCoeffs(p.coeffs, q.coeffs)), nil
+}
+
+// DivMod divides p by q, returning the quotient and the remainder, whose degree is less than that of q.
+func (p Polynomial) DivMod(q Polynomial) (Polynomial, Polynomial, error) {
+	variable, err := p.with(q)
+	if err != nil {
+		return Polynomial{}, Polynomial{}, err
+	}
+	if len(q.coeffs) == 0 {
+		return Polynomial{}, Polynomial{}, NewCalcError(ErrDivisionByZero, "")
+	}
+	quo, rem := divCoeffs(p.coeffs, q.coeffs)
+	return newPolynomial(variable, quo), newPolynomial(variable, rem), nil
+}
+
+// GCD returns the greatest common divisor of p and q with the leading coefficient 1, or 0 if both are 0.
+func (p Polynomial) GCD(q Polynomial) (Polynomial, error) {
+	variable, err := p.with(q)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	return newPolynomial(variable, gcdCoeffs(p.coeffs, q.coeffs)), nil
+}
+
+// Pow raises the polynomial to a non-negative integer power, failing with ErrDomain above the degree 1000.
+func (p Polynomial) Pow(n int) (Polynomial, error) {
+	return p.pow(n, func(int) error { return nil })
+}
+
+// pow raises the polynomial to the power n by repeated squaring. Before each multiplication it calls spend
+// with the number of coefficient products it takes.
+func (p Polynomial) pow(n int, spend func(products int) error) (Polynomial, error) {
+	if n < 0 {
+		return Polynomial{}, NewCalcError(ErrDomain, fmt.Sprintf("(%s)^%d, expected a non-negative integer power", p, n))
+	}
+	if p.Degree() > 0 && n > maxPolynomialDegree/p.Degree() {
+		return Polynomial{}, degreeLimitError(p, n)
+	}
+	mul := func(a, b []*big.Rat) ([]*big.Rat, error) {
+		if err := spend(products(a, b)); err != nil {
+			return nil, err
+		}
+		return mulCoeffs(a, b), nil
+	}
+
+	result, square := []*big.Rat{big.NewRat(1, 1)}, p.coeffs
+	for ; n > 0; n >>= 1 {
+		var err error
+		if n&1 == 1 {
+			if result, err = mul(result, square); err != nil {
+				return Polynomial{}, err
+			}
+		}
+		// The last square is not needed, and could exceed the degree limit.
+		if n > 1 {
+			if square, err = mul(square, square); err != nil {
+				return Polynomial{}, err
+			}
+		}
+	}
+	return newPolynomial(p.variable, result), nil
+}
+
+// degreeLimitError reports a power of p whose degree would exceed maxPolynomialDegree.
+func degreeLimitError(p Polynomial, n any) error {
+	return NewCalcError(ErrDomain, fmt.Sprintf("(%s)^%v exceeds the degree limit of %d", p, n, maxPolynomialDegree))
+}
+
+// Derivative returns the derivative of the polynomial.
+func (p Polynomial) Derivative() Polynomial {
+	return newPolynomial(p.variable, derivativeCoeffs(p.coeffs))
+}
+
+// Eval returns the value of the polynomial at x.
+func (p Polynomial) Eval(x float64) float64 {
+	return real(p.evalComplex(complex(x, 0)))
+}
+
+func (p Polynomial) evalComplex(z complex128) complex128 {
+	var sum complex128
+	for i := len(p.coeffs) - 1; i >= 0; i-- {
+		c, _ := p.coeffs[i].Float64()
+		sum = sum*z + complex(c, 0)
+	}
+	return sum
+}
+
+func addCoeffs(a, b []*big.Rat, sign int64) []*big.Rat {
+	sum := make([]*big.Rat, max(len(a), len(b)))
+	for i := range sum {
+		sum[i] = new(big.Rat)
+		if i < len(a) {
+			sum[i].Set(a[i])
+		}
+		if i < len(b) {
+			sum[i].Add(sum[i], new(big.Rat).Mul(b[i], big.NewRat(sign, 1)))
+		}
+	}
+	return trimCoeffs(sum)
+}
+
+func scaleCoeffs(a []*big.Rat, c *big.Rat) []*big.Rat {
+	scaled := make([]*big.Rat, len(a))
+	for i := range a {
+		scaled[i] = new(big.Rat).Mul(a[i], c)
+	}
+	return trimCoeffs(scaled)
+}
+
+func mulCoeffs(a, b []*big.Rat) []*big.Rat {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	// The products are summed as integers over the common denominators of a and b: reducing every rational
+	// term would dominate the time of large powers.
+	x, dx := integerCoeffs(a)
+	y, dy := integerCoeffs(b)
+	sums := make([]big.Int, len(a)+len(b)-1)
+	term := new(big.Int)
+	for i := range x {
+		if x[i].Sign() == 0 {
+			continue
+		}
+		for j := range y {
+			if y[j].Sign() != 0 {
+				sums[i+j].Add(&sums[i+j], term.Mul(&x[i], &y[j]))
+			}
+		}
+	}
+
+	den := dx.Mul(dx, dy)
+	product := make([]*big.Rat, len(sums))
+	for i := range sums {
+		product[i] = new(big.Rat).SetFrac(&sums[i], den)
+	}
+	return trimCoeffs(product)
+}
+
+// integerCoeffs returns the coefficients times their least common denominator, and the denominator.
+func integerCoeffs(a []*big.Rat) ([]big.Int, *big.Int) {
+	den := big.NewInt(1)
+	g := new(big.Int)
+	for _, c := range a {
+		if !c.IsInt() {
+			g.GCD(nil, nil, den, c.Denom())
+			den.Mul(den, c.Denom()).Quo(den, g)
+		}
+	}
+	scaled := make([]big.Int, len(a))
+	for i, c := range a {
+		scaled[i].Mul(c.Num(), den).Quo(&scaled[i], c.Denom())
+	}
+	return scaled, den
+}
+
+// products returns the number of coefficient products mulCoeffs takes for a and b.
+func products(a, b []*big.Rat) int {
+	nonZero := func(c []*big.Rat) int {
+		n := 0
+		for _, x := range c {
+			if x.Sign() != 0 {
+				n++
+			}
+		}
+		return n
+	}
+	return nonZero(a) * nonZero(b)
+}
+
+// divCoeffs divides a by a non-zero b by long division.
+func divCoeffs(a, b []*big.Rat) (quo, rem []*big.Rat) {
+	rem = slices.Clone(a)
+	if len(a) < len(b) {
+		return nil, rem
+	}
+	quo = make([]*big.Rat, len(a)-len(b)+1)
+	lead := b[len(b)-1]
+	for i := len(quo) - 1; i >= 0; i-- {
+		c := new(big.Rat).Quo(rem[i+len(b)-1], lead)
+		quo[i] = c
+		for j, y := range b {
+			rem[i+j] = new(big.Rat).Sub(rem[i+j], new(big.Rat).Mul(c, y))
+		}
+	}
+	return trimCoeffs(quo), trimCoeffs(rem[:len(b)-1])
+}
+
+// gcdCoeffs is Euclid's algorithm, with the result made monic.
+func gcdCoeffs(a, b []*big.Rat) []*big.Rat {
+	for len(b) > 0 {
+		_, rem := divCoeffs(a, b)
+		a, b = b, rem
+	}
+	if len(a) == 0 {
+		return nil
+	}
+	return scaleCoeffs(a, new(big.Rat).Inv(a[len(a)-1]))
+}
+
+func derivativeCoeffs(a []*big.Rat) []*big.Rat {
+	if len(a) <= 1 {
+		return nil
+	}
+	d := make([]*big.Rat, len(a)-1)
+	for i := range d {
+		d[i] = new(big.Rat).Mul(a[i+1], big.NewRat(int64(i+1), 1))
+	}
+	return d
+}
+
+func evalCoeffs(a []*big.Rat, x *big.Rat) *big.Rat {
+	sum := new(big.Rat)
+	for i := len(a) - 1; i >= 0; i-- {
+		sum.Mul(sum, x)
+		sum.Add(sum, a[i])
+	}
+	return sum
+}
+
+func trimCoeffs(a []*big.Rat) []*big.Rat {
+	end := len(a)
+	for end > 0 && a[end-1].Sign() == 0 {
+		end--
+	}
+	return a[:end]
+}
+
+func isPolynomial(v Value) bool {
+	switch v.(type) {
+	case Polynomial, Factorization:
+		return true
+	}
+	return false
+}
+
+// asPolynomial returns an operand of a polynomial operation as a polynomial: a factorization multiplied out,
+// or a number as a constant in variable.
+func asPolynomial(v Value, variable string) (Polynomial, error) {
+	switch v := v.(type) {
+	case Polynomial:
+		return v, nil
+	case Factorization:
+		return v.Polynomial(), nil
+	case Real:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return Polynomial{}, NewCalcError(ErrDomain, v.String())
+		}
+		return newPolynomial(variable, []*big.Rat{exactRat(float64(v))}), nil
+	}
+	return Polynomial{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a polynomial is expected", typeName(v), v))
+}
+
+func polynomialValue(p Polynomial, err error) (Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyPolynomialOperator applies an operator to a polynomial and a polynomial or a number, charging products to the budget.
+func (e *Environment) applyPolynomialOperator(op string, a, b Value) (Value, error) {
+	variable := ""
+	for _, v := range []Value{b, a} {
+		if p, err := asPolynomial(v, ""); err == nil && p.variable != "" {
+			variable = p.variable
+		}
+	}
+	p, err := asPolynomial(a, variable)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType(op) == Pow {
+		n, err := toReal(b)
+		if err != nil {
+			return nil, err
+		}
+		if n != math.Trunc(n) || n < 0 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("(%s)^%s, expected a non-negative integer power", p, b))
+		}
+		if n > maxPolynomialDegree {
+			return nil, degreeLimitError(p, b)
+		}
+		return polynomialValue(p.pow(int(n), func(products int) error { return e.spend(op, products) }))
+	}
+
+	q, err := asPolynomial(b, variable)
+	if err != nil {
+		return nil, err
+	}
+	switch tokenType(op) {
+	case Add:
+		return polynomialValue(p.Add(q))
+	case Sub:
+		return polynomialValue(p.Sub(q))
+	case Multi:
+		if err := e.spend(op, products(p.coeffs, q.coeffs)); err != nil {
+			return nil, err
+		}
+		return polynomialValue(p.Mul(q))
+	case Div:
+		quo, rem, err := p.DivMod(q)
+		if err != nil {
+			return nil, err
+		}
+		if len(rem.coeffs) != 0 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("%s is not divisible by %s, polydiv returns the remainder", p, q))
+		}
+		return quo, nil
+	case Eq:
+		return Real(boolean(p.Equal(q))), nil
+	case NotEq:
+		return Real(boolean(!p.Equal(q))), nil
+	}
+	return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", typeName(a), op, typeName(b)))
+}
+
+// polynomialOf converts an expression to a polynomial in variable, evaluating the parts without it.
+func (e *Environment) polynomialOf(n *node, f *frame, variable string) (Polynomial, error) {
+	notPolynomial := func() error {
+		return NewCalcError(ErrDomain, fmt.Sprintf("%s is not a polynomial in %s", format(n), variable))
+	}
+	evaluated := func() (Polynomial, error) {
+		v, err := e.eval(n, f)
+		if err != nil {
+			return Polynomial{}, err
+		}
+		return asPolynomial(v, variable)
+	}
+
+	arithmetic := n.kind == operatorNode && slices.Contains([]tokenType{Neg, Add, Sub, Multi, Div, Pow}, tokenType(n.token))
+	switch {
+	case !dependsOn(n, variable) && !arithmetic:
+		return evaluated()
+	case n.kind == identifierNode:
+		return newPolynomial(variable, []*big.Rat{new(big.Rat), big.NewRat(1, 1)}), nil
+	case !arithmetic:
+		return Polynomial{}, notPolynomial()
+	}
+
+	switch tokenType(n.token) {
+	case Neg:
+		p, err := e.polynomialOf(n.args[0], f, variable)
+		if err != nil {
+			return Polynomial{}, err
+		}
+		return newPolynomial(variable, scaleCoeffs(p.coeffs, big.NewRat(-1, 1))), nil
+	}
+
+	a, err := e.polynomialOf(n.args[0], f, variable)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	if tokenType(n.token) == Pow {
+		if dependsOn(n.args[1], variable) {
+			return Polynomial{}, notPolynomial()
+		}
+		exp, err := e.eval(n.args[1], f)
+		if err != nil {
+			return Polynomial{}, err
+		}
+		if k, err := toReal(exp); err == nil && a.Degree() <= 0 && (k != math.Trunc(k) || k < 0) {
+			// A constant keeps its exact inverse, 3^-2 is 1/9; a fractional power is its decimal.
+			if k != math.Trunc(k) || len(a.coeffs) == 0 || -k > maxRaisedPower {
+				return evaluated()
+			}
+			a, exp = newPolynomial(variable, []*big.Rat{new(big.Rat).Inv(a.coeffs[0])}), Real(-k)
+		}
+		return toPolynomialResult(e.applyPolynomialOperator(n.token, a, exp))
+	}
+	b, err := e.polynomialOf(n.args[1], f, variable)
+	if err != nil {
+		return Polynomial{}, err
+	}
+	if tokenType(n.token) == Div && b.Degree() > 0 {
+		if _, rem, err := a.DivMod(b); err == nil && len(rem.coeffs) != 0 {
+			return Polynomial{}, notPolynomial()
+		}
+	}
+	return toPolynomialResult(e.applyPolynomialOperator(n.token, a, b))
+}
+
+func toPolynomialResult(v Value, err error) (Polynomial, error) {
+	if err != nil {
+		return Polynomial{}, err
+	}
+	return v.(Polynomial), nil
+}
+
+// poly(expr, x) is expr as a polynomial in x, e.g. poly((x - 1)^2, x) is x^2 - 2*x + 1;
+// poly([1, 0, -1], x) is the polynomial with these coefficients, highest power first, x^2 - 1.
+var polyForm = specialForm{minArgs: 2, maxArgs: 2, binds: bindsAlways, eval: func(e *Environment, args []*node, f *frame) (Value, error) {
+	variable := args[1]
+	if variable.kind != identifierNode || e.isConstant(variable.token) {
+		return nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("poly in %s, expected a variable", format(variable)))
+	}
+
+	if args[0].kind != listNode {
+		return e.polynomialOf(args[0], f, variable.token)
+	}
+	values, err := e.evalArgs(args[0].args, f)
+	if err != nil {
+		return nil, err
+	}
+	coeffs := make([]*big.Rat, len(values))
+	for i, v := range values {
+		c, err := asPolynomial(v, variable.token)
+		if err != nil {
+			return nil, err
+		}
+		if c.Degree() > 0 {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("coefficient %s", c))
+		}
+		coeffs[i] = new(big.Rat)
+		if len(c.coeffs) > 0 {
+			coeffs[i] = c.coeffs[0]
+		}
+	}
+	return polynomialValue(NewPolynomial(variable.token, coeffs...))
+}}
+
+// polynomialFunctions are the functions of polynomials. They are added to the special forms in init.
+var polynomialFunctions = map[string]specialForm{
+	"poly": polyForm,
+	"degree": polynomialFunction(1, func(p []Polynomial, _ []Value) (Value, error) {
+		return Real(p[0].Degree()), nil
+	}),
+	// coeffs(p) lists the coefficients, highest power first.
+	"coeffs": polynomialFunction(1, func(p []Polynomial, _ []Value) (Value, error) {
+		coeffs := make(List, len(p[0].coeffs))
+		for i, c := range p[0].Coefficients() {
+			f, _ := c.Float64()
+			coeffs[i] = Real(f)
+		}
+		return coeffs, nil
+	}),
+	// polyval(p, x) is the value of p at a number x, also a complex one.
+	"polyval": polynomialFunction(2, func(p []Polynomial, args []Value) (Value, error) {
+		z, ok := toComplex(args[1])
+		if !ok {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("polyval at %s %s", typeName(args[1]), args[1]))
+		}
+		if imag(z) == 0 {
+			return Real(p[0].Eval(real(z))), nil
+		}
+		return complexValue(p[0].evalComplex(z))
+	}),
+	"polyder": polynomialFunction(1, func(p []Polynomial, _ []Value) (Value, error) {
+		return p[0].Derivative(), nil
+	}),
+	// polydiv(p, q) is the list [quotient, remainder].
+	"polydiv": polynomialFunction(2, func(p []Polynomial, _ []Value) (Value, error) {
+		quo, rem, err := p[0].DivMod(p[1])
+		if err != nil {
+			return nil, err
+		}
+		return List{quo, rem}, nil
+	}),
+	"polygcd": polynomialFunction(2, func(p []Polynomial, _ []Value) (Value, error) {
+		return polynomialValue(p[0].GCD(p[1]))
+	}),
+	"factor": polynomialFunction(1, func(p []Polynomial, _ []Value) (Value, error) {
+		fz, err := p[0].Factor()
+		if err != nil {
+			return nil, err
+		}
+		return fz, nil
+	}),
+	// roots(p) lists the distinct real roots in ascending order, in complex mode the complex ones after them.
+	"roots": valueFunction(1, 1, func(e *Environment, args []Value) (Value, error) {
+		p, err := asPolynomial(args[0], "")
+		if err != nil {
+			return nil, err
+		}
+		roots, err := p.Roots()
+		if err != nil {
+			return nil, err
+		}
+		reals, complexRoots := List{}, List{}
+		for _, z := range roots {
+			if imag(z) == 0 {
+				reals = append(reals, Real(real(z)))
+			} else if e.options.Complex {
+				complexRoots = append(complexRoots, Complex(z))
+			}
+		}
+		return append(reals, complexRoots...), nil
+	}),
+}
+
+// polynomialFunction is a function of argc arguments whose first argc polynomials are converted
+// with asPolynomial; the polynomials and the arguments are passed to fn.
+func polynomialFunction(argc int, fn func(p []Polynomial, args []Value) (Value, error)) specialForm {
+	return valueFunction(argc, argc, func(_ *Environment, args []Value) (Value, error) {
+		variable := ""
+		for _, v := range args {
+			if p, ok := v.(Polynomial); ok && p.Degree() > 0 {
+				variable = p.variable
+			}
+		}
+		var ps []Polynomial
+		for _, v := range args {
+			if _, ok := toComplex(v); ok && len(ps) > 0 {
+				break
+			}
+			p, err := asPolynomial(v, variable)
+			if err != nil {
+				return nil, err
+			}
+			ps = append(ps, p)
+		}
+		return fn(ps, args)
+	})
+}
+
+// Polynomial converts an expression to a polynomial in variable with the variables, functions and options
+// of the environment, like poly(expr, variable): Polynomial(x^2/4 - 1, "x") has the coefficients 1/4, 0, -1.
+func (e *Environment) Polynomial(x *Expression, variable string) (Polynomial, error) {
+	if err := ValidateName(variable); err != nil {
+		return Polynomial{}, err
+	}
+	if e.isConstant(variable) {
+		return Polynomial{}, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("polynomial in %s, expected a variable", variable))
+	}
+	e.reset()
+	return e.polynomialOf(x.root, nil, variable)
+}
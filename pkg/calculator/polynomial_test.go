@@ -0,0 +1,208 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolynomial(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"poly((x - 1)^2, x)", "x^2 - 2*x + 1"},
+		{"poly([1, 0, -1], x)", "x^2 - 1"},
+		{"poly(x/3 - 1/3, x)", "x/3 - 1/3"},
+		{"poly(0.1*x, x) * 10", "x"},
+		{"a = 2; poly(a*t^2 + 3^-1, t)", "2*t^2 + 1/3"},
+		{"poly(x^2 - 1, x) / poly(x + 1, x)", "x - 1"},
+		{"p = poly(x + 1, x); p^3 - 3*p", "x^3 + 3*x^2 - 2"},
+		{"-poly(x - 2, x)", "-x + 2"},
+		{"poly(x^2, x) == poly(x*x, x)", "1"},
+		{"poly(x, x) - poly(x, x) + poly(y + 1, y)", "y + 1"},
+		{"degree(poly(x^3 + x, x))", "3"},
+		{"degree(poly(0, x))", "-1"},
+		{"coeffs(poly((x + 1)^3, x))", "[1, 3, 3, 1]"},
+		{"polyval(poly(x^2 + 1, x), 3)", "10"},
+		{"polyder(poly(x^3/3 + x, x))", "x^2 + 1"},
+		{"polydiv(poly(x^3 + 2, x), poly(x^2 + 1, x))", "[x, -x + 2]"},
+		{"polygcd(poly(x^2 - 1, x), poly(x^2 - 2*x + 1, x))", "x - 1"},
+		{"polygcd(poly(6*x + 6, x), poly(0, x))", "x + 1"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFactor(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"x^2 - 1", "(x - 1)*(x + 1)"},
+		{"2*x^3 - 4*x^2 + 2*x", "2*x*(x - 1)^2"},
+		{"-2*x^2 + 2", "-2*(x - 1)*(x + 1)"},
+		{"x^2/4 - 1/9", "1/36*(3*x - 2)*(3*x + 2)"},
+		{"2x^2 - x/2", "1/2*x*(4*x - 1)"},
+		{"-x^2/2 + 1/2", "-1/2*(x - 1)*(x + 1)"},
+		{"5/2", "5/2"},
+		{"x^2 + 1", "x^2 + 1"},
+		{"x^4 + 4", "(x^2 - 2*x + 2)*(x^2 + 2*x + 2)"},
+		{"x^6 - 1", "(x - 1)*(x + 1)*(x^2 - x + 1)*(x^2 + x + 1)"},
+		{"x^8 + x^4 + 1", "(x^2 - x + 1)*(x^2 + x + 1)*(x^4 - x^2 + 1)"},
+		{"(x^2 + 1)^3*(x^3 - 2)^2", "(x^2 + 1)^3*(x^3 - 2)^2"},
+		{"x^5 - x^4 - x + 1", "(x - 1)^2*(x + 1)*(x^2 + 1)"},
+		{"-3", "-3"},
+	}
+
+	for _, tc := range testCases {
+		x, err := Parse(tc.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned unexpected error: %v", tc.input, err)
+		}
+		p, err := NewEnvironment(Options{}).Polynomial(x, "x")
+		if err != nil {
+			t.Errorf("Polynomial(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		fz, err := p.Factor()
+		if err != nil {
+			t.Errorf("Factor(%s) returned unexpected error: %v", p, err)
+			continue
+		}
+		if fz.String() != tc.want {
+			t.Errorf("Factor(%s) = %s, want %s", p, fz, tc.want)
+		}
+		if !fz.Polynomial().Equal(p) {
+			t.Errorf("Factor(%s) multiplies out to %s", p, fz.Polynomial())
+		}
+	}
+
+	p, _ := NewPolynomial("x", big.NewRat(1, 1), new(big.Rat), new(big.Rat), new(big.Rat), new(big.Rat), big.NewRat(1000003*1000033, 1))
+	if _, err := p.Factor(); err == nil {
+		t.Errorf("Factor(%s) factored a polynomial with a constant coefficient above 1e12", p)
+	}
+}
+
+func TestRoots(t *testing.T) {
+	testCases := []struct {
+		input   string
+		complex bool
+		want    []complex128
+	}{
+		{"roots(poly(x^2 - 2, x))", false, []complex128{-1.4142135623730951, 1.4142135623730951}},
+		{"roots(poly(x^3 - 2*x, x))", false, []complex128{-1.4142135623730951, 0, 1.4142135623730951}},
+		{"roots(poly((x - 1)^3*(2*x + 1), x))", false, []complex128{-0.5, 1}},
+		{"roots(poly(x^3 - 2, x))", false, []complex128{1.2599210498948732}},
+		{"roots(poly(x^2 + 1, x))", false, nil},
+		{"roots(poly(x^2 + 1, x))", true, []complex128{-1i, 1i}},
+		{"roots(poly(x^4 + 4, x))", true, []complex128{-1 - 1i, -1 + 1i, 1 - 1i, 1 + 1i}},
+		{"roots(poly(x^5 - x - 1, x))", false, []complex128{1.1673039782614187}},
+		{"roots(poly(3, x))", false, nil},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Complex: tc.complex}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		roots, ok := got.(List)
+		if !ok || len(roots) != len(tc.want) {
+			t.Errorf("EvaluateValue(%q) = %s, want %v", tc.input, got, tc.want)
+			continue
+		}
+		for i, root := range roots {
+			z, _ := toComplex(root)
+			if math.Abs(real(z)-real(tc.want[i])) > 1e-12 || math.Abs(imag(z)-imag(tc.want[i])) > 1e-12 {
+				t.Errorf("EvaluateValue(%q) = %s, want %v", tc.input, got, tc.want)
+				break
+			}
+		}
+	}
+
+	// Too large to factor, the roots of x^10 + x + 1 are all found by the Aberth method.
+	x, _ := Parse("x^10 + x + 1")
+	p, _ := NewEnvironment(Options{}).Polynomial(x, "x")
+	roots, err := p.Roots()
+	if err != nil || len(roots) != 10 {
+		t.Fatalf("Roots(%s) = %v, %v, want 10 roots", p, roots, err)
+	}
+	for _, z := range roots {
+		if y := p.evalComplex(z); math.Hypot(real(y), imag(y)) > 1e-12 {
+			t.Errorf("Roots(%s) found %v, where it is %v", p, z, y)
+		}
+	}
+}
+
+func TestPolynomialErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  ErrorType
+	}{
+		{"poly(1/x, x)", ErrDomain},
+		{"poly(sin(x), x)", ErrDomain},
+		{"poly(x^y, x)", ErrUnknownIdentifier},
+		{"poly(x^1.5, x)", ErrDomain},
+		{"poly(x, 2)", ErrInvalidIdentifier},
+		{"poly(x, pi)", ErrInvalidIdentifier},
+		{"poly([1, poly(x, x)], x)", ErrTypeMismatch},
+		{"poly(x, x) + poly(y, y)", ErrTypeMismatch},
+		{"poly(x, x) / poly(x + 1, x)", ErrDomain},
+		{"poly(x, x) / 0", ErrDivisionByZero},
+		{"poly(x, x)^-1", ErrDomain},
+		{"poly(x, x)^1001", ErrDomain},
+		{"poly(x^100000, x)", ErrDomain},
+		{"poly(x, x) + 1 km", ErrTypeMismatch},
+		{"factor(poly(0, x))", ErrDomain},
+		{"roots(poly(0, x))", ErrDomain},
+		{"polydiv(poly(x, x), poly(0, x))", ErrDivisionByZero},
+		{"polyval(poly(x, x), 1 m)", ErrTypeMismatch},
+		{"degree(2 km)", ErrTypeMismatch},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+			t.Errorf("EvaluateValue(%q) error = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+
+	if _, err := NewEnvironment(Options{}).EvaluateValue("poly(x^100000, x)"); err == nil || !strings.Contains(err.Error(), "degree limit of 1000") {
+		t.Errorf(`EvaluateValue("poly(x^100000, x)") error = %v, want one naming the degree limit`, err)
+	}
+}
+
+func TestPolynomialPowerBudget(t *testing.T) {
+	start := time.Now()
+	got, err := NewEnvironment(Options{}).EvaluateValue("coeffs(poly((x + 1)^1000, x))")
+	if err != nil {
+		t.Fatalf("(x + 1)^1000 returned unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("(x + 1)^1000 took %v", elapsed)
+	}
+	if coeffs := got.(List); len(coeffs) != 1001 || coeffs[1].String() != "1000" {
+		t.Errorf("(x + 1)^1000 has coefficients %v...", coeffs[:2])
+	}
+
+	// The coefficient products count against the evaluation budget.
+	_, err = NewEnvironment(Options{MaxEvaluations: 10000}).EvaluateValue("poly((x + 1)^1000, x)")
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrEvaluationLimit {
+		t.Errorf("(x + 1)^1000 with a budget of 10000 error = %v, want %v", err, ErrEvaluationLimit)
+	}
+}
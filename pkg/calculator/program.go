@@ -0,0 +1,129 @@
+package calculator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type statementKind int
+
+const (
+	expressionStatement statementKind = iota
+	assignmentStatement
+	functionStatement
+)
+
+// statement is a single part of a program: an expression, an assignment such as a = 3
+// or a function definition such as f(x) = x^2 + 1.
+type statement struct {
+	kind   statementKind
+	name   string
+	params []string
+	body   *node
+}
+
+// parseProgram splits the input into statements separated by ";" or line breaks and parses each of them.
+func parseProgram(input string, options Options) ([]statement, error) {
+	tokens, err := tokenizeWithOptions(input, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []statement
+	for len(tokens) > 0 {
+		end := slices.Index(tokens, string(Semicolon))
+		if end < 0 {
+			end = len(tokens)
+		}
+
+		stmt, err := parseStatement(tokens[:end])
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+
+		tokens = tokens[min(end+1, len(tokens)):]
+	}
+
+	return statements, nil
+}
+
+func parseStatement(tokens []string) (statement, error) {
+	stmt := statement{kind: expressionStatement}
+
+	if assign := topLevelIndex(tokens, string(Assign)); assign >= 0 {
+		if topLevelIndex(tokens[assign+1:], string(Assign)) >= 0 {
+			return statement{}, NewCalcError(ErrMismatchOperator, "more than one '=' in a statement")
+		}
+
+		if lhs := tokens[:assign]; len(lhs) == 1 {
+			stmt = statement{kind: assignmentStatement, name: lhs[0]}
+		} else {
+			name, params, err := parseSignature(lhs)
+			if err != nil {
+				return statement{}, err
+			}
+			stmt = statement{kind: functionStatement, name: name, params: params}
+		}
+		tokens = tokens[assign+1:]
+	}
+
+	rpn, err := toRPN(tokens)
+	if err != nil {
+		return statement{}, err
+	}
+
+	stmt.body, err = buildTree(rpn)
+	if err != nil {
+		return statement{}, err
+	}
+
+	return stmt, nil
+}
+
+// topLevelIndex returns the index of the first occurrence of a token outside of any brackets, or -1.
+// An "=" inside brackets belongs to an equation, not to an assignment.
+func topLevelIndex(tokens []string, token string) int {
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case isOpening(t):
+			depth++
+		case tokenType(t) == BracketRight, tokenType(t) == ListRight:
+			depth--
+		case depth == 0 && t == token:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSignature reads the left-hand side of a function definition: name(param, ...).
+func parseSignature(tokens []string) (string, []string, error) {
+	head := strings.Join(tokens, "")
+
+	n := len(tokens)
+	if n < 3 || !isIdentifier(tokens[0]) || tokenType(tokens[1]) != BracketLeft || tokenType(tokens[n-1]) != BracketRight {
+		return "", nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("definition must look like name(params) = expression: %s", head))
+	}
+
+	var params []string
+	for i, token := range tokens[2 : n-1] {
+		if i%2 == 1 {
+			if tokenType(token) != Comma {
+				return "", nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("parameter list of %s", head))
+			}
+			continue
+		}
+		if !isIdentifier(token) {
+			return "", nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("parameter %q of %s", token, head))
+		}
+		params = append(params, token)
+	}
+	if n > 3 && tokenType(tokens[n-2]) == Comma {
+		return "", nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("parameter list of %s", head))
+	}
+
+	return tokens[0], params, nil
+}
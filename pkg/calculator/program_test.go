@@ -0,0 +1,75 @@
+package calculator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPrograms(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  float64
+	}{
+		{"a = 3; b = a * 2; a + b", 9},
+		{"a = 3\nb = a * 2\na + b", 9},
+		{"a = 3\r\n\r\nb = a *\n 2\n", 6},
+		{"x = 2; x = x + 1; x", 3},
+		{"rate = 0.2; net(x) = x * (1 - rate); net(100)", 80},
+		{"a = -(1 + 2)", -3},
+		{"f(x) = (x +\n 1)\nf(1)", 2},
+	}
+
+	for _, tc := range testCases {
+		got, err := Evaluate(tc.input)
+		if err != nil {
+			t.Errorf("Evaluate(%q) returned unexpected error: %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestProgramErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"b = a * 2; a = 3; b", ErrUnknownIdentifier},
+		{"pi = 3", ErrInvalidIdentifier},
+		{"a = b = 3", ErrMismatchOperator},
+		{"1 = 2", ErrMismatchOperator},
+		{"(a = 2)", ErrMismatchOperator},
+		{"a + 1 = 2", ErrMismatchOperator},
+		{"a b = 2", ErrInvalidIdentifier},
+		{"a = ", ErrTooManyValues},
+	}
+
+	for _, tc := range testCases {
+		_, err := Evaluate(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("Evaluate(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+}
+
+func TestEnvironmentVariables(t *testing.T) {
+	env := NewEnvironment(Options{})
+
+	got, err := env.Evaluate("a = 3; b = a * 2")
+	if err != nil || got != 6 {
+		t.Fatalf("expected 6, got %v, %v", got, err)
+	}
+	if want := map[string]float64{"a": 3, "b": 6}; !reflect.DeepEqual(env.Variables(), want) {
+		t.Errorf("Variables() = %v, want %v", env.Variables(), want)
+	}
+
+	if _, err = env.Evaluate("a = 10; c = a / 0"); err == nil {
+		t.Fatalf("expected division by zero")
+	}
+	if want := map[string]float64{"a": 3, "b": 6}; !reflect.DeepEqual(env.Variables(), want) {
+		t.Errorf("expected variables to be restored after a failure, got %v", env.Variables())
+	}
+}
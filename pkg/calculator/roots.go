@@ -0,0 +1,73 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxRootIterations bounds the iterations of findRoot; Brent's method needs far fewer for any float64 bracket.
+const maxRootIterations = 200
+
+// findRoot finds a root of f in the bracket [a, b], where f(a) and f(b) have opposite signs, by Brent's method:
+// inverse quadratic interpolation and secant steps, falling back to bisection whenever they converge slowly.
+// It fails with ErrNoConvergence if the bracket does not hold a sign change or f is not finite inside it.
+func findRoot(f func(float64) float64, a, b, tol float64) (float64, error) {
+	fa, fb := f(a), f(b)
+	switch {
+	case fa == 0:
+		return a, nil
+	case fb == 0:
+		return b, nil
+	case math.IsNaN(fa) || math.IsNaN(fb) || math.Signbit(fa) == math.Signbit(fb):
+		return 0, NewCalcError(ErrNoConvergence, fmt.Sprintf("no sign change in [%g, %g]", a, b))
+	}
+
+	if math.Abs(fa) < math.Abs(fb) {
+		a, b, fa, fb = b, a, fb, fa
+	}
+	c, fc := a, fa
+	d := b - a
+	bisected := true
+
+	for range maxRootIterations {
+		if fb == 0 || math.Abs(b-a) <= tol*(1+math.Abs(b)) {
+			return b, nil
+		}
+
+		var s float64
+		if fa != fc && fb != fc {
+			s = a*fb*fc/((fa-fb)*(fa-fc)) + b*fa*fc/((fb-fa)*(fb-fc)) + c*fa*fb/((fc-fa)*(fc-fb))
+		} else {
+			s = b - fb*(b-a)/(fb-fa)
+		}
+
+		lo, hi := (3*a+b)/4, b
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if s < lo || s > hi ||
+			(bisected && math.Abs(s-b) >= math.Abs(b-c)/2) ||
+			(!bisected && math.Abs(s-b) >= math.Abs(c-d)/2) {
+			s = (a + b) / 2
+			bisected = true
+		} else {
+			bisected = false
+		}
+
+		fs := f(s)
+		if math.IsNaN(fs) || math.IsInf(fs, 0) {
+			return 0, NewCalcError(ErrNoConvergence, fmt.Sprintf("undefined at %g", s))
+		}
+		d, c, fc = c, b, fb
+		if math.Signbit(fa) == math.Signbit(fs) {
+			a, fa = s, fs
+		} else {
+			b, fb = s, fs
+		}
+		if math.Abs(fa) < math.Abs(fb) {
+			a, b, fa, fb = b, a, fb, fa
+		}
+	}
+
+	return 0, NewCalcError(ErrNoConvergence, fmt.Sprintf("%d iterations", maxRootIterations))
+}
@@ -0,0 +1,108 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MaxSampleSteps bounds the steps of an axis of Sample.
+	MaxSampleSteps = 10000
+	// MaxSamplePoints bounds the points of Sample, the product of the points of its axes.
+	MaxSamplePoints = 100000
+)
+
+// Axis is a variable of Sample and the range it runs through.
+type Axis struct {
+	Variable string
+	From, To float64
+}
+
+// Sampling is the result of Sample. Points holds the values of each axis: steps+1 evenly spaced numbers
+// from From to To. Values are the values of the expression at every combination of the points, those of the
+// last axis running fastest, so for two axes the value at Points[0][i], Points[1][j] is Values[i*len(Points[1])+j].
+// A value is NaN where the expression is undefined.
+type Sampling struct {
+	Points [][]float64
+	Values []float64
+}
+
+// Sample evaluates an expression of one or two variables on a grid of steps steps along each axis, with the
+// variables, functions and options of the environment, for example to plot it. A point where the expression
+// has no value, such as 1/x at 0 or sqrt(x) below 0, is NaN rather than a failure; any other error, such as an
+// unknown variable, fails the whole sample. integrate, series, product and solve share one evaluation budget for all points.
+func (e *Environment) Sample(x *Expression, axes []Axis, steps int) (Sampling, error) {
+	if e.options.Integer.Enabled() {
+		return Sampling{}, NewCalcError(ErrTypeMismatch, "an expression can not be sampled in integer mode")
+	}
+	if len(axes) != 1 && len(axes) != 2 {
+		return Sampling{}, NewCalcError(ErrArgumentCount, fmt.Sprintf("sample expects 1 or 2 axes, got %d", len(axes)))
+	}
+	if steps < 1 || steps > MaxSampleSteps {
+		return Sampling{}, NewCalcError(ErrDomain, fmt.Sprintf("%d steps, expected 1 to %d", steps, MaxSampleSteps))
+	}
+	if points := math.Pow(float64(steps+1), float64(len(axes))); points > MaxSamplePoints {
+		return Sampling{}, NewCalcError(ErrDomain, fmt.Sprintf("%g points, at most %d", points, MaxSamplePoints))
+	}
+
+	sampling := Sampling{Points: make([][]float64, len(axes))}
+	for i, axis := range axes {
+		if err := ValidateName(axis.Variable); err != nil {
+			return Sampling{}, err
+		}
+		if e.isConstant(axis.Variable) || (i > 0 && axis.Variable == axes[0].Variable) {
+			return Sampling{}, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("axis %q", axis.Variable))
+		}
+		if math.IsNaN(axis.From) || math.IsInf(axis.From, 0) || math.IsNaN(axis.To) || math.IsInf(axis.To, 0) || axis.From == axis.To {
+			return Sampling{}, NewCalcError(ErrDomain, fmt.Sprintf("range of %s from %g to %g", axis.Variable, axis.From, axis.To))
+		}
+
+		points := make([]float64, steps+1)
+		for j := range points {
+			points[j] = axis.From + (axis.To-axis.From)*float64(j)/float64(steps)
+		}
+		points[steps] = axis.To
+		sampling.Points[i] = points
+	}
+
+	e.reset()
+	f := &frame{args: make(map[string]Value, len(axes))}
+	evaluate := func() (float64, error) {
+		v, err := e.eval(x.root, f)
+		if err != nil {
+			if isUndefined(err) {
+				return math.NaN(), nil
+			}
+			return 0, err
+		}
+		y, err := toReal(v)
+		if err != nil {
+			return 0, err
+		}
+		if math.IsInf(y, 0) {
+			return math.NaN(), nil
+		}
+		return y, nil
+	}
+
+	for _, a := range sampling.Points[0] {
+		f.args[axes[0].Variable] = Real(a)
+		if len(axes) == 1 {
+			y, err := evaluate()
+			if err != nil {
+				return Sampling{}, err
+			}
+			sampling.Values = append(sampling.Values, y)
+			continue
+		}
+		for _, b := range sampling.Points[1] {
+			f.args[axes[1].Variable] = Real(b)
+			y, err := evaluate()
+			if err != nil {
+				return Sampling{}, err
+			}
+			sampling.Values = append(sampling.Values, y)
+		}
+	}
+	return sampling, nil
+}
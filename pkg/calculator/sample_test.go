@@ -0,0 +1,77 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	x, err := Parse("1/x + sqrt(x + 1)")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	sampling, err := NewEnvironment(Options{}).Sample(x, []Axis{{Variable: "x", From: -2, To: 2}}, 4)
+	if err != nil {
+		t.Fatalf("Sample returned unexpected error: %v", err)
+	}
+	if !slices.Equal(sampling.Points[0], []float64{-2, -1, 0, 1, 2}) {
+		t.Errorf("Points = %v", sampling.Points)
+	}
+	want := []float64{math.NaN(), -1, math.NaN(), 1 + math.Sqrt2, 0.5 + math.Sqrt(3)}
+	if !slices.EqualFunc(sampling.Values, want, func(a, b float64) bool {
+		return a == b || math.IsNaN(a) && math.IsNaN(b)
+	}) {
+		t.Errorf("Values = %v, want %v", sampling.Values, want)
+	}
+
+	x, err = Parse("a*x - y")
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	env := NewEnvironment(Options{})
+	env.Set("a", 10)
+	sampling, err = env.Sample(x, []Axis{{Variable: "x", From: 0, To: 1}, {Variable: "y", From: 1, To: 0}}, 2)
+	if err != nil {
+		t.Fatalf("Sample returned unexpected error: %v", err)
+	}
+	if !slices.Equal(sampling.Points[1], []float64{1, 0.5, 0}) ||
+		!slices.Equal(sampling.Values, []float64{-1, -0.5, 0, 4, 4.5, 5, 9, 9.5, 10}) {
+		t.Errorf("Sample = %v", sampling)
+	}
+}
+
+func TestSampleErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		expr  string
+		axes  []Axis
+		steps int
+		want  ErrorType
+	}{
+		{"Unknown variable", "x + z", []Axis{{"x", 0, 1}}, 10, ErrUnknownIdentifier},
+		{"No axis", "x", nil, 10, ErrArgumentCount},
+		{"Three axes", "x", []Axis{{"x", 0, 1}, {"y", 0, 1}, {"z", 0, 1}}, 10, ErrArgumentCount},
+		{"No steps", "x", []Axis{{"x", 0, 1}}, 0, ErrDomain},
+		{"Too many points", "x*y", []Axis{{"x", 0, 1}, {"y", 0, 1}}, 1000, ErrDomain},
+		{"Empty range", "x", []Axis{{"x", 1, 1}}, 10, ErrDomain},
+		{"Same variable", "x", []Axis{{"x", 0, 1}, {"x", 0, 1}}, 10, ErrInvalidIdentifier},
+		{"Constant", "pi", []Axis{{"pi", 0, 1}}, 10, ErrInvalidIdentifier},
+		{"Not a real number", "[x, x]", []Axis{{"x", 0, 1}}, 10, ErrTypeMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			x, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("Parse returned unexpected error: %v", err)
+			}
+			_, err = NewEnvironment(Options{}).Sample(x, tc.axes, tc.steps)
+			var calcErr CalcError
+			if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+				t.Errorf("Sample error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
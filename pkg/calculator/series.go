@@ -0,0 +1,57 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxExactInteger is the largest bound of a series; every integer up to it is exact in a float64.
+const maxExactInteger = 1 << 53
+
+// series returns the evaluation of series(expr, i, a, b) or product(expr, i, a, b), which combine expr by op for the
+// integers i from a to b; an empty range gives empty. The terms are charged to the evaluation budget up front.
+func series(name string, op tokenType, empty Value) func(e *Environment, args []*node, f *frame) (Value, error) {
+	return func(e *Environment, args []*node, f *frame) (Value, error) {
+		variable := args[1]
+		if variable.kind != identifierNode || e.isConstant(variable.token) {
+			return nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("%s over %s, expected a variable", name, format(variable)))
+		}
+
+		values, err := e.evalArgs(args[2:], f)
+		if err != nil {
+			return nil, err
+		}
+		bounds := make([]float64, len(values))
+		for i, v := range values {
+			if bounds[i], err = toReal(v); err != nil {
+				return nil, err
+			}
+		}
+		from, to := bounds[0], bounds[1]
+		if from != math.Trunc(from) || to != math.Trunc(to) || math.Abs(from) > maxExactInteger || math.Abs(to) > maxExactInteger {
+			return nil, NewCalcError(ErrNotInteger, formatCall(name+" bounds", bounds))
+		}
+		if to < from {
+			return empty, nil
+		}
+		if err := e.spend(name, int(to-from)+1); err != nil {
+			return nil, err
+		}
+
+		var result Value
+		for i := from; i <= to; i++ {
+			term, err := e.evalWith(args[0], f, variable.token, Real(i))
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				result = term
+				continue
+			}
+			if result, err = e.operate(string(op), result, term); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,205 @@
+package calculator
+
+import (
+	"math"
+	"strconv"
+)
+
+// maxFoldedDigits is the longest number a constant subexpression is folded into: 1/4 becomes 0.25,
+// but 1/3 stays as it is rather than turning into 0.3333333333333333.
+const maxFoldedDigits = 12
+
+// simplify rewrites a tree bottom-up into an equivalent, usually smaller one: it folds constants and
+// drops neutral terms such as x*1, x + 0 and x^1. Like most computer algebra it takes x*0 and 0/x to be 0
+// without asking whether x is finite and non-zero, but 0/0 and x/x are kept, as they are undefined for x = 0.
+func simplify(n *node) *node {
+	if len(n.args) == 0 {
+		return n
+	}
+	args := make([]*node, len(n.args))
+	for i, arg := range n.args {
+		args[i] = simplify(arg)
+	}
+	return rewrite(&node{kind: n.kind, token: n.token, args: args})
+}
+
+// rewrite simplifies a node whose operands are already simplified.
+func rewrite(n *node) *node {
+	if folded, ok := fold(n); ok {
+		return folded
+	}
+	if n.kind != operatorNode {
+		return n
+	}
+
+	a := n.args[0]
+	var b *node
+	if len(n.args) > 1 {
+		b = n.args[1]
+	}
+
+	switch tokenType(n.token) {
+	case Neg:
+		switch {
+		case isNegation(a):
+			return a.args[0]
+		case isOperation(a, Multi) && a.args[0].kind == numberNode:
+			// -(2*x) is -2*x.
+			return rewrite(product(number(-a.args[0].value), a.args[1]))
+		case isOperation(a, Sub):
+			// -(a - b) is b - a.
+			return rewrite(minus(a.args[1], a.args[0]))
+		}
+	case Question:
+		if a.kind == numberNode {
+			if a.value != 0 {
+				return n.args[1]
+			}
+			return n.args[2]
+		}
+	case Add:
+		switch {
+		case isLiteral(a, 0):
+			return b
+		case isLiteral(b, 0):
+			return a
+		case isNegation(b):
+			return rewrite(minus(a, b.args[0]))
+		case b.kind == numberNode && b.value < 0:
+			return rewrite(minus(a, number(-b.value)))
+		case isNegation(a):
+			return rewrite(minus(b, a.args[0]))
+		}
+	case Sub:
+		switch {
+		case isLiteral(b, 0):
+			return a
+		case isLiteral(a, 0):
+			return rewrite(negation(b))
+		case equal(a, b):
+			return number(0)
+		case isNegation(b):
+			return rewrite(plus(a, b.args[0]))
+		case b.kind == numberNode && b.value < 0:
+			return rewrite(plus(a, number(-b.value)))
+		}
+	case Multi:
+		switch {
+		case isLiteral(a, 0), isLiteral(b, 0):
+			return number(0)
+		case isLiteral(a, 1):
+			return b
+		case isLiteral(b, 1):
+			return a
+		case isLiteral(a, -1):
+			return rewrite(negation(b))
+		case isLiteral(b, -1):
+			return rewrite(negation(a))
+		case isNegation(a):
+			return rewrite(negation(rewrite(product(a.args[0], b))))
+		case isNegation(b):
+			return rewrite(negation(rewrite(product(a, b.args[0]))))
+		case b.kind == numberNode && a.kind != numberNode:
+			// The coefficient goes first: x*2 is 2*x.
+			return rewrite(product(b, a))
+		case isOperation(b, Multi) && b.args[0].kind == numberNode:
+			// 2*(3*x) is 6*x and a*(2*x) is 2*a*x.
+			return rewrite(product(rewrite(product(b.args[0], a)), b.args[1]))
+		case isOperation(b, Div) && isLiteral(b.args[0], 1):
+			return rewrite(quotient(a, b.args[1]))
+		case isOperation(a, Div) && isLiteral(a.args[0], 1):
+			return rewrite(quotient(b, a.args[1]))
+		}
+	case Div:
+		switch {
+		case isLiteral(a, 0) && !isLiteral(b, 0):
+			return number(0)
+		case isLiteral(b, 1):
+			return a
+		case isOperation(a, Div):
+			// (a/b)/c is a/(b*c).
+			return rewrite(quotient(a.args[0], rewrite(product(a.args[1], b))))
+		case isNegation(a):
+			return rewrite(negation(rewrite(quotient(a.args[0], b))))
+		case isNegation(b):
+			return rewrite(negation(rewrite(quotient(a, b.args[0]))))
+		}
+	case Pow:
+		switch {
+		case isLiteral(b, 0), isLiteral(a, 1):
+			return number(1)
+		case isLiteral(b, 1):
+			return a
+		}
+	}
+	return n
+}
+
+// fold evaluates an operation or a call whose operands are all numbers, if the result is a short number.
+// Operands that are the constants pi and e are folded only into an integer, so ln(e) becomes 1 but 2*pi stays.
+func fold(n *node) (*node, bool) {
+	if n.kind != operatorNode && (n.kind != callNode || len(n.args) == 0) {
+		return nil, false
+	}
+	symbolic := false
+	for _, arg := range n.args {
+		switch {
+		case arg.kind == identifierNode && isConstantName(arg.token):
+			symbolic = true
+		case arg.kind != numberNode:
+			return nil, false
+		}
+	}
+
+	v, err := NewEnvironment(Options{}).eval(n, nil)
+	if err != nil {
+		return nil, false
+	}
+	x, ok := v.(Real)
+	if !ok || math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+		return nil, false
+	}
+	if symbolic && float64(x) != math.Trunc(float64(x)) {
+		return nil, false
+	}
+	if len(strconv.FormatFloat(math.Abs(float64(x)), 'f', -1, 64)) > maxFoldedDigits {
+		return nil, false
+	}
+	return number(float64(x)), true
+}
+
+func isConstantName(name string) bool {
+	_, ok := constants[name]
+	return ok
+}
+
+func isLiteral(n *node, x float64) bool {
+	return n.kind == numberNode && n.value == x
+}
+
+func isOperation(n *node, op tokenType) bool {
+	return n.kind == operatorNode && tokenType(n.token) == op
+}
+
+func isNegation(n *node) bool {
+	return isOperation(n, Neg)
+}
+
+// equal reports whether two trees are the same, number literals compared by value.
+func equal(a, b *node) bool {
+	if a.kind != b.kind || len(a.args) != len(b.args) {
+		return false
+	}
+	if a.kind == numberNode {
+		return a.value == b.value
+	}
+	if a.token != b.token {
+		return false
+	}
+	for i := range a.args {
+		if !equal(a.args[i], b.args[i]) {
+			return false
+		}
+	}
+	return true
+}
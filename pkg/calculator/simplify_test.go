@@ -0,0 +1,93 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"3 + x*2 - x", "x + 3"},
+		{"x*1 + 0", "x"},
+		{"2(x + 1)", "2*x + 2"},
+		{"x*y + y*x", "2*x*y"},
+		{"x^2*sin(x) + 2x*x^2", "2*x^3 + x^2*sin(x)"},
+		{"1 + x + x^2", "x^2 + x + 1"},
+		{"x/3", "x/3"},
+		{"y*2*x/4", "0.5*x*y"},
+		{"0.1 + 0.2 + x", "x + 0.3"},
+		{"(x + 1)*(1 + x)", "(x + 1)^2"},
+		{"-x - y", "-x - y"},
+		{"x*x*x", "x^3"},
+		{"1e5 + 2.5e-3*x", "0.0025*x + 100000"},
+		{"1/x + 2/x", "3/x"},
+		{"x^-2", "1/x^2"},
+		{"-x/y", "-x/y"},
+		{"(2x)^3", "8*x^3"},
+		{"(x^2)^0.5", "(x^2)^0.5"},
+		{"a*b^(1/3)", "a*b^(1/3)"},
+		{"x - x", "0"},
+		{"b == a && x", "a == b && x"},
+		{"sin(0 + y*1) + cos(x*0)", "sin(y) + 1"},
+		{"3 m + 2 m", "5*m"},
+		{"60 mi/h to m/s", "60*mi/h to m/s"},
+		{"1/0", "1/0"},
+		{"0/0", "0/0"},
+		{"0/x", "0"},
+	}
+
+	for _, tc := range testCases {
+		x, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		got := x.Simplify().String()
+		if got != tc.want {
+			t.Errorf("Simplify(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+		again, err := Parse(got)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", got, err)
+			continue
+		}
+		if s := again.Simplify().String(); s != got {
+			t.Errorf("Simplify(%q) = %q, want it unchanged", got, s)
+		}
+	}
+}
+
+// TestSimplifyKeepsValue compares the values of expressions and their canonical forms.
+func TestSimplifyKeepsValue(t *testing.T) {
+	inputs := []string{
+		"3 + x*2 - x*y/4", "(x + y)^2 / (y + x) - 2x", "x^3 * x^-1.5 / sqrt(x)", "2^x * 2^x + 1/(3x)",
+		"-(x - y)*(y - x)/7", "(2x*y)^-2 + x%", "x > y ? x^2 : y*2*y",
+	}
+
+	for _, input := range inputs {
+		x, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		s := x.Simplify()
+		for _, at := range [][2]float64{{0.7, 1.9}, {2.5, 0.3}} {
+			values := make([]float64, 2)
+			for i, e := range []*Expression{x, s} {
+				env := NewEnvironment(Options{})
+				env.Set("x", at[0])
+				env.Set("y", at[1])
+				v, err := env.EvaluateExpression(e)
+				if err != nil {
+					t.Fatalf("evaluating %s: %v", e, err)
+				}
+				values[i] = float64(v.(Real))
+			}
+			if math.Abs(values[0]-values[1]) > 1e-9*math.Max(1, math.Abs(values[0])) {
+				t.Errorf("Simplify(%q) = %s is %v at %v, want %v", input, s, values[1], at, values[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	// defaultSolveBound is the bound of the default interval [-1000, 1000] solve looks for roots in numerically.
+	defaultSolveBound = 1000
+	// defaultSolveTolerance is the default tolerance of the numeric roots.
+	defaultSolveTolerance = 1e-12
+	// solveGrid is the number of subintervals solve scans its interval in for sign changes and minima.
+	solveGrid = 2000
+	// maxNewtonIterations bounds the Newton iterations that refine a root where the function touches zero.
+	maxNewtonIterations = 100
+)
+
+// solve(lhs = rhs, x, [lo, hi], [tol]) returns the real values of the variable x for which the equation holds,
+// as a sorted list; an expression without "=" is an equation lhs = 0. Linear and quadratic equations are solved
+// in closed form, any others numerically in [lo, hi], by default [-1000, 1000]: Brent's method finds the roots
+// where lhs - rhs changes its sign on a grid of the interval, Newton's method those where it only touches zero.
+// tol is the tolerance of the numeric roots. The other names in the equation are evaluated as usual.
+var solveForm = specialForm{minArgs: 2, maxArgs: 5, binds: bindsAlways, eval: func(e *Environment, args []*node, f *frame) (Value, error) {
+	if e.options.Interval {
+		return nil, numericalInIntervalMode("solve")
+	}
+	if len(args) == 3 {
+		return nil, NewCalcError(ErrArgumentCount, "solve expects an interval of two bounds")
+	}
+	variable := args[1]
+	if variable.kind != identifierNode || e.isConstant(variable.token) {
+		return nil, NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("solve for %s, expected a variable", format(variable)))
+	}
+
+	lo, hi, tol := float64(-defaultSolveBound), float64(defaultSolveBound), defaultSolveTolerance
+	bounded := len(args) >= 4
+	if bounded {
+		values, err := e.evalArgs(args[2:], f)
+		if err != nil {
+			return nil, err
+		}
+		nums := make([]float64, len(values))
+		for i, v := range values {
+			if nums[i], err = toReal(v); err != nil {
+				return nil, err
+			}
+		}
+		lo, hi = nums[0], nums[1]
+		if len(nums) == 3 {
+			tol = nums[2]
+		}
+		if !(lo < hi) || math.IsInf(hi-lo, 0) || !(tol > 0) {
+			return nil, NewCalcError(ErrDomain, formatCall("solve interval and tolerance", nums))
+		}
+	}
+
+	s := solver{env: e, equation: equationSides(args[0]), variable: variable.token, frame: f}
+	roots, err := s.closedForm()
+	if err == errNotPolynomial {
+		roots, err = s.numeric(lo, hi, tol)
+	} else if bounded {
+		roots = slices.DeleteFunc(roots, func(r float64) bool { return r < lo || r > hi })
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range roots {
+		roots[i] += 0 // -0 is 0
+	}
+	return listOf(roots...), nil
+}}
+
+// equationSides returns lhs - rhs of an equation, or the expression itself.
+func equationSides(n *node) *node {
+	if n.kind == operatorNode && tokenType(n.token) == Assign {
+		return minus(n.args[0], n.args[1])
+	}
+	return n
+}
+
+// errNotPolynomial is returned by closedForm for an equation it can not solve.
+var errNotPolynomial = NewCalcError(ErrDomain, "not a polynomial equation of degree 1 or 2")
+
+type solver struct {
+	env      *Environment
+	equation *node // lhs - rhs
+	variable string
+	frame    *frame
+}
+
+// at evaluates lhs - rhs for a value of the variable.
+func (s solver) at(x float64) (float64, error) {
+	if err := s.env.spend("solve", 1); err != nil {
+		return 0, err
+	}
+	v, err := s.env.evalWith(s.equation, s.frame, s.variable, Real(x))
+	if err != nil {
+		return 0, err
+	}
+	return toReal(v)
+}
+
+// degree returns the degree of lhs - rhs as a polynomial in the variable, or -1 if it is none.
+func (s solver) degree() int {
+	degree := 0
+	for _, m := range toPolynomial(simplify(s.equation), true) {
+		for _, f := range m.factors {
+			if !dependsOn(f.base, s.variable) {
+				continue
+			}
+			if f.base.kind != identifierNode || !f.exp.IsInt() || f.exp.Sign() < 0 {
+				return -1
+			}
+			degree = max(degree, int(f.exp.Num().Int64()))
+		}
+	}
+	return degree
+}
+
+// closedForm solves a linear or quadratic equation, whose coefficients it finds from the values at -1, 0 and 1.
+func (s solver) closedForm() ([]float64, error) {
+	degree := s.degree()
+	if degree < 0 || degree > 2 {
+		return nil, errNotPolynomial
+	}
+
+	c := make([]float64, 3)
+	for i, x := range []float64{-1, 0, 1} {
+		y, err := s.at(x)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = y
+	}
+	a, b, c0 := (c[0]+c[2])/2-c[1], (c[2]-c[0])/2, c[1]
+
+	switch {
+	case degree == 2 && a != 0:
+		d := b*b - 4*a*c0
+		switch {
+		case math.Abs(d) <= 1e-12*b*b:
+			return []float64{-b / (2 * a)}, nil
+		case d < 0:
+			return nil, nil
+		}
+		// The larger root in magnitude first, the other from Vieta's formula, to avoid cancellation.
+		q := -(b + math.Copysign(math.Sqrt(d), b)) / 2
+		roots := []float64{q / a, c0 / q}
+		slices.Sort(roots)
+		return roots, nil
+	case degree >= 1 && b != 0:
+		return []float64{-c0 / b}, nil
+	case c0 == 0:
+		return nil, NewCalcError(ErrDomain, fmt.Sprintf("every value of %s solves %s = 0", s.variable, format(s.equation)))
+	}
+	return nil, nil
+}
+
+// numeric finds the roots in [lo, hi] on a grid of solveGrid subintervals.
+func (s solver) numeric(lo, hi, tol float64) ([]float64, error) {
+	var failure error
+	f := func(x float64) float64 {
+		y, err := s.at(x)
+		if err != nil {
+			// A point outside the domain of the equation is skipped, any other failure ends the search.
+			if !isUndefined(err) && failure == nil {
+				failure = err
+			}
+			return math.NaN()
+		}
+		return y
+	}
+
+	xs := make([]float64, solveGrid+1)
+	ys := make([]float64, solveGrid+1)
+	for i := range xs {
+		xs[i] = lo + (hi-lo)*float64(i)/solveGrid
+		ys[i] = f(xs[i])
+	}
+	if failure != nil {
+		return nil, failure
+	}
+
+	var roots []float64
+	for i := range solveGrid {
+		a, b, fa, fb := xs[i], xs[i+1], ys[i], ys[i+1]
+		switch {
+		case fa == 0:
+			roots = append(roots, a)
+		case math.IsNaN(fa) || math.IsNaN(fb) || math.Signbit(fa) == math.Signbit(fb):
+			continue
+		default:
+			r, err := findRoot(f, a, b, tol)
+			// At a pole such as that of tan the sign changes too, but the value does not vanish.
+			if err == nil && math.Abs(f(r)) <= 1e-6*math.Max(1, math.Min(math.Abs(fa), math.Abs(fb))) {
+				roots = append(roots, r)
+			}
+		}
+	}
+	if ys[solveGrid] == 0 {
+		roots = append(roots, hi)
+	}
+
+	// Where |f| has a minimum without a sign change, f may touch zero, as x^2 does.
+	for i := 1; i < solveGrid; i++ {
+		y, prev, next := math.Abs(ys[i]), math.Abs(ys[i-1]), math.Abs(ys[i+1])
+		if !(y < prev && y <= next) || math.Signbit(ys[i-1]) != math.Signbit(ys[i+1]) || ys[i] == 0 {
+			continue
+		}
+		if r, ok := newton(f, xs[i], tol); ok && r >= xs[i-1] && r <= xs[i+1] &&
+			math.Abs(f(r)) <= 1e-10*math.Max(1, math.Max(prev, next)) {
+			roots = append(roots, r)
+		}
+	}
+
+	if failure != nil {
+		return nil, failure
+	}
+
+	slices.Sort(roots)
+	return slices.CompactFunc(roots, func(a, b float64) bool {
+		return math.Abs(a-b) <= math.Max(100*tol, 1e-9*math.Max(1, math.Abs(a)))
+	}), nil
+}
+
+// newton refines a root from a guess by Newton's method with a numeric derivative.
+func newton(f func(float64) float64, x, tol float64) (float64, bool) {
+	for range maxNewtonIterations {
+		h := 1e-7 * math.Max(1, math.Abs(x))
+		y := f(x)
+		dy := (f(x+h) - f(x-h)) / (2 * h)
+		if y == 0 {
+			return x, true
+		}
+		if dy == 0 || math.IsNaN(dy) || math.IsNaN(y) {
+			return x, math.Abs(y) < tol
+		}
+		step := y / dy
+		x -= step
+		if math.Abs(step) <= tol*math.Max(1, math.Abs(x)) {
+			return x, true
+		}
+	}
+	return x, false
+}
@@ -0,0 +1,86 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  []float64
+	}{
+		{"solve(2x + 3 = 7, x)", []float64{2}},
+		{"solve(3 = 2 - x, x)", []float64{-1}},
+		{"solve(x^2 - 5x + 6, x)", []float64{2, 3}},
+		{"solve(x^2 = 2, x)", []float64{-math.Sqrt2, math.Sqrt2}},
+		{"solve((x - 1)^2 = 0, x)", []float64{1}},
+		{"solve(x^2 + 1 = 0, x)", nil},
+		{"solve(x*(x + 100000000) = 0, x)", []float64{-1e8, 0}},
+		{"solve(x + 1 = x, x)", nil},
+		{"solve(x^2 = 4, x, 0, 10)", []float64{2}},
+		{"a = 3; solve(a*t = 12, t)", []float64{4}},
+		{"f(x) = x^2 - 9; solve(f(x), x)", []float64{-3, 3}},
+		{"solve(x^3 - 6x^2 + 11x - 6 = 0, x)", []float64{1, 2, 3}},
+		{"solve(cos(x) = x, x)", []float64{0.7390851332151607}},
+		{"solve(sin(x), x, -4, 4)", []float64{-math.Pi, 0, math.Pi}},
+		{"solve(exp(x) = 10, x, 0, 5, 0.000000001)", []float64{math.Log(10)}},
+		{"solve((x - 2)^4, x, 0, 5)", []float64{2}},
+		{"solve(ln(x) = 1, x)", []float64{math.E}},
+		{"solve(tan(x), x, 1, 2)", nil},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		roots, ok := got.(List)
+		if !ok || len(roots) != len(tc.want) {
+			t.Errorf("EvaluateValue(%q) = %s, want %v", tc.input, got, tc.want)
+			continue
+		}
+		for i, want := range tc.want {
+			if r := float64(roots[i].(Real)); math.Abs(r-want) > 1e-6*math.Max(1, math.Abs(want)) {
+				t.Errorf("EvaluateValue(%q) = %s, want %v", tc.input, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  ErrorType
+	}{
+		{"solve(x = x, x)", ErrDomain},
+		{"solve(2x = 4, 2)", ErrInvalidIdentifier},
+		{"solve(2x = 4, pi)", ErrInvalidIdentifier},
+		{"solve(2x = 4)", ErrArgumentCount},
+		{"solve(2x = 4, x, 1)", ErrArgumentCount},
+		{"solve(sin(x), x, 2, 1)", ErrDomain},
+		{"solve(sin(x), x, 1, 2, 0)", ErrDomain},
+		{"solve(sin(x) = y, x)", ErrUnknownIdentifier},
+		{"1 + (x = 2)", ErrMismatchOperator},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+			t.Errorf("EvaluateValue(%q) error = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestValidateSolve(t *testing.T) {
+	if err := Validate("solve(x^2 = a, x)", "a"); err != nil {
+		t.Errorf("Validate returned unexpected error: %v", err)
+	}
+	if err := Validate("solve(x^2 = a, x) + x", "a"); err == nil {
+		t.Error("Validate accepted a variable outside of solve")
+	}
+}
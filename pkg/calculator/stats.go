@@ -0,0 +1,128 @@
+package calculator
+
+import (
+	"math"
+	"slices"
+)
+
+// statisticalFunctions take their data as arguments, as lists or both: mean(1, 2, 3), mean([1, 2, 3])
+// and mean([1, 2], 3) are the same. Nested lists are flattened.
+var statisticalFunctions = map[string]builtin{
+	"count": variadic(0, func(args []float64) (float64, error) {
+		return float64(len(args)), nil
+	}),
+	"sum": variadic(0, func(args []float64) (float64, error) {
+		return sum(args), nil
+	}),
+	"mean": variadic(0, func(args []float64) (float64, error) {
+		return sum(args) / float64(len(args)), nil
+	}),
+	"median": variadic(0, func(args []float64) (float64, error) {
+		return quantile(args, 0.5), nil
+	}),
+	// mode is the most frequent value; of several equally frequent ones the smallest.
+	"mode": variadic(0, func(args []float64) (float64, error) {
+		sorted := slices.Sorted(slices.Values(args))
+		mode, best := math.NaN(), 0
+		for i := 0; i < len(sorted); {
+			j := i
+			for j < len(sorted) && sorted[j] == sorted[i] {
+				j++
+			}
+			if j-i > best {
+				mode, best = sorted[i], j-i
+			}
+			i = j
+		}
+		return mode, nil
+	}),
+	// var and stdev are the sample variance and standard deviation, varp and stdevp those of a population.
+	"var": variadic(0, func(args []float64) (float64, error) {
+		return variance(args, 1), nil
+	}),
+	"varp": variadic(0, func(args []float64) (float64, error) {
+		return variance(args, 0), nil
+	}),
+	"stdev": variadic(0, func(args []float64) (float64, error) {
+		return math.Sqrt(variance(args, 1)), nil
+	}),
+	"stdevp": variadic(0, func(args []float64) (float64, error) {
+		return math.Sqrt(variance(args, 0)), nil
+	}),
+	// percentile(data, p) interpolates between the closest values like PERCENTILE.INC of spreadsheets,
+	// the last argument is the fraction p from 0 to 1: percentile([1, 2, 3, 4], 90%).
+	"percentile": variadic(2, func(args []float64) (float64, error) {
+		p := args[len(args)-1]
+		if p < 0 || p > 1 {
+			return 0, NewCalcError(ErrDomain, formatCall("percentile", args))
+		}
+		return quantile(args[:len(args)-1], p), nil
+	}),
+}
+
+// aggregates are the functions whose list arguments are flattened into their items.
+var aggregates = map[string]bool{"min": true, "max": true}
+
+func init() {
+	for name, f := range statisticalFunctions {
+		builtins[name] = f
+		aggregates[name] = true
+	}
+}
+
+// flatten replaces the lists among values by their items, recursively.
+func flatten(values []Value) []Value {
+	if !slices.ContainsFunc(values, isList) {
+		return values
+	}
+	var flat []Value
+	for _, v := range values {
+		if l, ok := v.(List); ok {
+			flat = append(flat, flatten(l)...)
+		} else {
+			flat = append(flat, v)
+		}
+	}
+	return flat
+}
+
+func isList(v Value) bool {
+	_, ok := v.(List)
+	return ok
+}
+
+func sum(xs []float64) float64 {
+	var s float64
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+// variance is the sum of the squared deviations from the mean divided by len(xs) - ddof:
+// ddof 1 gives the sample variance, 0 the population variance. Too few values give NaN, a domain error.
+func variance(xs []float64, ddof int) float64 {
+	if len(xs) <= ddof {
+		return math.NaN()
+	}
+	mean := sum(xs) / float64(len(xs))
+	var s float64
+	for _, x := range xs {
+		s += (x - mean) * (x - mean)
+	}
+	return s / float64(len(xs)-ddof)
+}
+
+// quantile interpolates linearly between the closest ranks; an empty data set gives NaN.
+func quantile(xs []float64, p float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	sorted := slices.Sorted(slices.Values(xs))
+	rank := p * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo == len(sorted)-1 {
+		return sorted[lo]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
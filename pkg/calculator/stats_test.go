@@ -0,0 +1,105 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"[1, 2, 3]", "[1, 2, 3]"},
+		{"[]", "[]"},
+		{"[1 + 2, 3 * 4, -5]", "[3, 12, -5]"},
+		{"[[1, 2], [3, 4]]", "[[1, 2], [3, 4]]"},
+		{"[1 km, 2 h]", "[1 km, 2 h]"},
+		{"data = [4, 8, 15]; data", "[4, 8, 15]"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestStatisticalFunctions(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"count(4, 8, 15)", "3"},
+		{"count([])", "0"},
+		{"sum([1, 2, 3], 4)", "10"},
+		{"sum([])", "0"},
+		{"mean(1, 2, 3, 4)", "2.5"},
+		{"mean([1, 2, 3, 4])", "2.5"},
+		{"mean([1, 2], [3, [4]])", "2.5"},
+		{"median([3, 1, 2])", "2"},
+		{"median([3, 1, 2, 10])", "2.5"},
+		{"mode(1, 2, 2, 3, 3)", "2"},
+		{"mode([7])", "7"},
+		{"var([2, 4, 4, 4, 5, 5, 7, 9])", "4.571428571428571"},
+		{"varp([2, 4, 4, 4, 5, 5, 7, 9])", "4"},
+		{"stdevp([2, 4, 4, 4, 5, 5, 7, 9])", "2"},
+		{"stdev(1, 3)", "1.4142135623730951"},
+		{"percentile([1, 2, 3, 4], 90%)", "3.7"},
+		{"percentile([15, 20, 35, 40, 50], 0.4)", "29"},
+		{"percentile([5, 1], 0)", "1"},
+		{"percentile([5, 1], 1)", "5"},
+		{"min([3, 1, 2])", "1"},
+		{"max([3, 1], 7, [2])", "7"},
+		{"max([1 km, 900 m])", "1 km"},
+		{"xs = [2, 4, 9]; mean(xs) + max(xs)", "14"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestStatisticalFunctionsErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"mean([])", ErrDomain},
+		{"median()", ErrDomain},
+		{"var(5)", ErrDomain},
+		{"stdev([5])", ErrDomain},
+		{"percentile([1, 2], 1.5)", ErrDomain},
+		{"percentile([1, 2])", ErrDomain},
+		{"percentile(0.5)", ErrArgumentCount},
+		{"sin([1, 2])", ErrTypeMismatch},
+		{"[1, 2)", ErrMismatchedParentheses},
+		{"(1, 2]", ErrMismatchOperator},
+		{"[1, 2", ErrMismatchedParentheses},
+		{"x[1]", ErrMismatchedParentheses},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+
+	if _, err := NewEnvironment(Options{Integer: IntegerMode{Bits: 32}}).EvaluateValue("[1, 2]"); err == nil {
+		t.Errorf("expected no lists in integer mode")
+	}
+}
@@ -0,0 +1,123 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	numberNode nodeKind = iota
+	identifierNode
+	operatorNode
+	callNode
+	stringNode
+	listNode
+)
+
+// node is an expression tree built from Reverse Polish Notation. The items of a list literal are the args of a listNode.
+type node struct {
+	kind  nodeKind
+	token string // number literal, identifier, operator, function name or string literal without the quotes
+	value float64
+	args  []*node
+}
+
+// buildTree converts an expression in Reverse Polish Notation into an expression tree.
+func buildTree(rpn []string) (*node, error) {
+	var stack []*node
+
+	pop := func(i int, token string, n int) ([]*node, error) {
+		if len(stack) < n {
+			return nil, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %s", i, operatorSpelling(token)))
+		}
+		args := make([]*node, n)
+		copy(args, stack[len(stack)-n:])
+		stack = stack[:len(stack)-n]
+		return args, nil
+	}
+
+	for i, token := range rpn {
+		switch {
+		// Identifiers go first: strconv would read "nan" or "inf" as numbers.
+		case isIdentifier(token):
+			stack = append(stack, &node{kind: identifierNode, token: token})
+		case isString(token):
+			stack = append(stack, &node{kind: stringNode, token: token[1 : len(token)-1]})
+		case isNumber(token):
+			num, err := parseNumber(token)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &node{kind: numberNode, token: token, value: num})
+		case isPrefixOperator(token), isPostfixOperator(token):
+			args, err := pop(i, token, 1)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &node{kind: operatorNode, token: token, args: args})
+		case tokenType(token) == Question:
+			// cond ? a : b arrives as cond (a : b) ?; both halves form a single three-operand node.
+			args, err := pop(i, token, 2)
+			if err != nil {
+				return nil, err
+			}
+			branches := args[1]
+			if branches.kind != operatorNode || tokenType(branches.token) != Colon {
+				return nil, NewCalcError(ErrMismatchOperator, fmt.Sprintf("'?' without ':', position %d", i))
+			}
+			args = []*node{args[0], branches.args[0], branches.args[1]}
+			stack = append(stack, &node{kind: operatorNode, token: token, args: args})
+		case isBinaryOperator(token):
+			args, err := pop(i, token, 2)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &node{kind: operatorNode, token: token, args: args})
+		case strings.HasPrefix(token, string(ListLeft)):
+			argc, ok := parseListToken(token)
+			if !ok {
+				return nil, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %s", i, token))
+			}
+			args, err := pop(i, token, argc)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &node{kind: listNode, args: args})
+		default:
+			name, argc, ok := parseCallToken(token)
+			if !ok {
+				return nil, NewCalcError(ErrInsufficientValues, fmt.Sprintf("position %d: %s", i, token))
+			}
+			args, err := pop(i, token, argc)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &node{kind: callNode, token: name, args: args})
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, NewCalcError(ErrTooManyValues, "")
+	}
+
+	if err := checkColons(stack[0]); err != nil {
+		return nil, err
+	}
+
+	return stack[0], nil
+}
+
+// checkColons rejects a ':' that is not part of a conditional expression.
+func checkColons(n *node) error {
+	if n.kind == operatorNode && tokenType(n.token) == Colon {
+		return NewCalcError(ErrMismatchOperator, "':' without '?'")
+	}
+	for _, arg := range n.args {
+		if err := checkColons(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
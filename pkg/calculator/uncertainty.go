@@ -0,0 +1,286 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Uncertain is a measured value with a standard uncertainty, the value of 9.81 ± 0.02 in uncertainty mode.
+// Uncertainties propagate to first order: the uncertainty of f(x, y) is that of x times the partial derivative
+// of f by x, combined with that of y. The value keeps the contribution of every independent source of uncertainty,
+// every ± evaluated, so that a result using the same measurement twice accounts for the correlation:
+// x - x is exact, and x*x has twice the relative uncertainty of x.
+type Uncertain struct {
+	value float64
+	terms []contribution // by source, without zero weights
+}
+
+// contribution is the part of the uncertainty of a value due to one source: the standard uncertainty of the source
+// times the sensitivity of the value to it.
+type contribution struct {
+	source int
+	weight float64
+}
+
+// Value returns the best estimate.
+func (x Uncertain) Value() float64 {
+	return x.value
+}
+
+// Uncertainty returns the combined standard uncertainty, the root sum of squares of the contributions.
+func (x Uncertain) Uncertainty() float64 {
+	u := 0.0
+	for _, t := range x.terms {
+		u = math.Hypot(u, t.weight)
+	}
+	return u
+}
+
+// String formats the value as 9.81 ± 0.02.
+func (x Uncertain) String() string {
+	return formatFloat(x.value) + " ± " + formatFloat(x.Uncertainty())
+}
+
+func isUncertain(v Value) bool {
+	_, ok := v.(Uncertain)
+	return ok
+}
+
+// toUncertain returns a number as an uncertain one, a real number as an exact one.
+func toUncertain(v Value) (Uncertain, error) {
+	switch v := v.(type) {
+	case Uncertain:
+		return v, nil
+	case Real:
+		return Uncertain{value: float64(v)}, nil
+	case Integer:
+		return Uncertain{value: v.Float64()}, nil
+	}
+	return Uncertain{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a measured value is expected", typeName(v), v))
+}
+
+// addTerms returns the contributions a plus scale times b.
+func addTerms(a, b []contribution, scale float64) []contribution {
+	result := make([]contribution, 0, len(a)+len(b))
+	add := func(source int, weight float64) {
+		if weight != 0 {
+			result = append(result, contribution{source: source, weight: weight})
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || i < len(a) && a[i].source < b[j].source:
+			add(a[i].source, a[i].weight)
+			i++
+		case i == len(a) || b[j].source < a[i].source:
+			add(b[j].source, scale*b[j].weight)
+			j++
+		default:
+			add(a[i].source, a[i].weight+scale*b[j].weight)
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// propagate returns the value y of a function of the arguments, with their uncertainties weighted by the partial
+// derivatives of the function. A derivative that is not finite where an argument is uncertain, like that of sqrt at 0,
+// fails with ErrDomain, as the first-order approximation does not hold there.
+func propagate(name string, y float64, partials []float64, args []Uncertain) (Value, error) {
+	result := Uncertain{value: y}
+	for i, a := range args {
+		if len(a.terms) == 0 {
+			continue
+		}
+		d := partials[i]
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("%s has no derivative at %s", name, formatFloat(a.value)))
+		}
+		result.terms = addTerms(result.terms, a.terms, d)
+	}
+	return result, nil
+}
+
+// applyUncertainOperator applies a binary operator to measured values or real numbers. x ± u makes u the standard
+// uncertainty of a new, independent source. The comparisons compare the best estimates.
+func (e *Environment) applyUncertainOperator(op string, a, b Value) (Value, error) {
+	x, err := toUncertain(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toUncertain(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType(op) == PlusMinus {
+		if len(y.terms) > 0 || y.value < 0 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("uncertainty %s of %s, it must be a non-negative number", b, a))
+		}
+		if y.value > 0 {
+			e.sources++
+			x.terms = addTerms(x.terms, []contribution{{source: e.sources, weight: y.value}}, 1)
+		}
+		return x, nil
+	}
+
+	v, err := applyOperator(op, x.value, y.value)
+	if err != nil {
+		return nil, err
+	}
+	args := []Uncertain{x, y}
+	switch tokenType(op) {
+	case Add:
+		return propagate(op, v, []float64{1, 1}, args)
+	case Sub:
+		return propagate(op, v, []float64{1, -1}, args)
+	case Multi:
+		return propagate(op, v, []float64{y.value, x.value}, args)
+	case Div:
+		return propagate(op, v, []float64{1 / y.value, -v / y.value}, args)
+	case Pow:
+		// d/dx x^y = y*x^(y-1), d/dy x^y = x^y*ln(x), which needs x > 0 only if y is uncertain.
+		dy := 0.0
+		if len(y.terms) > 0 {
+			dy = v * math.Log(x.value)
+		}
+		return propagate(op, v, []float64{y.value * math.Pow(x.value, y.value-1), dy}, args)
+	}
+	return Real(v), nil
+}
+
+// partialDerivatives are the partial derivatives of the built-in functions at the arguments x, where the function is y.
+// The other functions are differentiated numerically.
+var partialDerivatives = map[string]func(x []float64, y float64) []float64{
+	"abs":   func(x []float64, _ float64) []float64 { return []float64{sign(x[0])} },
+	"sqrt":  func(_ []float64, y float64) []float64 { return []float64{1 / (2 * y)} },
+	"cbrt":  func(_ []float64, y float64) []float64 { return []float64{1 / (3 * y * y)} },
+	"exp":   func(_ []float64, y float64) []float64 { return []float64{y} },
+	"ln":    func(x []float64, _ float64) []float64 { return []float64{1 / x[0]} },
+	"sin":   func(x []float64, _ float64) []float64 { return []float64{math.Cos(x[0])} },
+	"cos":   func(x []float64, _ float64) []float64 { return []float64{-math.Sin(x[0])} },
+	"tan":   func(_ []float64, y float64) []float64 { return []float64{1 + y*y} },
+	"asin":  func(x []float64, _ float64) []float64 { return []float64{1 / math.Sqrt(1-x[0]*x[0])} },
+	"acos":  func(x []float64, _ float64) []float64 { return []float64{-1 / math.Sqrt(1-x[0]*x[0])} },
+	"atan":  func(x []float64, _ float64) []float64 { return []float64{1 / (1 + x[0]*x[0])} },
+	"sinh":  func(x []float64, _ float64) []float64 { return []float64{math.Cosh(x[0])} },
+	"cosh":  func(x []float64, _ float64) []float64 { return []float64{math.Sinh(x[0])} },
+	"tanh":  func(_ []float64, y float64) []float64 { return []float64{1 - y*y} },
+	"floor": func([]float64, float64) []float64 { return []float64{0} },
+	"ceil":  func([]float64, float64) []float64 { return []float64{0} },
+	"round": func([]float64, float64) []float64 { return []float64{0} },
+	"trunc": func([]float64, float64) []float64 { return []float64{0} },
+	"re":    func([]float64, float64) []float64 { return []float64{1} },
+	"im":    func([]float64, float64) []float64 { return []float64{0} },
+	"conj":  func([]float64, float64) []float64 { return []float64{1} },
+	"arg":   func([]float64, float64) []float64 { return []float64{0} },
+	"atan2": func(x []float64, _ float64) []float64 {
+		r := x[0]*x[0] + x[1]*x[1]
+		return []float64{x[1] / r, -x[0] / r}
+	},
+	"hypot": func(x []float64, y float64) []float64 { return []float64{x[0] / y, x[1] / y} },
+	"log": func(x []float64, y float64) []float64 {
+		if len(x) == 1 {
+			return []float64{1 / (x[0] * math.Ln10)}
+		}
+		lnb := math.Log(x[1])
+		return []float64{1 / (x[0] * lnb), -y / (x[1] * lnb)}
+	},
+	"sum": func(x []float64, _ float64) []float64 {
+		partials := make([]float64, len(x))
+		for i := range partials {
+			partials[i] = 1
+		}
+		return partials
+	},
+	"mean": func(x []float64, _ float64) []float64 {
+		partials := make([]float64, len(x))
+		for i := range partials {
+			partials[i] = 1 / float64(len(x))
+		}
+		return partials
+	},
+	"min": selected,
+	"max": selected,
+}
+
+// sign is the derivative of abs, which has none at 0.
+func sign(x float64) float64 {
+	if x == 0 {
+		return math.NaN()
+	}
+	return math.Copysign(1, x)
+}
+
+// selected is the derivative of min and max: 1 by the argument that is the result, 0 by the others.
+func selected(x []float64, y float64) []float64 {
+	partials := make([]float64, len(x))
+	for i, v := range x {
+		if v == y {
+			partials[i] = 1
+			break
+		}
+	}
+	return partials
+}
+
+// differenceStep is the relative step of the central differences, the cube root of the float64 epsilon,
+// which balances the error of the approximation against the rounding error.
+var differenceStep = math.Cbrt(0x1p-52)
+
+// numericPartials estimates the partial derivatives by the uncertain arguments with central differences.
+func numericPartials(name string, b builtin, x []float64, args []Uncertain) ([]float64, error) {
+	partials := make([]float64, len(x))
+	shifted := make([]float64, len(x))
+	for i := range x {
+		if len(args[i].terms) == 0 {
+			continue
+		}
+		h := differenceStep * math.Max(math.Abs(x[i]), 1)
+		copy(shifted, x)
+		shifted[i] = x[i] + h
+		above, err := b.call(name, shifted)
+		if err != nil {
+			return nil, err
+		}
+		shifted[i] = x[i] - h
+		below, err := b.call(name, shifted)
+		if err != nil {
+			return nil, err
+		}
+		partials[i] = (above - below) / (2 * h)
+	}
+	return partials, nil
+}
+
+// callUncertain applies a built-in function to measured values or real numbers. The combinatorics and number theory
+// functions take exact integers only, like the factorial.
+func callUncertain(name string, b builtin, args []Value) (Value, error) {
+	xs := make([]Uncertain, len(args))
+	nums := make([]float64, len(args))
+	for i, a := range args {
+		x, err := toUncertain(a)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := integerFunctions[name]; ok && len(x.terms) > 0 {
+			return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s of %s, a measured value", name, a))
+		}
+		xs[i] = x
+		nums[i] = x.value
+	}
+
+	y, err := b.call(name, nums)
+	if err != nil {
+		return nil, err
+	}
+	var partials []float64
+	if d, ok := partialDerivatives[name]; ok {
+		partials = d(nums, y)
+	} else if partials, err = numericPartials(name, b, nums, xs); err != nil {
+		return nil, err
+	}
+	return propagate(name, y, partials, xs)
+}
@@ -0,0 +1,93 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestUncertainty(t *testing.T) {
+	testCases := []struct {
+		input       string
+		value       float64
+		uncertainty float64
+	}{
+		{"9.81 ± 0.02", 9.81, 0.02},
+		{"9.81 +/- 0.02", 9.81, 0.02},
+		{"(9.81 ± 0.02) * 2", 19.62, 0.04},
+		{"x = 2 ± 0.1; y = 3 ± 0.2; x*y", 6, 0.5},
+		{"x = 2 ± 0.1; y = 3 ± 0.2; x + y", 5, math.Sqrt(0.05)},
+		{"(9.81 ± 0.02) - (9.81 ± 0.02)", 0, math.Sqrt(0.0008)},
+		{"g = 9.81 ± 0.02; g - g", 0, 0},
+		{"x = 2 ± 0.1; x / x", 1, 0},
+		{"x = 2 ± 0.1; x^2", 4, 0.4},
+		{"x = 2 ± 0.1; x * x", 4, 0.4},
+		{"2^(3 ± 0.1)", 8, 0.8 * math.Ln2},
+		{"sqrt(4 ± 0.4)", 2, 0.1},
+		{"sin(0 ± 0.01)", 0, 0.01},
+		{"log(100 ± 1)", 2, 1 / (100 * math.Ln10)},
+		{"mean(1 ± 0.1, 3 ± 0.1)", 2, 0.1 / math.Sqrt2},
+		{"max(1 ± 0.1, 2 ± 0.2)", 2, 0.2},
+		{"f(x) = x^2 + 1; f(3 ± 0.1)", 10, 0.6},
+		{"(50 ± 1)%", 0.5, 0.01},
+		{"-(3 ± 0.1)", -3, 0.1},
+		{"l = 1.2 ± 0.01; t = 2.2 ± 0.02; 4*pi^2*l/t^2", 4 * math.Pi * math.Pi * 1.2 / (2.2 * 2.2),
+			4 * math.Pi * math.Pi * 1.2 / (2.2 * 2.2) * math.Hypot(0.01/1.2, 2*0.02/2.2)},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{Uncertainty: true}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		x, ok := got.(Uncertain)
+		if !ok {
+			t.Errorf("EvaluateValue(%q) = %s, want a measured value", tc.input, got)
+			continue
+		}
+		if math.Abs(x.Value()-tc.value) > 1e-12 || math.Abs(x.Uncertainty()-tc.uncertainty) > 1e-12 {
+			t.Errorf("EvaluateValue(%q) = %s, want %g ± %g", tc.input, x, tc.value, tc.uncertainty)
+		}
+	}
+
+	// Numerically differentiated functions propagate the uncertainty too.
+	got, err := NewEnvironment(Options{Uncertainty: true}).EvaluateValue("median(1, 2 ± 0.1, 5)")
+	x, ok := got.(Uncertain)
+	if err != nil || !ok || math.Abs(x.Uncertainty()-0.1) > 1e-9 {
+		t.Errorf("EvaluateValue(%q) = %v, %v, want 2 ± 0.1", "median(1, 2 ± 0.1, 5)", got, err)
+	}
+}
+
+func TestUncertaintyErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  ErrorType
+	}{
+		{"2 ± -1", ErrDomain},
+		{"2 ± (1 ± 0.1)", ErrDomain},
+		{"sqrt(0 ± 0.1)", ErrDomain},
+		{"(-2)^(2 ± 0.1)", ErrDomain},
+		{"abs(0 ± 0.1)", ErrDomain},
+		{"(3 ± 0.1)!", ErrTypeMismatch},
+		{"nCr(5 ± 0.1, 2)", ErrTypeMismatch},
+		{"gcd(4, 6 ± 1)", ErrTypeMismatch},
+		{"(3 ± 0.1) + 1 km", ErrTypeMismatch},
+		{"(3 ± 0.1) m", ErrTypeMismatch},
+		{"x = 3 ± 0.1; x * 1 m", ErrTypeMismatch},
+		{"(3 ± 0.1) / 0", ErrDivisionByZero},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Uncertainty: true}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.want {
+			t.Errorf("EvaluateValue(%q) error = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+
+	if _, err := NewEnvironment(Options{Uncertainty: true}).EvaluateValue("(3 ± 0.1) m"); err == nil || !strings.Contains(err.Error(), "has no unit") {
+		t.Errorf(`EvaluateValue("(3 ± 0.1) m") error = %v, want one saying that a measured value has no unit`, err)
+	}
+}
@@ -0,0 +1,480 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// dimension maps base units to their exponents, e.g. {m: 1, s: -1} for a speed.
+// Zero exponents are left out, so a dimensionless unit has an empty dimension.
+type dimension map[string]int
+
+// mul returns the dimension of a unit of dimension d multiplied by the k-th power of a unit of dimension o.
+func (d dimension) mul(o dimension, k int) dimension {
+	result := maps.Clone(d)
+	if result == nil {
+		result = make(dimension)
+	}
+	for base, exp := range o {
+		result[base] += exp * k
+		if result[base] == 0 {
+			delete(result, base)
+		}
+	}
+	return result
+}
+
+func (d dimension) equal(o dimension) bool {
+	return maps.Equal(d, o)
+}
+
+// unitDef is a registered unit: its size in base units and its dimension.
+type unitDef struct {
+	factor float64
+	dim    dimension
+}
+
+// Units is a registry of units of measurement. A unit is either a base unit, which is a dimension of its own,
+// or defined by an expression of units registered before, e.g. "km" as "1000 m" or "N" as "kg*m/s^2".
+// Define all units before the registry is shared between goroutines.
+type Units struct {
+	defs map[string]unitDef
+}
+
+// NewUnits returns an empty registry. StandardUnits returns one with the common units.
+func NewUnits() *Units {
+	return &Units{defs: make(map[string]unitDef)}
+}
+
+// DefineBase registers a base unit, which measures a dimension of its own.
+func (u *Units) DefineBase(name string) error {
+	return u.define(name, unitDef{factor: 1, dim: dimension{name: 1}})
+}
+
+// Define registers a unit given by an expression of numbers and registered units, e.g. Define("mi", "1609.344 m").
+func (u *Units) Define(name, definition string) error {
+	v, err := NewEnvironment(Options{Units: u}).EvaluateValue(definition)
+	if err != nil {
+		return err
+	}
+
+	var def unitDef
+	switch v := v.(type) {
+	case Real:
+		def = unitDef{factor: float64(v)}
+	case Quantity:
+		def = unitDef{factor: v.base(), dim: v.unit.dim}
+	default:
+		return NewCalcError(ErrTypeMismatch, fmt.Sprintf("unit %s = %s", name, v))
+	}
+	if def.factor <= 0 {
+		return NewCalcError(ErrDomain, fmt.Sprintf("unit %s = %s", name, v))
+	}
+
+	return u.define(name, def)
+}
+
+func (u *Units) define(name string, def unitDef) error {
+	// "in" is a conversion keyword, except right after a number, where it names the inch.
+	if !isIdentifier(name) || isConversionKeyword(name) && name != "in" {
+		return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("unit %q", name))
+	}
+	if _, ok := u.defs[name]; ok {
+		return NewCalcError(ErrInvalidIdentifier, fmt.Sprintf("unit %s is already defined", name))
+	}
+	u.defs[name] = def
+	return nil
+}
+
+// lookup returns the unit with the given name.
+func (u *Units) lookup(name string) (unit, bool) {
+	def, ok := u.defs[name]
+	if !ok {
+		return unit{}, false
+	}
+	return unit{terms: []unitTerm{{name: name, exp: 1}}, factor: def.factor, dim: def.dim}, true
+}
+
+// StandardUnits returns a new registry with the SI base units and the common metric, imperial and US units.
+// The inch is "inch", and "in" right after a number, as elsewhere "in" is the conversion keyword.
+func StandardUnits() *Units {
+	u := NewUnits()
+	for _, base := range []string{"m", "kg", "s", "A", "K", "mol", "cd"} {
+		mustDefine(u.DefineBase(base))
+	}
+
+	for _, def := range [][2]string{
+		// length
+		{"km", "1000 m"}, {"cm", "0.01 m"}, {"mm", "0.001 m"},
+		{"inch", "0.0254 m"}, {"in", "inch"}, {"ft", "0.3048 m"}, {"yd", "0.9144 m"}, {"mi", "1609.344 m"}, {"nmi", "1852 m"},
+		// mass
+		{"g", "0.001 kg"}, {"mg", "0.001 g"}, {"t", "1000 kg"}, {"lb", "0.45359237 kg"}, {"oz", "lb/16"},
+		// time
+		{"ms", "0.001 s"}, {"min", "60 s"}, {"h", "60 min"}, {"day", "24 h"}, {"d", "1 day"}, {"week", "7 day"},
+		// area and volume
+		{"ha", "10000 m^2"}, {"L", "0.001 m^3"}, {"l", "L"}, {"mL", "0.001 L"}, {"ml", "mL"}, {"gal", "3.785411784 L"},
+		// speed
+		{"kph", "km/h"}, {"mph", "mi/h"}, {"kn", "nmi/h"},
+		// force, pressure, energy and power
+		{"N", "kg*m/s^2"}, {"kN", "1000 N"}, {"Pa", "N/m^2"}, {"kPa", "1000 Pa"}, {"bar", "100000 Pa"},
+		{"J", "N*m"}, {"kJ", "1000 J"}, {"W", "J/s"}, {"kW", "1000 W"}, {"kWh", "kW*h"},
+	} {
+		mustDefine(u.Define(def[0], def[1]))
+	}
+
+	return u
+}
+
+func mustDefine(err error) {
+	if err != nil {
+		panic(fmt.Sprintf("calculator: standard units: %v", err))
+	}
+}
+
+// standardUnits is the registry used when Options.Units is not set. It is filled in init,
+// because the definitions are evaluated by the evaluator that looks it up.
+var standardUnits *Units
+
+func init() {
+	standardUnits = StandardUnits()
+}
+
+// unitTerm is a power of a registered unit.
+type unitTerm struct {
+	name string
+	exp  int
+}
+
+// unit is a product of powers of registered units, such as km/h or kg*m/s^2, with the terms in the order
+// they were written.
+type unit struct {
+	terms  []unitTerm
+	factor float64 // the size of the unit in base units
+	dim    dimension
+}
+
+// dimensionless is the unit of a plain number.
+var dimensionless = unit{factor: 1}
+
+// mul returns the unit u multiplied by the k-th power of o. Terms of the same unit are merged, so m/s*s is m.
+func (u unit) mul(o unit, k int) unit {
+	terms := slices.Clone(u.terms)
+	for _, t := range o.terms {
+		i := slices.IndexFunc(terms, func(x unitTerm) bool { return x.name == t.name })
+		if i < 0 {
+			terms = append(terms, unitTerm{name: t.name, exp: t.exp * k})
+			continue
+		}
+		terms[i].exp += t.exp * k
+		if terms[i].exp == 0 {
+			terms = slices.Delete(terms, i, i+1)
+		}
+	}
+	return unit{terms: terms, factor: u.factor * math.Pow(o.factor, float64(k)), dim: u.dim.mul(o.dim, k)}
+}
+
+// String writes the unit as kg*m/s^2 or J/(kg*K); a plain number has the empty unit.
+func (u unit) String() string {
+	var num, den []string
+	for _, t := range u.terms {
+		switch {
+		case t.exp == 1 || t.exp == -1:
+			if t.exp > 0 {
+				num = append(num, t.name)
+			} else {
+				den = append(den, t.name)
+			}
+		case t.exp > 0:
+			num = append(num, fmt.Sprintf("%s^%d", t.name, t.exp))
+		default:
+			den = append(den, fmt.Sprintf("%s^%d", t.name, -t.exp))
+		}
+	}
+
+	s := strings.Join(num, "*")
+	switch {
+	case len(den) == 0:
+		return s
+	case s == "":
+		s = "1"
+	}
+	if len(den) == 1 {
+		return s + "/" + den[0]
+	}
+	return s + "/(" + strings.Join(den, "*") + ")"
+}
+
+// describe names the unit in error messages, where a plain number has no unit to show.
+func (u unit) describe() string {
+	if len(u.terms) == 0 {
+		return "number"
+	}
+	return u.String()
+}
+
+// Quantity is a number with a unit of measurement, such as 3.25 km.
+type Quantity struct {
+	value float64
+	unit  unit
+}
+
+// Value returns the number of units.
+func (q Quantity) Value() float64 {
+	return q.value
+}
+
+// Unit returns the unit, e.g. km or kg*m/s^2.
+func (q Quantity) Unit() string {
+	return q.unit.String()
+}
+
+// String formats the quantity as 3.25 km. The number is rounded to 15 significant digits,
+// which hides the rounding errors of the conversion factors: 12 inch to ft is 1 ft.
+func (q Quantity) String() string {
+	return strconv.FormatFloat(q.value, 'g', 15, 64) + " " + q.unit.String()
+}
+
+// base returns the value in base units.
+func (q Quantity) base() float64 {
+	return q.value * q.unit.factor
+}
+
+// quantityValue turns the result of a computation with units into a Value. Units that cancel out
+// leave a Real: 3 km / 500 m is 6.
+func quantityValue(x float64, u unit) (Value, error) {
+	if math.IsNaN(x) {
+		return nil, NewCalcError(ErrDomain, "")
+	}
+	if len(u.dim) == 0 {
+		x *= u.factor
+	}
+	if x > 1e308 || x < -1e308 {
+		return nil, NewCalcError(ErrTooLargeNumber, fmt.Sprintf("%g %s", x, u))
+	}
+	if len(u.dim) == 0 {
+		return Real(x), nil
+	}
+	return Quantity{value: x, unit: u}, nil
+}
+
+func isQuantity(v Value) bool {
+	_, ok := v.(Quantity)
+	return ok
+}
+
+// toQuantity reads a real number as a quantity without a unit.
+func toQuantity(v Value) (Quantity, error) {
+	switch v := v.(type) {
+	case Quantity:
+		return v, nil
+	case Real:
+		return Quantity{value: float64(v), unit: dimensionless}, nil
+	}
+	return Quantity{}, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a quantity is expected", typeName(v), v))
+}
+
+// applyQuantityOperator applies a binary operator to numbers of which at least one has a unit.
+// Sums, differences and comparisons need operands of the same dimension; a sum or difference
+// is expressed in the unit of the left operand, so 3 km + 250 m is 3.25 km.
+func applyQuantityOperator(op string, a, b Value) (Value, error) {
+	if isUncertain(a) || isUncertain(b) {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s, a measured value has no unit",
+			typeName(a), operatorSpelling(op), typeName(b)))
+	}
+	x, err := toQuantity(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toQuantity(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenType(op) {
+	case Multi:
+		return quantityValue(x.value*y.value, x.unit.mul(y.unit, 1))
+	case Div:
+		if y.value == 0 {
+			return nil, NewCalcError(ErrDivisionByZero, "")
+		}
+		return quantityValue(x.value/y.value, x.unit.mul(y.unit, -1))
+	case Pow:
+		if len(y.unit.dim) != 0 {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("exponent %s", y))
+		}
+		k := y.base()
+		if k != math.Trunc(k) || math.Abs(k) > 64 {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s raised to %g", x.unit, k))
+		}
+		return quantityValue(math.Pow(x.value, k), dimensionless.mul(x.unit, int(k)))
+	case Add, Sub, Eq, NotEq, Less, LessEq, Greater, GreaterEq:
+		if !x.unit.dim.equal(y.unit.dim) {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s %s %s", x.unit.describe(), op, y.unit.describe()))
+		}
+		result, err := applyOperator(op, x.value, y.base()/x.unit.factor)
+		if err != nil {
+			return nil, err
+		}
+		if tokenType(op) == Add || tokenType(op) == Sub {
+			return quantityValue(result, x.unit)
+		}
+		return Real(result), nil
+	}
+	return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s %s", typeName(a), op, typeName(b)))
+}
+
+// convert expresses a quantity in another unit of the same dimension: 60 mi/h to m/s is 26.8224 m/s.
+func convert(v Value, target unit) (Value, error) {
+	x, err := toQuantity(v)
+	if err != nil {
+		return nil, err
+	}
+	if !x.unit.dim.equal(target.dim) {
+		return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s to %s", x.unit.describe(), target.describe()))
+	}
+	return Quantity{value: x.base() / target.factor, unit: target}, nil
+}
+
+// units returns the unit registry of the environment.
+func (e *Environment) units() *Units {
+	return e.options.units()
+}
+
+// units returns the unit registry of the options, StandardUnits if none is set.
+func (o Options) units() *Units {
+	if o.Units != nil {
+		return o.Units
+	}
+	return standardUnits
+}
+
+// evalUnit evaluates the target of a conversion: registered units combined with "*", "/" and integer powers.
+// Unlike in an expression, the names are always units here, even if a variable shadows one of them.
+func (e *Environment) evalUnit(n *node) (unit, error) {
+	switch n.kind {
+	case numberNode:
+		// 1/h is the unit per hour.
+		if n.value == 1 {
+			return dimensionless, nil
+		}
+	case identifierNode:
+		u, ok := e.units().lookup(n.token)
+		if !ok {
+			return unit{}, NewCalcError(ErrUnknownIdentifier, fmt.Sprintf("unit %s", n.token))
+		}
+		return u, nil
+	case operatorNode:
+		switch tokenType(n.token) {
+		case Multi, Div:
+			a, err := e.evalUnit(n.args[0])
+			if err != nil {
+				return unit{}, err
+			}
+			b, err := e.evalUnit(n.args[1])
+			if err != nil {
+				return unit{}, err
+			}
+			if tokenType(n.token) == Div {
+				return a.mul(b, -1), nil
+			}
+			return a.mul(b, 1), nil
+		case Pow:
+			a, err := e.evalUnit(n.args[0])
+			if err != nil {
+				return unit{}, err
+			}
+			if k, ok := unitExponent(n.args[1]); ok {
+				return dimensionless.mul(a, k), nil
+			}
+		}
+	}
+	return unit{}, NewCalcError(ErrMismatchOperator, "conversion target must be a unit such as m/s^2")
+}
+
+// unitExponent reads the exponent of a unit: an integer literal, possibly negated.
+func unitExponent(n *node) (int, bool) {
+	sign := 1
+	if n.kind == operatorNode && tokenType(n.token) == Neg {
+		sign, n = -1, n.args[0]
+	}
+	if n.kind != numberNode || n.value != math.Trunc(n.value) || math.Abs(n.value) > 64 {
+		return 0, false
+	}
+	return sign * int(n.value), true
+}
+
+// quantityFunctions are the built-in functions that keep the unit of their argument: round(2.4 km) is 2 km.
+var quantityFunctions = map[string]func(float64) float64{
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"trunc": math.Trunc,
+}
+
+// callQuantity applies a built-in function to arguments of which at least one is a quantity. Besides
+// quantityFunctions, min and max compare arguments of the same dimension and sqrt takes the root of the unit.
+func callQuantity(name string, args []Value) (Value, error) {
+	qs := make([]Quantity, len(args))
+	for i, a := range args {
+		q, err := toQuantity(a)
+		if err != nil {
+			return nil, err
+		}
+		qs[i] = q
+	}
+
+	switch name {
+	case "min", "max":
+		result := qs[0]
+		for _, q := range qs[1:] {
+			if !q.unit.dim.equal(result.unit.dim) {
+				return nil, NewCalcError(ErrDimension, fmt.Sprintf("%s(%s, %s)", name, result.unit.describe(), q.unit.describe()))
+			}
+			if (name == "min") == (q.base() < result.base()) {
+				result = q
+			}
+		}
+		return result, nil
+	case "sqrt":
+		if err := checkArity(name, len(qs), 1, 1); err != nil {
+			return nil, err
+		}
+		root, ok := qs[0].unit.sqrt()
+		if !ok {
+			return nil, NewCalcError(ErrDimension, fmt.Sprintf("sqrt(%s)", qs[0].unit))
+		}
+		if qs[0].value < 0 {
+			return nil, NewCalcError(ErrDomain, fmt.Sprintf("sqrt(%s)", qs[0]))
+		}
+		return quantityValue(math.Sqrt(qs[0].value), root)
+	}
+
+	fn, ok := quantityFunctions[name]
+	if !ok {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s of a quantity", name))
+	}
+	if err := checkArity(name, len(qs), 1, 1); err != nil {
+		return nil, err
+	}
+	return quantityValue(fn(qs[0].value), qs[0].unit)
+}
+
+// sqrt returns the square root of the unit, which exists if every power is even: sqrt of m^2 is m.
+func (u unit) sqrt() (unit, bool) {
+	root := unit{factor: math.Sqrt(u.factor), dim: make(dimension, len(u.dim))}
+	for _, t := range u.terms {
+		if t.exp%2 != 0 {
+			return unit{}, false
+		}
+		root.terms = append(root.terms, unitTerm{name: t.name, exp: t.exp / 2})
+	}
+	for base, exp := range u.dim {
+		root.dim[base] = exp / 2
+	}
+	return root, true
+}
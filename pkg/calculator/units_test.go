@@ -0,0 +1,159 @@
+package calculator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"3 km + 250 m", "3.25 km"},
+		{"250 m + 3 km", "3250 m"},
+		{"60 mi/h to m/s", "26.8224 m/s"},
+		{"5 kg * 10 m/s^2", "50 kg*m/s^2"},
+		{"5 kg * 10 m/s^2 to N", "50 N"},
+		{"2 m * 3 m", "6 m^2"},
+		{"(2 m)^2", "4 m^2"},
+		{"6 m^2 / (3 m)", "2 m"},
+		{"6 m^2 / 3 m", "2 m"},
+		{"1 m / 1 m", "1"},
+		{"10 km / 2 h", "5 km/h"},
+		{"1/2 s to 1/min", "30 1/min"},
+		{"4 / (2 s) to 1/min", "120 1/min"},
+		{"3 km / (500 m)", "6"},
+		{"1 kWh to kJ", "3600 kJ"},
+		{"1 day in s", "86400 s"},
+		{"72 in to ft", "6 ft"},
+		{"1 ft in in", "12 in"},
+		{"3in + 1 inch", "4 in"},
+		{"30 min to h", "0.5 h"},
+		{"12 inch to ft", "1 ft"},
+		{"1 t to lb to kg", "1000 kg"},
+		{"2 m^-1", "2 1/m"},
+		{"-3 m", "-3 m"},
+		{"3m", "3 m"},
+		{"3 m > 2 ft", "1"},
+		{"1 km == 1000 m", "1"},
+		{"x = 3 m; x + 1 m", "4 m"},
+		{"m = 5; m + 1", "6"},
+		{"m = 5; 2 km to m", "2000 m"},
+		{"f(d) = d / (40 km/h); f(120 km) to h", "3 h"},
+		{"50% * 4 kg", "2 kg"},
+		{"round(2.4 km)", "2 km"},
+		{"abs(-3 m)", "3 m"},
+		{"max(1 km, 900 m, 1.2 km)", "1.2 km"},
+		{"min(1 km, 900 m)", "900 m"},
+		{"sqrt(9 m^2)", "3 m"},
+		{"3 km > 1 km ? 1 h : 2 h", "1 h"},
+	}
+
+	for _, tc := range testCases {
+		got, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestUnitsErrors(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errType ErrorType
+	}{
+		{"3 m + 2 s", ErrDimension},
+		{"3 m + 2", ErrDimension},
+		{"3 m to s", ErrDimension},
+		{"3 to m", ErrDimension},
+		{"3 m > 2 kg", ErrDimension},
+		{"2^(3 m)", ErrDimension},
+		{"(2 m)^0.5", ErrDimension},
+		{"sqrt(2 m)", ErrDimension},
+		{"max(1 m, 1 s)", ErrDimension},
+		{"3 m to furlong", ErrUnknownIdentifier},
+		{"3 m to 2 m", ErrMismatchOperator},
+		{"3 m to m + 1", ErrMismatchOperator},
+		{"sin(3 m)", ErrTypeMismatch},
+		{"(3 m)!", ErrTypeMismatch},
+		{"3 m / (0 s)", ErrDivisionByZero},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+
+	if _, err := Evaluate("3 km"); err == nil {
+		t.Errorf("Evaluate(%q): expected error for a quantity result", "3 km")
+	}
+}
+
+func TestUnitsStrict(t *testing.T) {
+	env := NewEnvironment(Options{Strict: true})
+
+	got, err := env.EvaluateValue("90*km/h to m/s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.String() != "25 m/s" {
+		t.Errorf("got %s, want 25 m/s", got)
+	}
+
+	if _, err := env.EvaluateValue("3 km"); err == nil {
+		t.Errorf("expected error for implicit multiplication in strict mode")
+	}
+}
+
+func TestDefineUnits(t *testing.T) {
+	units := StandardUnits()
+	if err := units.DefineBase("pallet"); err != nil {
+		t.Fatalf("DefineBase: %v", err)
+	}
+	if err := units.Define("truck", "33 pallet"); err != nil {
+		t.Fatalf("Define: %v", err)
+	}
+	if err := units.Define("dozen", "12"); err != nil {
+		t.Fatalf("Define: %v", err)
+	}
+
+	env := NewEnvironment(Options{Units: units})
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"66 pallet to truck", "2 truck"},
+		{"2 truck * 500 kg/pallet", "1000 truck*kg/pallet"},
+		{"2 truck * 500 kg/pallet to t", "33 t"},
+		{"3 dozen", "36"},
+		{"36 to dozen", "3 dozen"},
+	}
+	for _, tc := range testCases {
+		got, err := env.EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+
+	if _, err := NewEnvironment(Options{}).EvaluateValue("1 pallet"); err == nil {
+		t.Errorf("expected the standard units to stay unchanged")
+	}
+
+	for _, def := range [][2]string{{"m", "1 m"}, {"to", "1 m"}, {"x y", "1 m"}, {"neg", "-1 m"}, {"bad", "1 m +"}} {
+		if err := units.Define(def[0], def[1]); err == nil {
+			t.Errorf("Define(%q, %q): expected error", def[0], def[1])
+		}
+	}
+}
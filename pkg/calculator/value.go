@@ -0,0 +1,245 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// Value is the result of an evaluation: a Real, a Quantity with a unit, a Money amount, a Time, a List,
+// a Polynomial, a Complex in complex mode, an Interval in interval mode,
+// an Uncertain in uncertainty mode or an Integer in integer mode.
+type Value interface {
+	// String formats the value with as many digits as needed to read it back exactly.
+	String() string
+}
+
+// Real is a real number.
+type Real float64
+
+func (n Real) String() string {
+	return formatFloat(float64(n))
+}
+
+// Complex is a complex number with a non-zero imaginary part. A complex result whose imaginary part is zero
+// is a Real.
+type Complex complex128
+
+// Real returns the real part.
+func (c Complex) Real() float64 {
+	return real(c)
+}
+
+// Imag returns the imaginary part.
+func (c Complex) Imag() float64 {
+	return imag(c)
+}
+
+// String formats the number as 3+4i, 3-4i or 2i.
+func (c Complex) String() string {
+	re, im := real(c), imag(c)
+	if re == 0 {
+		return formatFloat(im) + "i"
+	}
+
+	sign := "+"
+	if math.Signbit(im) {
+		sign = "-"
+	}
+	return formatFloat(re) + sign + formatFloat(math.Abs(im)) + "i"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// complexValue turns a complex result into a Value, a Real if its imaginary part is zero.
+func complexValue(c complex128) (Value, error) {
+	if cmplx.IsNaN(c) {
+		return nil, NewCalcError(ErrDomain, "")
+	}
+	if cmplx.IsInf(c) || math.Abs(real(c)) > 1e308 || math.Abs(imag(c)) > 1e308 {
+		return nil, NewCalcError(ErrTooLargeNumber, Complex(c).String())
+	}
+	if imag(c) == 0 {
+		return Real(real(c)), nil
+	}
+	return Complex(c), nil
+}
+
+// typeName names the type of a value in error messages.
+func typeName(v Value) string {
+	switch v.(type) {
+	case Real:
+		return "number"
+	case Complex:
+		return "complex number"
+	case Integer:
+		return "integer"
+	case Quantity:
+		return "quantity"
+	case Money:
+		return "amount"
+	case Time:
+		return "date"
+	case List:
+		return "list"
+	case Polynomial:
+		return "polynomial"
+	case Factorization:
+		return "factorization"
+	case Interval:
+		return "interval"
+	case Uncertain:
+		return "measured value"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toReal returns the real number a value holds.
+func toReal(v Value) (float64, error) {
+	switch v := v.(type) {
+	case Real:
+		return float64(v), nil
+	case Integer:
+		return v.Float64(), nil
+	case Interval:
+		if v.lo == v.hi {
+			return v.lo, nil
+		}
+	case Uncertain:
+		if len(v.terms) == 0 {
+			return v.value, nil
+		}
+	}
+	return 0, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s %s where a real number is expected", typeName(v), v))
+}
+
+// toComplex returns the complex number a number value holds.
+func toComplex(v Value) (complex128, bool) {
+	switch v := v.(type) {
+	case Real:
+		return complex(float64(v), 0), true
+	case Complex:
+		return complex128(v), true
+	}
+	return 0, false
+}
+
+// truthy reports whether a value counts as true: any non-zero number does.
+func truthy(v Value) bool {
+	switch v := v.(type) {
+	case Real:
+		return v != 0
+	case Complex:
+		return v != 0
+	case Integer:
+		return v.bits != 0
+	case Quantity:
+		return v.value != 0
+	case Money:
+		return v.amount.Sign() != 0
+	case Time:
+		return true
+	case List:
+		return len(v) > 0
+	case Polynomial:
+		return v.Degree() >= 0
+	case Factorization:
+		return true
+	case Interval:
+		return !v.contains(0)
+	case Uncertain:
+		return v.value != 0
+	}
+	return false
+}
+
+// applyComplexOperator applies a binary arithmetic or equality operator to complex numbers.
+func applyComplexOperator(op string, a, b complex128) (Value, error) {
+	switch tokenType(op) {
+	case Add:
+		return complexValue(a + b)
+	case Sub:
+		return complexValue(a - b)
+	case Multi:
+		return complexValue(a * b)
+	case Div:
+		if b == 0 {
+			return nil, NewCalcError(ErrDivisionByZero, "")
+		}
+		return complexValue(a / b)
+	case Pow:
+		if a == 0 && real(b) < 0 {
+			return nil, NewCalcError(ErrDivisionByZero, "")
+		}
+		return complexValue(complexPow(a, b))
+	case Eq:
+		return Real(boolean(a == b)), nil
+	case NotEq:
+		return Real(boolean(a != b)), nil
+	case Less, LessEq, Greater, GreaterEq:
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("complex numbers are not ordered: %s %s %s", Complex(a), op, Complex(b)))
+	}
+	return nil, NewCalcError(ErrMismatchOperator, op)
+}
+
+// complexPow raises a to the power b. An integer power is computed by repeated multiplication,
+// which keeps (1+i)^2 exactly 2i where cmplx.Pow would leave rounding errors in both parts.
+func complexPow(a, b complex128) complex128 {
+	n := real(b)
+	if imag(b) != 0 || n != math.Trunc(n) || math.Abs(n) > 1024 {
+		return cmplx.Pow(a, b)
+	}
+
+	result, base := complex(1, 0), a
+	for k := int(math.Abs(n)); k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result *= base
+		}
+		base *= base
+	}
+	if n < 0 {
+		return 1 / result
+	}
+	return result
+}
+
+// complexFunctions are the complex variants of the built-in functions. In complex mode they are used when
+// an argument is complex or the real function is not defined for a real argument, so sqrt(-4) is 2i.
+var complexFunctions = map[string]func(complex128) complex128{
+	"abs":  func(c complex128) complex128 { return complex(cmplx.Abs(c), 0) },
+	"arg":  func(c complex128) complex128 { return complex(cmplx.Phase(c), 0) },
+	"conj": cmplx.Conj,
+	"re":   func(c complex128) complex128 { return complex(real(c), 0) },
+	"im":   func(c complex128) complex128 { return complex(imag(c), 0) },
+	"sqrt": cmplx.Sqrt,
+	"exp":  cmplx.Exp,
+	"ln":   cmplx.Log,
+	"log":  cmplx.Log10,
+	"sin":  cmplx.Sin,
+	"cos":  cmplx.Cos,
+	"tan":  cmplx.Tan,
+	"asin": cmplx.Asin,
+	"acos": cmplx.Acos,
+	"atan": cmplx.Atan,
+	"sinh": cmplx.Sinh,
+	"cosh": cmplx.Cosh,
+	"tanh": cmplx.Tanh,
+}
+
+// callComplex applies the complex variant of a built-in function.
+func callComplex(name string, fn func(complex128) complex128, args []Value) (Value, error) {
+	if err := checkArity(name, len(args), 1, 1); err != nil {
+		return nil, err
+	}
+	c, ok := toComplex(args[0])
+	if !ok {
+		return nil, NewCalcError(ErrTypeMismatch, fmt.Sprintf("%s(%s)", name, typeName(args[0])))
+	}
+	if c == 0 && (name == "ln" || name == "log") {
+		return nil, NewCalcError(ErrDomain, fmt.Sprintf("%s(0)", name))
+	}
+	return complexValue(fn(c))
+}
@@ -0,0 +1,120 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestComplexMode(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  string
+	}{
+		{"sqrt(-4)", "2i"},
+		{"3+4i", "3+4i"},
+		{"3-4i", "3-4i"},
+		{"-i", "-1i"},
+		{"i*i", "-1"},
+		{"abs(3+4i)", "5"},
+		{"conj(3+4i)", "3-4i"},
+		{"re(3+4i) + im(3+4i)", "7"},
+		{"(1+i)^2", "2i"},
+		{"1/(1+i)", "0.5-0.5i"},
+		{"z = 1 + 2i; z * conj(z)", "5"},
+		{"ln(-1)", "3.141592653589793i"},
+		{"sqrt(4)", "2"},
+		{"(3+4i) == 3+4i", "1"},
+		{"i ? 1 : 2", "1"},
+		{"f(z) = z^2 + 1; f(i)", "0"},
+		{"50% * 2i", "1i"},
+	}
+
+	env := NewEnvironment(Options{Complex: true})
+	for _, tc := range testCases {
+		got, err := env.EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("EvaluateValue(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestComplexFunctions(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  complex128
+	}{
+		{"arg(i)", complex(math.Pi/2, 0)},
+		{"exp(i*pi)", -1},
+		{"(-8)^(1/3)", cmplx.Pow(-8, complex(1.0/3, 0))},
+		{"asin(2)", cmplx.Asin(2)},
+		{"sin(1+i)", cmplx.Sin(1 + 1i)},
+		{"log(-100)", cmplx.Log10(-100)},
+	}
+
+	env := NewEnvironment(Options{Complex: true})
+	for _, tc := range testCases {
+		got, err := env.EvaluateValue(tc.input)
+		if err != nil {
+			t.Errorf("EvaluateValue(%q) returned unexpected error: %v", tc.input, err)
+			continue
+		}
+		c, _ := toComplex(got)
+		if cmplx.Abs(c-tc.want) > 1e-12 {
+			t.Errorf("EvaluateValue(%q) = %s, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestComplexModeErrors(t *testing.T) {
+	testCases := []struct {
+		complex bool
+		input   string
+		errType ErrorType
+	}{
+		{true, "i < 1", ErrTypeMismatch},
+		{true, "max(i, 1)", ErrTypeMismatch},
+		{true, "nCr(i, 1)", ErrTypeMismatch},
+		{true, "(2i)!", ErrTypeMismatch},
+		{true, "i = 2", ErrInvalidIdentifier},
+		{true, "ln(0)", ErrDomain},
+		{true, "1 / (0i)", ErrDivisionByZero},
+		{true, "0^(-1+i)", ErrDivisionByZero},
+		{false, "sqrt(-4)", ErrDomain},
+		{false, "(-8)^(1/3)", ErrDomain},
+		{false, "i", ErrUnknownIdentifier},
+	}
+
+	for _, tc := range testCases {
+		_, err := NewEnvironment(Options{Complex: tc.complex}).EvaluateValue(tc.input)
+		var calcErr CalcError
+		if !errors.As(err, &calcErr) || calcErr.Type != tc.errType {
+			t.Errorf("EvaluateValue(%q) error = %v, want error type %v", tc.input, err, tc.errType)
+		}
+	}
+
+	_, err := NewEnvironment(Options{Complex: true}).Evaluate("sqrt(-4)")
+	var calcErr CalcError
+	if !errors.As(err, &calcErr) || calcErr.Type != ErrTypeMismatch {
+		t.Errorf("Evaluate(\"sqrt(-4)\") error = %v, want a type mismatch", err)
+	}
+}
+
+func TestComplexVariables(t *testing.T) {
+	env := NewEnvironment(Options{Complex: true})
+	if _, err := env.EvaluateValue("z = 3 + 4i; r = abs(z)"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vars := env.Variables(); len(vars) != 1 || vars["r"] != 5 {
+		t.Errorf("Variables() = %v, want only the real r = 5", vars)
+	}
+	if values := env.Values(); values["z"] != Complex(3+4i) || values["r"] != Real(5) {
+		t.Errorf("Values() = %v", values)
+	}
+}